@@ -7,12 +7,13 @@ import (
 	"runtime/debug"
 
 	"github.com/vladgrskkh/todo/config"
+	"github.com/vladgrskkh/todo/internal/domain"
 	"github.com/vladgrskkh/todo/internal/handlers/middleware/metrics"
 	"github.com/vladgrskkh/todo/internal/handlers/routes"
 	"github.com/vladgrskkh/todo/internal/repository"
 	"github.com/vladgrskkh/todo/internal/server"
 	"github.com/vladgrskkh/todo/internal/service"
-	"github.com/vladgrskkh/todo/pkg/envload"
+	"github.com/vladgrskkh/todo/internal/webhook"
 	"github.com/vladgrskkh/todo/pkg/inmemorydb"
 )
 
@@ -21,23 +22,39 @@ func main() {
 	var envPath string
 
 	flag.StringVar(&envPath, "envpath", ".env", "set path to .env file")
+	config.RegisterFlags(flag.CommandLine)
 
 	flag.Parse()
 
-	logger.Info("loading environment variables")
-	err := envload.Load(envPath, true)
+	// flags override environment variables, which override the .env file
+	logger.Info("loading config")
+	cfg, err := config.Load(flag.CommandLine, envPath)
 	if err != nil {
 		logger.Error(err.Error())
+		os.Exit(1)
 	}
 
-	logger.Info("loading config")
-	cfg, err := config.New()
-	if err != nil {
-		logger.Error(err.Error())
+	logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: cfg.LogLevel}))
+
+	dbOpts := []inmemorydb.Option{
+		inmemorydb.WithFlushInterval(cfg.DBFlushInterval),
+		inmemorydb.WithBufferSize(cfg.DBBufferSize),
+		inmemorydb.WithFlushEvery(cfg.DBFlushEvery),
+		inmemorydb.WithWriteBehind(cfg.DBWriteQueueSize),
+	}
+	if cfg.DBRestoreFromBackup {
+		dbOpts = append(dbOpts, inmemorydb.WithBackupFallback(func(err error) {
+			logger.Warn("database file failed to load, restored from backup",
+				slog.String("error", err.Error()),
+				slog.String("corrupt_file", cfg.DBPath+".corrupt"))
+		}))
+	}
+
+	if cfg.StartupCheck && !verifyLog(logger, cfg.DBPath, cfg.DBRestoreFromBackup) {
 		os.Exit(1)
 	}
 
-	db, err := inmemorydb.Open(cfg.DBPath)
+	db, err := inmemorydb.Open(cfg.DBPath, dbOpts...)
 	if err != nil {
 		logger.Error(err.Error())
 		os.Exit(1)
@@ -53,10 +70,47 @@ func main() {
 	logger.Info("database opened")
 	logger.Info("creating task repository and todo service")
 	taskRepo := repository.NewTaskRepo(db)
-	service := service.NewTodoService(logger, taskRepo)
+
+	var ids service.IDGenerator = service.NewMonotonicIDGenerator(taskRepo)
+	if cfg.IDGenerator == "timestamp" {
+		ids = service.NewTimestampIDGenerator(nil)
+	}
+
+	var observers []service.Observer
+	if cfg.WebhookURL != "" {
+		logger.Info("starting webhook sender", slog.String("url", cfg.WebhookURL))
+		sender := webhook.New(logger, cfg.WebhookURL, cfg.WebhookSecret,
+			webhook.WithTimeout(cfg.WebhookTimeout), webhook.WithMaxAttempts(cfg.WebhookMaxAttempts))
+		defer sender.Close()
+		observers = append(observers, sender)
+	}
+
+	service := service.NewTodoService(logger, taskRepo, service.WithValidationLimits(domain.ValidationLimits{
+		MaxTitleLength:       cfg.MaxTitleLength,
+		MaxDescriptionLength: cfg.MaxDescriptionLength,
+		ByPriority:           cfg.PriorityLimits,
+	}), service.WithUniqueTitles(cfg.UniqueTitles), service.WithTrashTTL(cfg.TrashTTL), service.WithIDGenerator(ids),
+		service.WithObservers(observers...))
+
+	if cfg.StartupCheck && !checkIntegrity(logger, service, cfg.StartupMaxCorrupt) {
+		os.Exit(1)
+	}
+
+	if cfg.VacuumInterval > 0 {
+		logger.Info("starting periodic database vacuum", slog.String("interval", cfg.VacuumInterval.String()))
+		stopVacuum := service.StartVacuum(cfg.VacuumInterval)
+		defer stopVacuum()
+	}
+
+	if cfg.TrashTTL > 0 && cfg.TrashSweepInterval > 0 {
+		logger.Info("starting trash sweeper", slog.String("ttl", cfg.TrashTTL.String()),
+			slog.String("interval", cfg.TrashSweepInterval.String()))
+		stopSweeper := service.StartTrashSweeper(cfg.TrashSweepInterval)
+		defer stopSweeper()
+	}
 
 	logger.Info("creating routes and server")
-	router := routes.Routes(logger, service, cfg.Env, cfg.Version)
+	router := routes.Routes(logger, service, cfg)
 	s := server.New(logger, cfg, router)
 
 	logger.Info("initializing metrics")
@@ -69,3 +123,62 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// verifyLog checks that every entry of the database log parses, logging the result.
+// The database can't be opened with bad entries, so it returns false for them, unless
+// the database is restored from backup. The share of corrupt tasks allowed by
+// StartupMaxCorrupt is checked by checkIntegrity, after the log is loaded.
+func verifyLog(logger *slog.Logger, dbPath string, restoreFromBackup bool) bool {
+	report, err := inmemorydb.Verify(dbPath)
+	if err != nil {
+		logger.Error(err.Error())
+		return false
+	}
+
+	attrs := []any{
+		slog.Int("entries", report.Entries),
+		slog.Int("bad_entries", report.BadEntries),
+		slog.Any("bad_lines", report.BadLines),
+	}
+
+	switch {
+	case report.BadEntries > 0 && !restoreFromBackup:
+		logger.Error("refusing to start: database log has unparsable entries", attrs...)
+		return false
+	case report.BadEntries > 0:
+		logger.Error("database log has unparsable entries, restoring from backup", attrs...)
+	default:
+		logger.Info("database log verified", slog.Int("entries", report.Entries), slog.Int("keys", report.Keys))
+	}
+
+	return true
+}
+
+// checkIntegrity decodes every stored task, logging the result. It returns false if
+// the share of corrupt tasks is greater than maxCorrupt, so the server must not start.
+func checkIntegrity(logger *slog.Logger, service *service.TodoService, maxCorrupt float64) bool {
+	report, err := service.CheckIntegrity()
+	if err != nil {
+		logger.Error(err.Error())
+		return false
+	}
+
+	attrs := []any{
+		slog.Int("tasks", report.Tasks),
+		slog.Int("corrupt", report.Corrupt),
+		slog.Any("corrupt_keys", report.CorruptKeys),
+	}
+
+	switch {
+	case report.CorruptRatio() > maxCorrupt:
+		logger.Error("refusing to start: share of corrupt tasks exceeds the threshold",
+			append(attrs, slog.Float64("max_corrupt", maxCorrupt))...)
+		return false
+	case report.Corrupt > 0:
+		logger.Error("corrupt tasks found", attrs...)
+	default:
+		logger.Info("stored tasks verified", attrs...)
+	}
+
+	return true
+}
@@ -2,8 +2,25 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"net/netip"
+	"net/url"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/repository"
+)
+
+// maxTextLength is the largest title or description length limit that can be configured:
+// a task with both at the limit, 4 bytes per symbol, still fits a database record, with
+// taskOverhead left for the rest of the task(subtasks, tags, etc.).
+const (
+	taskOverhead  = 128 << 10
+	maxTextLength = (repository.MaxTaskSize - taskOverhead) / 2 / 4
 )
 
 type Config struct {
@@ -11,6 +28,141 @@ type Config struct {
 	Env     string
 	Version string
 	DBPath  string
+
+	// LogLevel is the minimum level of logged messages.
+	LogLevel slog.Level
+
+	// PanicDetails adds the panic value and stack to 500 responses caused by
+	// panics, defaults to true in development.
+	PanicDetails bool
+
+	// ReadHeaderTimeout is the amount of time allowed to read request headers.
+	// Separate from the server ReadTimeout to protect against slow-header clients.
+	ReadHeaderTimeout time.Duration
+
+	// RequestTimeout limits the time a request is processed, after it the client gets 503.
+	// 0 disables the limit.
+	RequestTimeout time.Duration
+
+	// SlowRequestThreshold is the duration after which requests are logged at warn
+	// level. 0 disables slow request logging.
+	SlowRequestThreshold time.Duration
+
+	// ShutdownTimeout is how long the server waits for requests to finish on shutdown.
+	ShutdownTimeout time.Duration
+
+	// MaxTitleLength and MaxDescriptionLength limit task fields length(in symbols).
+	// PriorityLimits overrides them for tasks with the priority.
+	MaxTitleLength       int
+	MaxDescriptionLength int
+	PriorityLimits       map[string]domain.PriorityLimits
+
+	// MaxConcurrentRequests limits the number of requests processed at the same time.
+	// 0 disables the limit.
+	MaxConcurrentRequests int
+
+	// Per-client rate limits(requests per second and burst), separate for
+	// reads and writes(POST/PUT/PATCH/DELETE). 0 rate disables the limit.
+	ReadRateLimit  float64
+	ReadRateBurst  int
+	WriteRateLimit float64
+	WriteRateBurst int
+
+	// TrustedProxies are the networks of reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers are trusted to find the client IP for logging and rate limiting.
+	// Empty by default(the direct peer address is used).
+	TrustedProxies []netip.Prefix
+
+	// MaxURLLength and MaxHeaderBytes limit request URL length and total headers
+	// size, requests exceeding them are rejected with 431. 0 disables the limit.
+	MaxURLLength   int
+	MaxHeaderBytes int
+
+	// RejectBodyOnGetDelete rejects GET and DELETE requests with a body with 400.
+	RejectBodyOnGetDelete bool
+
+	// DefaultPageSize is used when the list request has no page_size, 0(the default)
+	// returns all tasks. MaxPageSize clamps page_size of paged requests(with page or
+	// page_size), 0 disables the limit.
+	DefaultPageSize int
+	MaxPageSize     int
+
+	// VacuumInterval is how often the database file is compacted in background.
+	// 0 disables periodic compaction.
+	VacuumInterval time.Duration
+
+	// DBFlushInterval is how often buffered database writes are flushed to disk
+	// in background. 0 disables background flushing(writes are flushed on shutdown).
+	DBFlushInterval time.Duration
+
+	// DBBufferSize is the size in bytes of the database write buffer, 0 uses the
+	// default(4KB). DBFlushEvery flushes the buffer every DBFlushEvery writes, 0 disables it.
+	DBBufferSize int
+	DBFlushEvery int
+
+	// DBWriteQueueSize enables write-behind mode: database writes are queued and
+	// appended to the file in background, up to DBWriteQueueSize writes. 0 disables it.
+	DBWriteQueueSize int
+
+	// DBRestoreFromBackup makes startup fall back to the database backup
+	// file(left by compaction) when the database file is corrupt.
+	DBRestoreFromBackup bool
+
+	// StartupCheck makes startup verify every database log entry and decode every
+	// stored task before serving. Startup fails on unparsable log entries(unless
+	// DBRestoreFromBackup is set), as the log can't be loaded with them, and if the
+	// share of corrupt tasks is greater than StartupMaxCorrupt(0..1). Smaller task
+	// corruption is logged.
+	StartupCheck      bool
+	StartupMaxCorrupt float64
+
+	// PatchRequireIfMatch makes PATCH requests without If-Match fail with 428.
+	PatchRequireIfMatch bool
+
+	// UniqueTitles makes creating a task fail with 409 if an open task has the same
+	// title(ignoring surrounding whitespace and case).
+	UniqueTitles bool
+
+	// TrashTTL is how long deleted tasks are kept in the trash to be restored,
+	// 0 deletes tasks permanently. Expired tasks and idempotency records are purged
+	// every TrashSweepInterval.
+	TrashTTL           time.Duration
+	TrashSweepInterval time.Duration
+
+	// StringIDs makes task ids encode as JSON strings in responses, so clients
+	// parsing numbers as float64 don't lose precision. Ids are accepted in both forms.
+	StringIDs bool
+
+	// IDGenerator is the strategy of server-assigned task ids: "monotonic"(sequential,
+	// persisted counter) or "timestamp"(time-sortable, Snowflake-like).
+	IDGenerator string
+
+	// WebhookURL is the URL task events(created, updated, deleted) are POSTed to,
+	// webhooks are disabled when it is empty. WebhookSecret signs the events(HMAC-SHA256).
+	// A delivery attempt times out after WebhookTimeout, failed events are retried
+	// up to WebhookMaxAttempts times in total.
+	WebhookURL         string
+	WebhookSecret      string
+	WebhookTimeout     time.Duration
+	WebhookMaxAttempts int
+
+	// AdminToken is the bearer token required by admin/debug endpoints.
+	// Admin endpoints are disabled when it is empty.
+	AdminToken string
+
+	// CORSAllowedOrigins are origins allowed to make cross-origin requests("*" allows any).
+	// CORS is disabled when empty. CORSMaxAge is how long browsers cache preflight
+	// responses, 0 omits the Access-Control-Max-Age header.
+	CORSAllowedOrigins []string
+	CORSMaxAge         time.Duration
+
+	// TaskCacheMaxAge is the max-age of Cache-Control: private sent with
+	// GET /todos/{id} responses. 0 omits the header.
+	TaskCacheMaxAge time.Duration
+
+	// BasePath is the prefix task routes are mounted under(e.g. /v1).
+	// Empty by default.
+	BasePath string
 }
 
 func New() (*Config, error) {
@@ -31,10 +183,497 @@ func New() (*Config, error) {
 
 	version := os.Getenv("API_TODO_VERSION")
 
+	var logLevel slog.Level
+	if v := os.Getenv("API_TODO_LOG_LEVEL"); v != "" {
+		err = logLevel.UnmarshalText([]byte(v))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing API_TODO_LOG_LEVEL: %w", err)
+		}
+	}
+
+	panicDetails, err := boolEnv("API_TODO_PANIC_DETAILS", env == "development")
+	if err != nil {
+		return nil, err
+	}
+
+	readHeaderTimeout, err := positiveDurationEnv("API_TODO_READ_HEADER_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	requestTimeout, err := nonNegativeDurationEnv("API_TODO_REQUEST_TIMEOUT", 20*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	slowRequestThreshold, err := nonNegativeDurationEnv("API_TODO_SLOW_REQUEST_THRESHOLD", time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	shutdownTimeout, err := positiveDurationEnv("API_TODO_SHUTDOWN_TIMEOUT", 15*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	maxTitleLength, err := positiveIntEnv("API_TODO_MAX_TITLE_LENGTH", 100)
+	if err != nil {
+		return nil, err
+	}
+	if maxTitleLength > maxTextLength {
+		return nil, fmt.Errorf("API_TODO_MAX_TITLE_LENGTH must not be greater than %d, got %d", maxTextLength, maxTitleLength)
+	}
+
+	maxDescriptionLength, err := positiveIntEnv("API_TODO_MAX_DESCRIPTION_LENGTH", 2000)
+	if err != nil {
+		return nil, err
+	}
+	if maxDescriptionLength > maxTextLength {
+		return nil, fmt.Errorf("API_TODO_MAX_DESCRIPTION_LENGTH must not be greater than %d, got %d", maxTextLength, maxDescriptionLength)
+	}
+
+	priorityLimits, err := priorityLimitsEnv("API_TODO_PRIORITY_LIMITS")
+	if err != nil {
+		return nil, err
+	}
+
+	maxConcurrentRequests, err := nonNegativeIntEnv("API_TODO_MAX_CONCURRENT_REQUESTS", 100)
+	if err != nil {
+		return nil, err
+	}
+
+	readRateLimit, err := nonNegativeFloatEnv("API_TODO_READ_RATE_LIMIT", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	readRateBurst, err := positiveIntEnv("API_TODO_READ_RATE_BURST", 50)
+	if err != nil {
+		return nil, err
+	}
+
+	writeRateLimit, err := nonNegativeFloatEnv("API_TODO_WRITE_RATE_LIMIT", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	writeRateBurst, err := positiveIntEnv("API_TODO_WRITE_RATE_BURST", 10)
+	if err != nil {
+		return nil, err
+	}
+
+	trustedProxies, err := prefixListEnv("API_TODO_TRUSTED_PROXIES")
+	if err != nil {
+		return nil, err
+	}
+
+	maxURLLength, err := nonNegativeIntEnv("API_TODO_MAX_URL_LENGTH", 8192)
+	if err != nil {
+		return nil, err
+	}
+
+	maxHeaderBytes, err := nonNegativeIntEnv("API_TODO_MAX_HEADER_BYTES", 32768)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultPageSize, err := nonNegativeIntEnv("API_TODO_DEFAULT_PAGE_SIZE", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPageSize, err := nonNegativeIntEnv("API_TODO_MAX_PAGE_SIZE", 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	if maxPageSize > 0 && defaultPageSize > maxPageSize {
+		return nil, fmt.Errorf("API_TODO_DEFAULT_PAGE_SIZE must not be greater than API_TODO_MAX_PAGE_SIZE, got %d > %d", defaultPageSize, maxPageSize)
+	}
+
+	vacuumInterval, err := nonNegativeDurationEnv("API_TODO_VACUUM_INTERVAL", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	dbFlushInterval, err := nonNegativeDurationEnv("API_TODO_DB_FLUSH_INTERVAL", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	dbBufferSize, err := nonNegativeIntEnv("API_TODO_DB_BUFFER_SIZE", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	dbFlushEvery, err := nonNegativeIntEnv("API_TODO_DB_FLUSH_EVERY", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	dbWriteQueueSize, err := nonNegativeIntEnv("API_TODO_DB_WRITE_QUEUE_SIZE", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	dbRestoreFromBackup, err := boolEnv("API_TODO_DB_RESTORE_FROM_BACKUP", false)
+	if err != nil {
+		return nil, err
+	}
+
+	startupCheck, err := boolEnv("API_TODO_STARTUP_CHECK", false)
+	if err != nil {
+		return nil, err
+	}
+
+	startupMaxCorrupt, err := nonNegativeFloatEnv("API_TODO_STARTUP_MAX_CORRUPT", 0)
+	if err != nil {
+		return nil, err
+	}
+	if startupMaxCorrupt > 1 {
+		return nil, fmt.Errorf("API_TODO_STARTUP_MAX_CORRUPT must not be greater than 1, got %v", startupMaxCorrupt)
+	}
+
+	rejectBodyOnGetDelete, err := boolEnv("API_TODO_REJECT_BODY_ON_GET_DELETE", true)
+	if err != nil {
+		return nil, err
+	}
+
+	patchRequireIfMatch, err := boolEnv("API_TODO_PATCH_REQUIRE_IF_MATCH", true)
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueTitles, err := boolEnv("API_TODO_UNIQUE_TITLES", false)
+	if err != nil {
+		return nil, err
+	}
+
+	trashTTL, err := nonNegativeDurationEnv("API_TODO_TRASH_TTL", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	trashSweepInterval, err := nonNegativeDurationEnv("API_TODO_TRASH_SWEEP_INTERVAL", time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	stringIDs, err := boolEnv("API_TODO_STRING_IDS", false)
+	if err != nil {
+		return nil, err
+	}
+
+	idGenerator := os.Getenv("API_TODO_ID_GENERATOR")
+	switch idGenerator {
+	case "":
+		idGenerator = "monotonic"
+	case "monotonic", "timestamp":
+	default:
+		return nil, fmt.Errorf("API_TODO_ID_GENERATOR must be monotonic or timestamp, got %q", idGenerator)
+	}
+
+	webhookURL := os.Getenv("API_TODO_WEBHOOK_URL")
+	if webhookURL != "" {
+		u, err := url.Parse(webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("API_TODO_WEBHOOK_URL must be an http(s) URL, got %q", webhookURL)
+		}
+	}
+
+	webhookSecret := os.Getenv("API_TODO_WEBHOOK_SECRET")
+
+	webhookTimeout, err := positiveDurationEnv("API_TODO_WEBHOOK_TIMEOUT", 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookMaxAttempts, err := positiveIntEnv("API_TODO_WEBHOOK_MAX_ATTEMPTS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	adminToken := os.Getenv("API_TODO_ADMIN_TOKEN")
+
+	var corsAllowedOrigins []string
+	for origin := range strings.SplitSeq(os.Getenv("API_TODO_CORS_ALLOWED_ORIGINS"), ",") {
+		origin = strings.TrimSpace(origin)
+		if origin != "" {
+			corsAllowedOrigins = append(corsAllowedOrigins, origin)
+		}
+	}
+
+	corsMaxAge, err := nonNegativeDurationEnv("API_TODO_CORS_MAX_AGE", 10*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	taskCacheMaxAge, err := nonNegativeDurationEnv("API_TODO_TASK_CACHE_MAX_AGE", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	basePath := strings.TrimSuffix(os.Getenv("API_TODO_BASE_PATH"), "/")
+	if basePath != "" && !strings.HasPrefix(basePath, "/") {
+		return nil, fmt.Errorf("API_TODO_BASE_PATH must start with '/', got %q", basePath)
+	}
+
 	return &Config{
 		Port:    port,
 		Env:     env,
 		Version: version,
 		DBPath:  dbPath,
+
+		LogLevel:     logLevel,
+		PanicDetails: panicDetails,
+
+		ReadHeaderTimeout: readHeaderTimeout,
+		RequestTimeout:    requestTimeout,
+
+		SlowRequestThreshold: slowRequestThreshold,
+		ShutdownTimeout:      shutdownTimeout,
+
+		MaxTitleLength:       maxTitleLength,
+		MaxDescriptionLength: maxDescriptionLength,
+		PriorityLimits:       priorityLimits,
+
+		MaxConcurrentRequests: maxConcurrentRequests,
+
+		ReadRateLimit:  readRateLimit,
+		ReadRateBurst:  readRateBurst,
+		WriteRateLimit: writeRateLimit,
+		WriteRateBurst: writeRateBurst,
+
+		TrustedProxies: trustedProxies,
+
+		MaxURLLength:   maxURLLength,
+		MaxHeaderBytes: maxHeaderBytes,
+
+		RejectBodyOnGetDelete: rejectBodyOnGetDelete,
+
+		DefaultPageSize: defaultPageSize,
+		MaxPageSize:     maxPageSize,
+
+		VacuumInterval:      vacuumInterval,
+		DBFlushInterval:     dbFlushInterval,
+		DBBufferSize:        dbBufferSize,
+		DBFlushEvery:        dbFlushEvery,
+		DBWriteQueueSize:    dbWriteQueueSize,
+		DBRestoreFromBackup: dbRestoreFromBackup,
+
+		StartupCheck:      startupCheck,
+		StartupMaxCorrupt: startupMaxCorrupt,
+
+		PatchRequireIfMatch: patchRequireIfMatch,
+		StringIDs:           stringIDs,
+		IDGenerator:         idGenerator,
+
+		UniqueTitles: uniqueTitles,
+
+		TrashTTL:           trashTTL,
+		TrashSweepInterval: trashSweepInterval,
+
+		WebhookURL:         webhookURL,
+		WebhookSecret:      webhookSecret,
+		WebhookTimeout:     webhookTimeout,
+		WebhookMaxAttempts: webhookMaxAttempts,
+
+		AdminToken: adminToken,
+
+		CORSAllowedOrigins: corsAllowedOrigins,
+		CORSMaxAge:         corsMaxAge,
+
+		TaskCacheMaxAge: taskCacheMaxAge,
+
+		BasePath: basePath,
 	}, nil
 }
+
+// boolEnv reads a boolean(as accepted by strconv.ParseBool) from the environment variable key.
+// Returns defaultValue if the variable is not set.
+func boolEnv(key string, defaultValue bool) (bool, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+
+	return b, nil
+}
+
+// positiveDurationEnv reads a positive duration from the environment variable key.
+// Returns defaultValue if the variable is not set.
+func positiveDurationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %s", key, d)
+	}
+
+	return d, nil
+}
+
+// nonNegativeDurationEnv reads a non-negative duration from the environment variable key.
+// Returns defaultValue if the variable is not set.
+func nonNegativeDurationEnv(key string, defaultValue time.Duration) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %s", key, d)
+	}
+
+	return d, nil
+}
+
+// positiveIntEnv reads a positive integer from the environment variable key.
+// Returns defaultValue if the variable is not set.
+func positiveIntEnv(key string, defaultValue int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+
+	if n <= 0 {
+		return 0, fmt.Errorf("%s must be positive, got %d", key, n)
+	}
+
+	return n, nil
+}
+
+// nonNegativeIntEnv reads a non-negative integer from the environment variable key.
+// Returns defaultValue if the variable is not set.
+func nonNegativeIntEnv(key string, defaultValue int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+
+	if n < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %d", key, n)
+	}
+
+	return n, nil
+}
+
+// nonNegativeFloatEnv reads a non-negative float from the environment variable key.
+// Returns defaultValue if the variable is not set.
+func nonNegativeFloatEnv(key string, defaultValue float64) (float64, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return defaultValue, nil
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("error parsing %s: %w", key, err)
+	}
+
+	if f < 0 {
+		return 0, fmt.Errorf("%s must not be negative, got %g", key, f)
+	}
+
+	return f, nil
+}
+
+// priorityLimitsEnv reads the length limits by task priority from the environment variable
+// key, formatted as a comma-separated list of priority=title:description(e.g.
+// "high=200:10000,low=:500"), an empty limit keeps the general one. Returns nil if the
+// variable is not set.
+func priorityLimitsEnv(key string) (map[string]domain.PriorityLimits, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return nil, nil
+	}
+
+	limits := make(map[string]domain.PriorityLimits)
+	for item := range strings.SplitSeq(v, ",") {
+		priority, lengths, found := strings.Cut(strings.TrimSpace(item), "=")
+		if !found || !slices.Contains(domain.Priorities, priority) {
+			return nil, fmt.Errorf("%s must be a list of priority=title:description with priorities %s, got %q",
+				key, strings.Join(domain.Priorities, ", "), item)
+		}
+
+		title, description, found := strings.Cut(lengths, ":")
+		if !found {
+			return nil, fmt.Errorf("%s must be a list of priority=title:description, got %q", key, item)
+		}
+
+		var l domain.PriorityLimits
+		for _, limit := range []struct {
+			value string
+			dst   *int
+		}{{title, &l.MaxTitleLength}, {description, &l.MaxDescriptionLength}} {
+			if limit.value == "" {
+				continue
+			}
+
+			n, err := strconv.Atoi(limit.value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%s limits must be positive integers, got %q", key, item)
+			}
+			if n > maxTextLength {
+				return nil, fmt.Errorf("%s limits must not be greater than %d, got %q", key, maxTextLength, item)
+			}
+			*limit.dst = n
+		}
+
+		limits[priority] = l
+	}
+
+	return limits, nil
+}
+
+// prefixListEnv reads a comma-separated list of CIDRs from the environment variable key.
+// A single IP is accepted as a network of one address. Returns nil if the variable is not set.
+func prefixListEnv(key string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for v := range strings.SplitSeq(os.Getenv(key), ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(v)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(v)
+			if addrErr != nil {
+				return nil, fmt.Errorf("error parsing %s: %w", key, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
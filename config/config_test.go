@@ -0,0 +1,260 @@
+package config
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("uses default read header timeout", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_READ_HEADER_TIMEOUT", "")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if cfg.ReadHeaderTimeout != 5*time.Second {
+			t.Errorf("Expected read header timeout %s, got %s", 5*time.Second, cfg.ReadHeaderTimeout)
+		}
+	})
+
+	t.Run("parses read header timeout", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_READ_HEADER_TIMEOUT", "2s")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if cfg.ReadHeaderTimeout != 2*time.Second {
+			t.Errorf("Expected read header timeout %s, got %s", 2*time.Second, cfg.ReadHeaderTimeout)
+		}
+	})
+
+	t.Run("returns error for invalid read header timeout", func(t *testing.T) {
+		tests := []string{"abc", "0s", "-1s"}
+
+		for _, v := range tests {
+			t.Run(v, func(t *testing.T) {
+				t.Setenv("API_TODO_PORT", "8080")
+				t.Setenv("API_TODO_READ_HEADER_TIMEOUT", v)
+
+				_, err := New()
+				if err == nil {
+					t.Errorf("Expected error for %q", v)
+				}
+			})
+		}
+	})
+
+	t.Run("uses default validation limits", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_MAX_TITLE_LENGTH", "")
+		t.Setenv("API_TODO_MAX_DESCRIPTION_LENGTH", "")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if cfg.MaxTitleLength != 100 || cfg.MaxDescriptionLength != 2000 {
+			t.Errorf("Expected limits 100/2000, got %d/%d", cfg.MaxTitleLength, cfg.MaxDescriptionLength)
+		}
+	})
+
+	t.Run("parses validation limits", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_MAX_TITLE_LENGTH", "50")
+		t.Setenv("API_TODO_MAX_DESCRIPTION_LENGTH", "500")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if cfg.MaxTitleLength != 50 || cfg.MaxDescriptionLength != 500 {
+			t.Errorf("Expected limits 50/500, got %d/%d", cfg.MaxTitleLength, cfg.MaxDescriptionLength)
+		}
+	})
+
+	t.Run("parses priority limits", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_PRIORITY_LIMITS", "high=200:10000, low=:500")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(cfg.PriorityLimits) != 2 ||
+			cfg.PriorityLimits["high"] != (domain.PriorityLimits{MaxTitleLength: 200, MaxDescriptionLength: 10000}) ||
+			cfg.PriorityLimits["low"] != (domain.PriorityLimits{MaxDescriptionLength: 500}) {
+			t.Errorf("Unexpected priority limits: %+v", cfg.PriorityLimits)
+		}
+
+		for _, v := range []string{"urgent=1:1", "high=200", "high=0:10", "high=a:10", "high=10000000:10"} {
+			t.Setenv("API_TODO_PRIORITY_LIMITS", v)
+
+			_, err = New()
+			if err == nil {
+				t.Errorf("Expected error for priority limits %q", v)
+			}
+		}
+	})
+
+	t.Run("returns error for non-positive limit", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_MAX_TITLE_LENGTH", "0")
+
+		_, err := New()
+		if err == nil {
+			t.Error("Expected error for zero title length")
+		}
+	})
+
+	t.Run("returns error for limit too large to be stored", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_MAX_DESCRIPTION_LENGTH", strconv.Itoa(maxTextLength+1))
+
+		_, err := New()
+		if err == nil {
+			t.Error("Expected error for too large description length")
+		}
+
+		t.Setenv("API_TODO_MAX_DESCRIPTION_LENGTH", strconv.Itoa(maxTextLength))
+
+		_, err = New()
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	})
+
+	t.Run("parses max concurrent requests", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_MAX_CONCURRENT_REQUESTS", "0")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if cfg.MaxConcurrentRequests != 0 {
+			t.Errorf("Expected max concurrent requests 0, got %d", cfg.MaxConcurrentRequests)
+		}
+
+		t.Setenv("API_TODO_MAX_CONCURRENT_REQUESTS", "-1")
+
+		_, err = New()
+		if err == nil {
+			t.Error("Expected error for negative max concurrent requests")
+		}
+	})
+
+	t.Run("normalizes base path", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_BASE_PATH", "/v1/")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if cfg.BasePath != "/v1" {
+			t.Errorf("Expected base path '/v1', got '%s'", cfg.BasePath)
+		}
+
+		t.Setenv("API_TODO_BASE_PATH", "v1")
+
+		_, err = New()
+		if err == nil {
+			t.Error("Expected error for base path without leading slash")
+		}
+	})
+
+	t.Run("parses id generator", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_ID_GENERATOR", "")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if cfg.IDGenerator != "monotonic" {
+			t.Errorf("Expected id generator 'monotonic', got '%s'", cfg.IDGenerator)
+		}
+
+		t.Setenv("API_TODO_ID_GENERATOR", "random")
+
+		_, err = New()
+		if err == nil {
+			t.Error("Expected error for unknown id generator")
+		}
+	})
+
+	t.Run("validates webhook url", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_WEBHOOK_URL", "https://hooks.example.com/todo")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if cfg.WebhookURL != "https://hooks.example.com/todo" || cfg.WebhookMaxAttempts != 5 {
+			t.Errorf("Expected webhook url and default max attempts, got '%s' and %d", cfg.WebhookURL, cfg.WebhookMaxAttempts)
+		}
+
+		t.Setenv("API_TODO_WEBHOOK_URL", "hooks.example.com")
+
+		_, err = New()
+		if err == nil {
+			t.Error("Expected error for webhook url without scheme")
+		}
+	})
+
+	t.Run("parses CORS settings", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_CORS_ALLOWED_ORIGINS", "https://a.example.com, ,https://b.example.com")
+		t.Setenv("API_TODO_CORS_MAX_AGE", "1h")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(cfg.CORSAllowedOrigins) != 2 || cfg.CORSAllowedOrigins[0] != "https://a.example.com" || cfg.CORSAllowedOrigins[1] != "https://b.example.com" {
+			t.Errorf("Unexpected allowed origins: %v", cfg.CORSAllowedOrigins)
+		}
+		if cfg.CORSMaxAge != time.Hour {
+			t.Errorf("Expected CORS max age 1h, got %s", cfg.CORSMaxAge)
+		}
+	})
+
+	t.Run("parses trusted proxies", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.5")
+
+		cfg, err := New()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(cfg.TrustedProxies) != 2 || cfg.TrustedProxies[0].String() != "10.0.0.0/8" || cfg.TrustedProxies[1].String() != "192.168.1.5/32" {
+			t.Errorf("Unexpected trusted proxies: %v", cfg.TrustedProxies)
+		}
+
+		t.Setenv("API_TODO_TRUSTED_PROXIES", "10.0.0.0/33")
+
+		_, err = New()
+		if err == nil {
+			t.Error("Expected error for invalid trusted proxy")
+		}
+	})
+}
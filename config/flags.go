@@ -0,0 +1,60 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/vladgrskkh/todo/pkg/envload"
+)
+
+// flagEnv maps the flags defined by RegisterFlags to the environment variables they override.
+var flagEnv = map[string]string{
+	"port":      "API_TODO_PORT",
+	"db-path":   "API_TODO_DB_PATH",
+	"env":       "API_TODO_ENV",
+	"version":   "API_TODO_VERSION",
+	"log-level": "API_TODO_LOG_LEVEL",
+}
+
+// RegisterFlags defines flags for the key settings(port, db path, env, version and
+// log level) on fs. Flags set on the command line override the environment in Load.
+func RegisterFlags(fs *flag.FlagSet) {
+	fs.String("port", "", "server port(overrides API_TODO_PORT)")
+	fs.String("db-path", "", "database file path(overrides API_TODO_DB_PATH)")
+	fs.String("env", "", "environment name(overrides API_TODO_ENV)")
+	fs.String("version", "", "application version(overrides API_TODO_VERSION)")
+	fs.String("log-level", "", "log level: debug, info, warn or error(overrides API_TODO_LOG_LEVEL)")
+}
+
+// Load returns the config built from the following sources, highest precedence first:
+// flags set on fs(registered with RegisterFlags), environment variables and the env
+// file at envPath. A missing env file is not an error.
+//
+// Layering is done through the environment: env file values are only set for missing
+// variables and set flags overwrite their variables, then New reads the result.
+func Load(fs *flag.FlagSet, envPath string) (*Config, error) {
+	err := envload.Load(envPath, false)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		key, ok := flagEnv[f.Name]
+		if !ok || flagErr != nil {
+			return
+		}
+
+		err := os.Setenv(key, f.Value.String())
+		if err != nil {
+			flagErr = fmt.Errorf("error applying -%s flag: %w", f.Name, err)
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	return New()
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// unsetenv unsets the environment variable key for the duration of the test.
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+
+	// t.Setenv restores the previous value on cleanup
+	t.Setenv(key, "")
+	err := os.Unsetenv(key)
+	if err != nil {
+		t.Fatalf("Failed to unset %s: %v", key, err)
+	}
+}
+
+func newFlagSet(t *testing.T, args ...string) *flag.FlagSet {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	RegisterFlags(fs)
+
+	err := fs.Parse(args)
+	if err != nil {
+		t.Fatalf("Failed to parse flags: %v", err)
+	}
+
+	return fs
+}
+
+func TestLoad(t *testing.T) {
+	for _, key := range flagEnv {
+		unsetenv(t, key)
+	}
+
+	envPath := filepath.Join(t.TempDir(), ".env")
+	err := os.WriteFile(envPath, []byte(
+		"API_TODO_PORT=1000\nAPI_TODO_ENV=dotenv\nAPI_TODO_DB_PATH=dotenv.db\nAPI_TODO_VERSION=dotenv\n"), 0o644)
+	if err != nil {
+		t.Fatalf("Failed to write env file: %v", err)
+	}
+
+	t.Setenv("API_TODO_PORT", "2000")
+	t.Setenv("API_TODO_ENV", "environ")
+
+	fs := newFlagSet(t, "-port", "3000", "-log-level", "debug")
+
+	cfg, err := Load(fs, envPath)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if cfg.Port != 3000 {
+		t.Errorf("Expected flag to override env var and env file, got port %d", cfg.Port)
+	}
+	if cfg.Env != "environ" {
+		t.Errorf("Expected env var to override env file, got env '%s'", cfg.Env)
+	}
+	if cfg.DBPath != "dotenv.db" || cfg.Version != "dotenv" {
+		t.Errorf("Expected env file values, got db path '%s' and version '%s'", cfg.DBPath, cfg.Version)
+	}
+	if cfg.LogLevel != slog.LevelDebug {
+		t.Errorf("Expected log level %s, got %s", slog.LevelDebug, cfg.LogLevel)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Run("ignores missing env file", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+
+		cfg, err := Load(newFlagSet(t), filepath.Join(t.TempDir(), ".env"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Port != 8080 {
+			t.Errorf("Expected port 8080, got %d", cfg.Port)
+		}
+	})
+
+	t.Run("returns error for invalid flag value", func(t *testing.T) {
+		t.Setenv("API_TODO_PORT", "8080")
+		t.Setenv("API_TODO_LOG_LEVEL", "")
+
+		_, err := Load(newFlagSet(t, "-log-level", "verbose"), filepath.Join(t.TempDir(), ".env"))
+		if err == nil {
+			t.Error("Expected error for invalid log level")
+		}
+	})
+}
@@ -1,11 +1,17 @@
 package apierrors
 
 import (
+	"errors"
+	"expvar"
 	"log/slog"
 	"net/http"
 	"runtime/debug"
+	"strconv"
 
+	"github.com/vladgrskkh/todo/internal/i18n"
+	"github.com/vladgrskkh/todo/internal/requestid"
 	"github.com/vladgrskkh/todo/pkg/jsonhttp"
+	"github.com/vladgrskkh/todo/pkg/validator"
 )
 
 // logError logs an error with the request method, URL, and stack trace.
@@ -16,41 +22,182 @@ func logError(logger *slog.Logger, r *http.Request, err error) {
 		slog.String("trace", string(debug.Stack())))
 }
 
-// errorResponse writes a JSON response with a provided status code and message
-// to the http.ResponseWriter.
-func errorResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, status int, message interface{}) {
-	data := jsonhttp.Envelope{
-		"error": message,
+// errorResponse writes a JSON error response with a provided status code,
+// machine-readable code and message to the http.ResponseWriter.
+// String messages are translated to the request language by code.
+// Error responses are never cached, so a transient error isn't served from a cache.
+func errorResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, status int, code string, message any, headers http.Header) {
+	if msg, ok := message.(string); ok {
+		message = i18n.Translate(i18n.Language(r), code, msg)
 	}
 
-	err := jsonhttp.WriteJSON(w, status, data, nil)
+	headers = headers.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set("Cache-Control", "no-store")
+	headers.Set("Pragma", "no-cache")
+
+	err := jsonhttp.WriteError(w, status, code, message, headers)
 	if err != nil {
 		logError(logger, r, err)
 		w.WriteHeader(500)
 	}
 }
 
+// badRequestCode returns the error code for the known request decoding errors.
+func badRequestCode(err error) string {
+	switch {
+	case errors.Is(err, jsonhttp.ErrEmptyBody):
+		return "empty_body"
+	case errors.Is(err, jsonhttp.ErrBodyTooLarge):
+		return "body_too_large"
+	case errors.Is(err, jsonhttp.ErrUnknownField):
+		return "unknown_field"
+	case errors.Is(err, jsonhttp.ErrMalformedJSON), errors.Is(err, jsonhttp.ErrMultipleValues), errors.Is(err, jsonhttp.ErrNotArray):
+		return "malformed_json"
+	case errors.Is(err, jsonhttp.ErrInvalidType):
+		return "invalid_type"
+	default:
+		return "bad_request"
+	}
+}
+
+// BadRequestResponse writes a 400 response for request decoding errors, except for
+// jsonhttp.ErrBodyTooLarge that gets 413 Payload Too Large.
 func BadRequestResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, err error) {
-	errorResponse(logger, w, r, http.StatusBadRequest, err.Error())
+	status := http.StatusBadRequest
+	if errors.Is(err, jsonhttp.ErrBodyTooLarge) {
+		status = http.StatusRequestEntityTooLarge
+	}
+
+	errorResponse(logger, w, r, status, badRequestCode(err), err.Error(), nil)
 }
 
+// ServerErrorResponse logs err and writes a generic 500 response. The error message
+// is sent with the request id(if any), which is logged as well, so users can
+// reference the incident.
 func ServerErrorResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, err error) {
+	ServerErrorDetailsResponse(logger, w, r, err, nil)
+}
+
+// ServerErrorDetailsResponse is ServerErrorResponse with details added to the error
+// message(e.g. the panic value and stack in development). Details must not be
+// sent in production, as they expose internals.
+func ServerErrorDetailsResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, err error, details map[string]string) {
+	serverErrorResponse(logger, w, r, err, "internal_error", "server encountered a problem and could not process your request", details)
+}
+
+// CorruptDataResponse is ServerErrorResponse for data that is stored but can't be
+// read(repository.ErrCorrupt). The error is logged with corrupt=true and sent with
+// the data_corrupt code, so it isn't mistaken for a missing resource.
+func CorruptDataResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, err error) {
+	logger = logger.With(slog.Bool("corrupt", true))
+	serverErrorResponse(logger, w, r, err, "data_corrupt", "stored data is corrupt and could not be read", nil)
+}
+
+// serverErrorResponse logs the error and writes 500 with the code, the message and
+// the request id, so the client can report it.
+func serverErrorResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, err error, code, msg string, details map[string]string) {
+	id := requestid.FromContext(r.Context())
+	if id != "" {
+		logger = logger.With(slog.String("request_id", id))
+	}
+
 	logError(logger, r, err)
 
-	message := "server encountered a problem and could not process your request"
-	errorResponse(logger, w, r, http.StatusInternalServerError, message)
+	message := map[string]string{
+		"message": i18n.Translate(i18n.Language(r), code, msg),
+	}
+	if id != "" {
+		message["request_id"] = id
+	}
+	for key, value := range details {
+		message[key] = value
+	}
+
+	errorResponse(logger, w, r, http.StatusInternalServerError, code, message, nil)
 }
 
 func NotFoundResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request) {
 	message := "requested resource could not be found"
-	errorResponse(logger, w, r, http.StatusNotFound, message)
+	errorResponse(logger, w, r, http.StatusNotFound, "not_found", message, nil)
 }
 
-func FailedValidationResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, errors map[string]string) {
-	errorResponse(logger, w, r, http.StatusBadRequest, errors)
+// ValidationErrorFields counts validation errors by field name. It's published
+// as validation_errors by metrics.InitMetrics.
+var ValidationErrorFields = new(expvar.Map)
+
+// FailedValidationResponse writes validation errors, translated to the request language
+// for the errors with codes.
+func FailedValidationResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, v *validator.Validator) {
+	lang := i18n.Language(r)
+
+	errors := make(map[string]string, len(v.Errors))
+	for key, message := range v.Errors {
+		ValidationErrorFields.Add(key, 1)
+
+		if code, ok := v.Codes[key]; ok {
+			message = i18n.Translate(lang, code.Code, message, code.Args...)
+		}
+		errors[key] = message
+	}
+
+	errorResponse(logger, w, r, http.StatusBadRequest, "validation_failed", errors, nil)
 }
 
 func DuplicateTaskResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request) {
 	message := "task with this id already exists"
-	errorResponse(logger, w, r, http.StatusConflict, message)
+	errorResponse(logger, w, r, http.StatusConflict, "task_exists", message, nil)
+}
+
+func DuplicateTitleResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request) {
+	message := "open task with this title already exists"
+	errorResponse(logger, w, r, http.StatusConflict, "duplicate_title", message, nil)
+}
+
+func ServiceUnavailableResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, retryAfter int) {
+	headers := http.Header{"Retry-After": []string{strconv.Itoa(retryAfter)}}
+
+	message := "server is busy, please retry later"
+	errorResponse(logger, w, r, http.StatusServiceUnavailable, "service_unavailable", message, headers)
+}
+
+func TimeoutResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request) {
+	message := "request took too long to process"
+	errorResponse(logger, w, r, http.StatusServiceUnavailable, "request_timeout", message, nil)
+}
+
+func RateLimitExceededResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request) {
+	headers := http.Header{"Retry-After": []string{"1"}}
+
+	message := "rate limit exceeded"
+	errorResponse(logger, w, r, http.StatusTooManyRequests, "rate_limited", message, headers)
+}
+
+func UnauthorizedResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request) {
+	headers := http.Header{"Www-Authenticate": []string{"Bearer"}}
+
+	message := "invalid or missing authentication token"
+	errorResponse(logger, w, r, http.StatusUnauthorized, "unauthorized", message, headers)
+}
+
+func RequestHeaderFieldsTooLargeResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, message string) {
+	errorResponse(logger, w, r, http.StatusRequestHeaderFieldsTooLarge, "request_too_large", message, nil)
+}
+
+// PreconditionFailedResponse is sent when the If-Match header doesn't match the current resource.
+func PreconditionFailedResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request) {
+	message := "resource was modified, If-Match does not match its current ETag"
+	errorResponse(logger, w, r, http.StatusPreconditionFailed, "precondition_failed", message, nil)
+}
+
+// PreconditionRequiredResponse is sent when a conditional request has no If-Match header.
+func PreconditionRequiredResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request) {
+	message := "request must be conditional, If-Match header is required"
+	errorResponse(logger, w, r, http.StatusPreconditionRequired, "precondition_required", message, nil)
+}
+
+func UnsupportedMediaTypeResponse(logger *slog.Logger, w http.ResponseWriter, r *http.Request, message string) {
+	errorResponse(logger, w, r, http.StatusUnsupportedMediaType, "unsupported_media_type", message, nil)
 }
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+)
+
+// JSONID is an int64 id encoded as a JSON number. It's decoded from both a number and
+// a string holding a number, so clients sending ids as strings(e.g. to keep the
+// precision of ids above 2^53) are accepted.
+type JSONID int64
+
+// MarshalJSON implements json.Marshaler.
+func (id JSONID) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(id), 10), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. null leaves the id unchanged, like
+// for a plain int64.
+func (id *JSONID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
+	s := string(data)
+	value := "number"
+	if data[0] == '"' {
+		value = "string"
+		err := json.Unmarshal(data, &s)
+		if err != nil {
+			return err
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: value, Type: reflect.TypeFor[JSONID]()}
+	}
+
+	*id = JSONID(n)
+	return nil
+}
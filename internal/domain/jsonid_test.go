@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONID(t *testing.T) {
+	// 2^53 + 1 can't be represented as float64
+	const largeID = 9007199254740993
+
+	t.Run("decode string id", func(t *testing.T) {
+		var task Task
+		err := json.Unmarshal([]byte(`{"id":"9007199254740993","title":"Task"}`), &task)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if task.ID != largeID {
+			t.Errorf("Expected id %d, got %d", int64(largeID), task.ID)
+		}
+		if task.Title != "Task" {
+			t.Errorf("Expected title 'Task', got '%s'", task.Title)
+		}
+	})
+
+	t.Run("number ids", func(t *testing.T) {
+		js, err := json.Marshal(BulkResult{ID: largeID, Status: BulkUpdated})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if string(js) != `{"id":9007199254740993,"status":"updated"}` {
+			t.Errorf("Expected id encoded as number, got %s", js)
+		}
+	})
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    JSONID
+		expectError bool
+	}{
+		{name: "number", input: `9007199254740993`, expected: largeID},
+		{name: "string", input: `"9007199254740993"`, expected: largeID},
+		{name: "null", input: `null`, expected: 0},
+		{name: "empty string", input: `""`, expectError: true},
+		{name: "not a number", input: `"abc"`, expectError: true},
+		{name: "fraction", input: `1.5`, expectError: true},
+		{name: "bool", input: `true`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var id JSONID
+			err := json.Unmarshal([]byte(tt.input), &id)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got id %d", id)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if id != tt.expected {
+				t.Errorf("Expected id %d, got %d", tt.expected, id)
+			}
+		})
+	}
+}
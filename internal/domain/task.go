@@ -1,17 +1,150 @@
 package domain
 
 import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
 	"unicode/utf8"
 
 	"github.com/vladgrskkh/todo/pkg/validator"
 )
 
+const (
+	// maxSubtasks limits the number of subtasks a task can have.
+	maxSubtasks = 100
+	// maxSubtaskTitleLength limits subtask title length(in symbols).
+	maxSubtaskTitleLength = 100
+	// maxTags limits the number of tags a task can have.
+	maxTags = 20
+	// maxTagLength limits tag length(in symbols).
+	maxTagLength = 50
+)
+
+// MaxTaskID is the largest task id. Ids stay exact in JavaScript numbers, and ids
+// sent by clients can't take all the ids left for the server to generate.
+const MaxTaskID = 1<<53 - 1
+
+// Task priorities. Empty priority means the task has no priority.
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+)
+
+// Priorities is the list of valid task priorities.
+var Priorities = []string{PriorityLow, PriorityMedium, PriorityHigh}
+
+// ValidationLimits holds configurable limits used by task validation.
+type ValidationLimits struct {
+	MaxTitleLength       int
+	MaxDescriptionLength int
+
+	// ByPriority overrides the length limits for tasks with the priority.
+	ByPriority map[string]PriorityLimits
+}
+
+// PriorityLimits are the length limits of tasks with a priority, 0 keeps the general limit.
+type PriorityLimits struct {
+	MaxTitleLength       int
+	MaxDescriptionLength int
+}
+
+// forPriority returns the title and description length limits of tasks with the priority.
+func (l ValidationLimits) forPriority(priority string) (maxTitle int, maxDescription int) {
+	maxTitle, maxDescription = l.MaxTitleLength, l.MaxDescriptionLength
+
+	override := l.ByPriority[priority]
+	if override.MaxTitleLength > 0 {
+		maxTitle = override.MaxTitleLength
+	}
+	if override.MaxDescriptionLength > 0 {
+		maxDescription = override.MaxDescriptionLength
+	}
+
+	return maxTitle, maxDescription
+}
+
+// DefaultValidationLimits returns limits used when none are configured.
+func DefaultValidationLimits() ValidationLimits {
+	return ValidationLimits{
+		MaxTitleLength:       100,
+		MaxDescriptionLength: 2000,
+	}
+}
+
+type Subtask struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
 type Task struct {
-	ID          int64  `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Done        bool   `json:"done"`
-	version     int    `json:"-"`
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Done        bool       `json:"done"`
+	Subtasks    []Subtask  `json:"subtasks,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     int        `json:"version"`              // incremented on every update, read-only for clients
+	Position    int        `json:"position"`             // manual order, assigned on create and changed by move
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // set while the task is in the trash
+}
+
+// TagCount is the number of tasks using the tag.
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TaskStats is the summary of task counts.
+type TaskStats struct {
+	Total          int `json:"total"`
+	Done           int `json:"done"`
+	Open           int `json:"open"`
+	CompletedToday int `json:"completed_today"` // done tasks last updated today
+}
+
+// DailyStats is the number of tasks created and completed on the day.
+type DailyStats struct {
+	Date      string `json:"date"` // YYYY-MM-DD
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"` // done tasks last updated on the day
+}
+
+// TaskBoard is the tasks grouped by status.
+type TaskBoard struct {
+	Open []*Task `json:"open"`
+	Done []*Task `json:"done"`
+}
+
+// Statuses of BulkResult.
+const (
+	BulkUpdated  = "updated"
+	BulkNotFound = "notfound"
+	BulkConflict = "conflict"
+)
+
+// BulkResult is the outcome of a bulk operation for one task. Errors explain
+// a conflict(e.g. the task is completed).
+type BulkResult struct {
+	ID     JSONID            `json:"id"`
+	Status string            `json:"status"`
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// SortByPosition sorts tasks by position ascending, tasks with the same position by id.
+func SortByPosition(tasks []*Task) {
+	slices.SortFunc(tasks, func(a, b *Task) int {
+		if a.Position != b.Position {
+			return a.Position - b.Position
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
 }
 
 func NewTask(id int64, title string, description string) *Task {
@@ -20,27 +153,199 @@ func NewTask(id int64, title string, description string) *Task {
 		Title:       title,
 		Description: description,
 		Done:        false,
-		version:     1,
+		Version:     1,
 	}
 }
 
 // Update modifies the task with the provided title, description and done status.
 // It checks that the task is not completed before modifying it.
 func (t *Task) Update(v *validator.Validator, title string, description string, done bool) {
-	v.Check(!t.Done, "done", "cannot modify a completed task")
+	v.CheckCode(!t.Done, "done", "task_completed", "cannot modify a completed task")
 
 	t.Title = title
 	t.Description = description
 	t.Done = done
 
-	t.version++
+	t.Version++
+}
+
+// Progress returns the fraction of done subtasks(from 0 to 1).
+// Task without subtasks has progress 1 if it is done and 0 otherwise.
+func (t *Task) Progress() float64 {
+	if len(t.Subtasks) == 0 {
+		if t.Done {
+			return 1
+		}
+		return 0
+	}
+
+	done := 0
+	for _, st := range t.Subtasks {
+		if st.Done {
+			done++
+		}
+	}
+
+	return float64(done) / float64(len(t.Subtasks))
 }
 
+// MarshalJSON adds computed fields(progress) to the JSON representation of the task.
+func (t Task) MarshalJSON() ([]byte, error) {
+	// alias type without methods to avoid MarshalJSON recursion
+	type task Task
+
+	return json.Marshal(struct {
+		task
+		ID       JSONID  `json:"id"`
+		Progress float64 `json:"progress"`
+	}{
+		task:     task(t),
+		ID:       JSONID(t.ID),
+		Progress: t.Progress(),
+	})
+}
+
+// UnmarshalJSON accepts the id both as a number and as a string(see JSONID).
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type task Task
+
+	aux := struct {
+		*task
+		ID *JSONID `json:"id"`
+	}{
+		task: (*task)(t),
+		ID:   (*JSONID)(&t.ID),
+	}
+
+	return json.Unmarshal(data, &aux)
+}
+
+// ValidateTask validates the task with DefaultValidationLimits.
 func ValidateTask(v *validator.Validator, task *Task) {
-	v.Check(task.ID > 0, "id", "must be a positive integer")
+	ValidateTaskWithLimits(v, task, DefaultValidationLimits())
+}
+
+// ValidateTaskWithLimits validates the task using provided limits for title and description
+// length, overridden by the limits of the task priority.
+func ValidateTaskWithLimits(v *validator.Validator, task *Task, limits ValidationLimits) {
+	v.CheckCode(task.ID > 0, "id", "positive_integer", "must be a positive integer")
+	v.CheckCode(task.ID <= MaxTaskID, "id", "too_large", "must not be greater than %d", MaxTaskID)
+
+	ValidateTaskFields(v, task, limits)
+}
+
+// ValidateTaskFields validates the task like ValidateTaskWithLimits, except for the id
+// (e.g. before the id is generated).
+func ValidateTaskFields(v *validator.Validator, task *Task, limits ValidationLimits) {
+	maxTitle, maxDescription := limits.forPriority(task.Priority)
+
+	v.CheckCode(task.Title != "", "title", "required", "must be provided")
+	v.CheckCode(utf8.RuneCountInString(task.Title) <= maxTitle, "title",
+		"too_long", "must not be more than %d symbols long", maxTitle)
+	v.CheckCode(validator.NoControlChars(task.Title), "title", "control_chars", "must not contain control characters")
+
+	v.CheckCode(utf8.RuneCountInString(task.Description) <= maxDescription, "description",
+		"too_long", "must not be more than %d symbols long", maxDescription)
+	v.CheckCode(validator.NoControlChars(task.Description), "description", "control_chars", "must not contain control characters")
+
+	v.CheckCode(len(task.Subtasks) <= maxSubtasks, "subtasks", "too_many_items", "must not contain more than %d subtasks", maxSubtasks)
+	for _, st := range task.Subtasks {
+		v.CheckCode(st.Title != "", "subtasks", "item_required", "subtask title must be provided")
+		v.CheckCode(utf8.RuneCountInString(st.Title) <= maxSubtaskTitleLength, "subtasks", "item_too_long",
+			"subtask title must not be more than %d symbols long", maxSubtaskTitleLength)
+		v.CheckCode(validator.NoControlChars(st.Title), "subtasks", "item_control_chars", "subtask title must not contain control characters")
+	}
+
+	v.CheckCode(len(task.Tags) <= maxTags, "tags", "too_many_items", "must not contain more than %d tags", maxTags)
+	for _, tag := range task.Tags {
+		v.CheckCode(tag != "", "tags", "item_required", "tag must not be empty")
+		v.CheckCode(utf8.RuneCountInString(tag) <= maxTagLength, "tags", "item_too_long", "tag must not be more than %d symbols long", maxTagLength)
+		v.CheckCode(validator.NoControlChars(tag), "tags", "item_control_chars", "tag must not contain control characters")
+	}
+
+	v.CheckCode(task.Priority == "" || slices.Contains(Priorities, task.Priority), "priority",
+		"permitted_value", "must be one of %s", strings.Join(Priorities, ", "))
+}
 
-	v.Check(task.Title != "", "title", "must be provided")
-	v.Check(utf8.RuneCountInString(task.Title) <= 100, "title", "must not be more than 100 symbols long")
+// TaskFilter selects tasks matching all of the set fields. Zero filter matches all tasks.
+type TaskFilter struct {
+	Done     *bool
+	Priority string
+	Tag      string
+	// Query matches tasks with title or description containing it, case-insensitive.
+	Query string
+	// CreatedFrom and CreatedTo match tasks created within [CreatedFrom, CreatedTo],
+	// a zero bound leaves the range open on its side.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// DueFrom and DueTo match tasks due within [DueFrom, DueTo] like the created range,
+	// tasks without a due date don't match if either is set.
+	DueFrom time.Time
+	DueTo   time.Time
+	// DueWithin matches tasks due between now and now+DueWithin. It's resolved into
+	// DueFrom and DueTo by the service, with its clock.
+	DueWithin time.Duration
+}
+
+// Match reports whether the task matches the filter.
+func (f TaskFilter) Match(t *Task) bool {
+	if f.Done != nil && t.Done != *f.Done {
+		return false
+	}
+	if f.Priority != "" && t.Priority != f.Priority {
+		return false
+	}
+	if f.Tag != "" && !slices.Contains(t.Tags, f.Tag) {
+		return false
+	}
+	if f.Query != "" {
+		query := strings.ToLower(f.Query)
+		if !strings.Contains(strings.ToLower(t.Title), query) && !strings.Contains(strings.ToLower(t.Description), query) {
+			return false
+		}
+	}
+	if !inRange(&t.CreatedAt, f.CreatedFrom, f.CreatedTo) {
+		return false
+	}
+	if !inRange(t.DueDate, f.DueFrom, f.DueTo) {
+		return false
+	}
+
+	return true
+}
+
+// inRange reports whether t is within [from, to], a zero bound is open. Nil t is
+// in range only if both bounds are open.
+func inRange(t *time.Time, from, to time.Time) bool {
+	if from.IsZero() && to.IsZero() {
+		return true
+	}
+
+	return t != nil && !t.Before(from) && (to.IsZero() || !t.After(to))
+}
+
+// Apply returns the tasks matching the filter, preserving their order.
+func (f TaskFilter) Apply(tasks []*Task) []*Task {
+	matched := make([]*Task, 0, len(tasks))
+	for _, task := range tasks {
+		if f.Match(task) {
+			matched = append(matched, task)
+		}
+	}
+
+	return matched
+}
+
+// GroupByStatus groups the tasks into open and done ones, preserving their order.
+func GroupByStatus(tasks []*Task) TaskBoard {
+	board := TaskBoard{Open: make([]*Task, 0), Done: make([]*Task, 0)}
+	for _, task := range tasks {
+		if task.Done {
+			board.Done = append(board.Done, task)
+		} else {
+			board.Open = append(board.Open, task)
+		}
+	}
 
-	v.Check(utf8.RuneCountInString(task.Description) <= 2000, "description", "must not be more than 2000 symbols long")
+	return board
 }
@@ -1,7 +1,10 @@
 package domain
 
 import (
+	"encoding/json"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/vladgrskkh/todo/pkg/validator"
 )
@@ -22,8 +25,8 @@ func TestTaskUpdate(t *testing.T) {
 		if !task.Done {
 			t.Error("Expected task to be done")
 		}
-		if task.version != 2 {
-			t.Errorf("Expected version 2, got %d", task.version)
+		if task.Version != 2 {
+			t.Errorf("Expected version 2, got %d", task.Version)
 		}
 		if !v.Valid() {
 			t.Errorf("Expected validator to be valid, got errors: %v", v.Errors)
@@ -78,6 +81,11 @@ func TestValidateTask(t *testing.T) {
 			task:  NewTask(-1, "Valid Title", "Valid Description"),
 			valid: false,
 		},
+		{
+			name:  "too large ID task",
+			task:  NewTask(MaxTaskID+1, "Valid Title", "Valid Description"),
+			valid: false,
+		},
 		{
 			name:  "empty title task",
 			task:  NewTask(1, "", "Valid Description"),
@@ -93,6 +101,36 @@ func TestValidateTask(t *testing.T) {
 			task:  NewTask(-99, "", ""),
 			valid: false,
 		},
+		{
+			name:  "title with newline",
+			task:  NewTask(1, "Valid\nTitle", "Valid Description"),
+			valid: false,
+		},
+		{
+			name:  "title with NUL",
+			task:  NewTask(1, "Valid\x00Title", "Valid Description"),
+			valid: false,
+		},
+		{
+			name:  "description with NUL",
+			task:  NewTask(1, "Valid Title", "Valid\x00Description"),
+			valid: false,
+		},
+		{
+			name:  "subtask title with newline",
+			task:  &Task{ID: 1, Title: "Valid Title", Subtasks: []Subtask{{Title: "Valid\nSubtask"}}},
+			valid: false,
+		},
+		{
+			name:  "tag with NUL",
+			task:  &Task{ID: 1, Title: "Valid Title", Tags: []string{"wo\x00rk"}},
+			valid: false,
+		},
+		{
+			name:  "unicode title and description",
+			task:  NewTask(1, "Купить молоко 🥛", "Описание\tс табом"),
+			valid: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -193,3 +231,314 @@ func TestValidateTask(t *testing.T) {
 		}
 	})
 }
+
+func TestTaskProgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		done     bool
+		subtasks []Subtask
+		expected float64
+	}{
+		{
+			name:     "all subtasks done",
+			subtasks: []Subtask{{Title: "a", Done: true}, {Title: "b", Done: true}},
+			expected: 1,
+		},
+		{
+			name:     "no subtasks done",
+			subtasks: []Subtask{{Title: "a"}, {Title: "b"}},
+			expected: 0,
+		},
+		{
+			name:     "partially done",
+			subtasks: []Subtask{{Title: "a", Done: true}, {Title: "b"}, {Title: "c"}, {Title: "d", Done: true}},
+			expected: 0.5,
+		},
+		{
+			name:     "no subtasks and done",
+			done:     true,
+			expected: 1,
+		},
+		{
+			name:     "no subtasks and not done",
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := NewTask(1, "Task", "Description")
+			task.Done = tt.done
+			task.Subtasks = tt.subtasks
+
+			if got := task.Progress(); got != tt.expected {
+				t.Errorf("Expected progress %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	t.Run("progress is included in JSON", func(t *testing.T) {
+		task := NewTask(1, "Task", "Description")
+		task.Subtasks = []Subtask{{Title: "a", Done: true}, {Title: "b"}}
+
+		data, err := json.Marshal(task)
+		if err != nil {
+			t.Fatalf("Failed to marshal task: %v", err)
+		}
+
+		var response map[string]any
+		err = json.Unmarshal(data, &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal task: %v", err)
+		}
+
+		if response["progress"] != 0.5 {
+			t.Errorf("Expected progress 0.5, got %v", response["progress"])
+		}
+		if response["title"] != "Task" {
+			t.Errorf("Expected title 'Task', got %v", response["title"])
+		}
+	})
+}
+
+func TestValidateTaskSubtasks(t *testing.T) {
+	t.Run("rejects subtask without title", func(t *testing.T) {
+		task := NewTask(1, "Task", "Description")
+		task.Subtasks = []Subtask{{Title: ""}}
+		v := validator.New()
+
+		ValidateTask(v, task)
+
+		if _, exists := v.Errors["subtasks"]; !exists {
+			t.Error("Expected 'subtasks' error to exist")
+		}
+	})
+
+	t.Run("accepts valid subtasks", func(t *testing.T) {
+		task := NewTask(1, "Task", "Description")
+		task.Subtasks = []Subtask{{Title: "a"}, {Title: "b", Done: true}}
+		v := validator.New()
+
+		ValidateTask(v, task)
+
+		if !v.Valid() {
+			t.Errorf("Expected task to be valid, got errors: %v", v.Errors)
+		}
+	})
+}
+
+func TestValidateTaskWithLimits(t *testing.T) {
+	limits := ValidationLimits{MaxTitleLength: 5, MaxDescriptionLength: 10}
+
+	t.Run("enforces custom limits", func(t *testing.T) {
+		task := NewTask(1, "Too long title", "Too long description")
+		v := validator.New()
+
+		ValidateTaskWithLimits(v, task, limits)
+
+		if v.Errors["title"] != "must not be more than 5 symbols long" {
+			t.Errorf("Unexpected title error: '%s'", v.Errors["title"])
+		}
+		if v.Errors["description"] != "must not be more than 10 symbols long" {
+			t.Errorf("Unexpected description error: '%s'", v.Errors["description"])
+		}
+	})
+
+	t.Run("accepts values within custom limits", func(t *testing.T) {
+		task := NewTask(1, "Title", "Short")
+		v := validator.New()
+
+		ValidateTaskWithLimits(v, task, limits)
+
+		if !v.Valid() {
+			t.Errorf("Expected task to be valid, got errors: %v", v.Errors)
+		}
+	})
+
+	t.Run("priority limits override the general ones", func(t *testing.T) {
+		limits := ValidationLimits{
+			MaxTitleLength:       5,
+			MaxDescriptionLength: 10,
+			ByPriority: map[string]PriorityLimits{
+				PriorityHigh: {MaxDescriptionLength: 50},
+				PriorityLow:  {MaxTitleLength: 3, MaxDescriptionLength: 5},
+			},
+		}
+		description := "Description of 30 symbols long"
+
+		high := NewTask(1, "Title", description)
+		high.Priority = PriorityHigh
+		v := validator.New()
+
+		ValidateTaskWithLimits(v, high, limits)
+
+		if !v.Valid() {
+			t.Errorf("Expected high priority task to be valid, got errors: %v", v.Errors)
+		}
+
+		low := NewTask(1, "Title", description)
+		low.Priority = PriorityLow
+		v = validator.New()
+
+		ValidateTaskWithLimits(v, low, limits)
+
+		if v.Errors["title"] != "must not be more than 3 symbols long" {
+			t.Errorf("Unexpected title error: '%s'", v.Errors["title"])
+		}
+		if v.Errors["description"] != "must not be more than 5 symbols long" {
+			t.Errorf("Unexpected description error: '%s'", v.Errors["description"])
+		}
+
+		medium := NewTask(1, "Title", description)
+		medium.Priority = PriorityMedium
+		v = validator.New()
+
+		ValidateTaskWithLimits(v, medium, limits)
+
+		if v.Errors["description"] != "must not be more than 10 symbols long" {
+			t.Errorf("Expected general description limit without priority limits, got '%s'", v.Errors["description"])
+		}
+	})
+
+	t.Run("default limits are preserved", func(t *testing.T) {
+		defaults := DefaultValidationLimits()
+
+		if defaults.MaxTitleLength != 100 || defaults.MaxDescriptionLength != 2000 {
+			t.Errorf("Unexpected default limits: %+v", defaults)
+		}
+	})
+}
+
+func TestValidateTaskTags(t *testing.T) {
+	t.Run("rejects empty tag", func(t *testing.T) {
+		task := NewTask(1, "Task", "Description")
+		task.Tags = []string{"work", ""}
+		v := validator.New()
+
+		ValidateTask(v, task)
+
+		if _, exists := v.Errors["tags"]; !exists {
+			t.Error("Expected 'tags' error to exist")
+		}
+	})
+
+	t.Run("rejects too many tags", func(t *testing.T) {
+		task := NewTask(1, "Task", "Description")
+		for i := 0; i < 21; i++ {
+			task.Tags = append(task.Tags, "tag")
+		}
+		v := validator.New()
+
+		ValidateTask(v, task)
+
+		if _, exists := v.Errors["tags"]; !exists {
+			t.Error("Expected 'tags' error to exist")
+		}
+	})
+}
+
+func TestValidateTaskPriority(t *testing.T) {
+	tests := []struct {
+		priority  string
+		expectErr bool
+	}{
+		{"", false},
+		{PriorityLow, false},
+		{PriorityHigh, false},
+		{"urgent", true},
+		{"High", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.priority, func(t *testing.T) {
+			task := NewTask(1, "Task", "Description")
+			task.Priority = tt.priority
+			v := validator.New()
+
+			ValidateTask(v, task)
+
+			if _, exists := v.Errors["priority"]; exists != tt.expectErr {
+				t.Errorf("Expected 'priority' error %v, got %v", tt.expectErr, v.Errors)
+			}
+		})
+	}
+}
+
+func TestGroupByStatus(t *testing.T) {
+	first := NewTask(1, "First", "")
+	done := NewTask(2, "Done", "")
+	done.Done = true
+	second := NewTask(3, "Second", "")
+
+	board := GroupByStatus([]*Task{first, done, second})
+
+	if len(board.Open) != 2 || board.Open[0].ID != 1 || board.Open[1].ID != 3 {
+		t.Errorf("Expected open tasks 1 and 3, got %v", board.Open)
+	}
+	if len(board.Done) != 1 || board.Done[0].ID != 2 {
+		t.Errorf("Expected done task 2, got %v", board.Done)
+	}
+
+	empty := GroupByStatus(nil)
+	if empty.Open == nil || empty.Done == nil {
+		t.Error("Expected empty groups to be non-nil")
+	}
+}
+
+func TestTaskFilter(t *testing.T) {
+	report := NewTask(1, "Write report", "Quarterly")
+	report.Priority = PriorityHigh
+	report.Tags = []string{"work"}
+
+	done := NewTask(2, "Send REPORT", "")
+	done.Priority = PriorityHigh
+	done.Tags = []string{"work"}
+	done.Done = true
+
+	home := NewTask(3, "Clean", "Report to the landlord")
+	home.Priority = PriorityLow
+	home.Tags = []string{"home"}
+
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, task := range []*Task{report, done, home} {
+		task.CreatedAt = day.AddDate(0, 0, i)
+	}
+	due := day.AddDate(0, 0, 5)
+	report.DueDate = &due
+
+	tasks := []*Task{report, done, home}
+	notDone, isDone := false, true
+
+	tests := []struct {
+		name     string
+		filter   TaskFilter
+		expected []int64
+	}{
+		{"empty filter returns all", TaskFilter{}, []int64{1, 2, 3}},
+		{"done", TaskFilter{Done: &isDone}, []int64{2}},
+		{"query matches title and description", TaskFilter{Query: "report"}, []int64{1, 2, 3}},
+		{"done and priority", TaskFilter{Done: &notDone, Priority: PriorityHigh}, []int64{1}},
+		{"all filters", TaskFilter{Done: &notDone, Priority: PriorityHigh, Tag: "work", Query: "report"}, []int64{1}},
+		{"no matches", TaskFilter{Tag: "home", Priority: PriorityHigh}, []int64{}},
+		{"created range", TaskFilter{CreatedFrom: day.AddDate(0, 0, 1), CreatedTo: day.AddDate(0, 0, 2)}, []int64{2, 3}},
+		{"created from", TaskFilter{CreatedFrom: day.AddDate(0, 0, 2)}, []int64{3}},
+		{"created to and tag", TaskFilter{CreatedTo: day.AddDate(0, 0, 1), Tag: "work"}, []int64{1, 2}},
+		{"due range", TaskFilter{DueFrom: day, DueTo: due}, []int64{1}},
+		{"due from excludes tasks without due date", TaskFilter{DueFrom: day}, []int64{1}},
+		{"due before the range", TaskFilter{DueTo: day}, []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matched := tt.filter.Apply(tasks)
+
+			ids := make([]int64, 0, len(matched))
+			for _, task := range matched {
+				ids = append(ids, task.ID)
+			}
+			if !slices.Equal(ids, tt.expected) {
+				t.Errorf("Expected tasks %v, got %v", tt.expected, ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/handlers/dto"
+	"github.com/vladgrskkh/todo/internal/handlers/middleware/metrics"
+	s "github.com/vladgrskkh/todo/internal/service"
+	"github.com/vladgrskkh/todo/pkg/jsonhttp"
+	"github.com/vladgrskkh/todo/pkg/validator"
+)
+
+// csvHeader is the header row of the CSV export. Tags are joined with ";".
+var csvHeader = []string{"id", "title", "description", "done", "tags", "due_date", "created_at", "updated_at", "position"}
+
+type TaskExporter interface {
+	GetAllTasks() ([]*domain.Task, error)
+}
+
+// NewExportTasksCSVHandler streams all tasks sorted by id as CSV with a header row.
+func NewExportTasksCSVHandler(logger *slog.Logger, service TaskExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tasks, err := service.GetAllTasks()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		slices.SortFunc(tasks, func(a, b *domain.Task) int {
+			return cmp.Compare(a.ID, b.ID)
+		})
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="todos.csv"`)
+		w.WriteHeader(http.StatusOK)
+
+		cw := csv.NewWriter(w)
+
+		// response is already started, so write errors can only be logged
+		err = cw.Write(csvHeader)
+		if err != nil {
+			logger.Error("error writing csv", slog.String("error", err.Error()))
+			return
+		}
+
+		for _, task := range tasks {
+			err = cw.Write(taskCSVRecord(task))
+			if err != nil {
+				logger.Error("error writing csv", slog.String("error", err.Error()))
+				return
+			}
+		}
+
+		cw.Flush()
+		err = cw.Error()
+		if err != nil {
+			logger.Error("error writing csv", slog.String("error", err.Error()))
+		}
+	}
+}
+
+// taskCSVRecord converts the task to a CSV row matching csvHeader.
+func taskCSVRecord(task *domain.Task) []string {
+	dueDate := ""
+	if task.DueDate != nil {
+		dueDate = task.DueDate.Format(time.RFC3339)
+	}
+
+	return []string{
+		strconv.FormatInt(task.ID, 10),
+		task.Title,
+		task.Description,
+		strconv.FormatBool(task.Done),
+		strings.Join(task.Tags, ";"),
+		dueDate,
+		task.CreatedAt.Format(time.RFC3339),
+		task.UpdatedAt.Format(time.RFC3339),
+		strconv.Itoa(task.Position),
+	}
+}
+
+// maxImportBytes limits the size of the imported CSV file.
+const maxImportBytes = 10 * 1_048_576 // 10 MB
+
+type TaskImporter interface {
+	CreateTask(task *domain.Task) error
+}
+
+// importFailure describes a CSV row that was not imported. Error is either
+// a message or validation errors map.
+type importFailure struct {
+	Line  int `json:"line"`
+	Error any `json:"error"`
+}
+
+// NewImportTasksCSVHandler creates tasks from a CSV body(Content-Type: text/csv).
+// The first row must be a header with at least id and title columns, description,
+// tags(joined with ";") and due_date(RFC 3339) are optional and unknown columns are
+// ignored, so the file produced by the CSV export can be imported back.
+// Invalid rows are reported with their line numbers and don't abort the import.
+func NewImportTasksCSVHandler(logger *slog.Logger, service TaskImporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "text/csv" {
+			apierrors.UnsupportedMediaTypeResponse(logger, w, r, "content type must be text/csv")
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+		cr := csv.NewReader(r.Body)
+
+		header, err := cr.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errors.New("body must not be empty")
+			}
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		columns := make(map[string]int, len(header))
+		for i, name := range header {
+			columns[strings.TrimSpace(name)] = i
+		}
+
+		_, hasID := columns["id"]
+		_, hasTitle := columns["title"]
+		if !hasID || !hasTitle {
+			apierrors.BadRequestResponse(logger, w, r, errors.New("csv must start with a header row containing id and title columns"))
+			return
+		}
+
+		succeeded := 0
+		failed := make([]importFailure, 0)
+
+		for {
+			record, err := cr.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				var parseErr *csv.ParseError
+				if !errors.As(err, &parseErr) {
+					apierrors.BadRequestResponse(logger, w, r, err)
+					return
+				}
+
+				failed = append(failed, importFailure{Line: parseErr.StartLine, Error: parseErr.Err.Error()})
+
+				// rows with wrong number of fields are skipped, other parse errors
+				// leave the reader in unknown state, so the rest of the file is skipped
+				if errors.Is(parseErr.Err, csv.ErrFieldCount) {
+					continue
+				}
+				break
+			}
+
+			line, _ := cr.FieldPos(0)
+
+			input, err := csvCreateTaskInput(record, columns)
+			if err != nil {
+				failed = append(failed, importFailure{Line: line, Error: err.Error()})
+				continue
+			}
+
+			task := domain.NewTask(int64(input.ID), input.Title, input.Description)
+			task.Tags = input.Tags
+			task.DueDate = input.DueDate
+
+			err = service.CreateTask(task)
+			if err != nil {
+				var validationErr *validator.Validator
+				switch {
+				case errors.As(err, &validationErr):
+					failed = append(failed, importFailure{Line: line, Error: validationErr.Errors})
+				case errors.Is(err, s.ErrTaskExists), errors.Is(err, s.ErrDuplicateTitle):
+					failed = append(failed, importFailure{Line: line, Error: err.Error()})
+				default:
+					apierrors.ServerErrorResponse(logger, w, r, err)
+					return
+				}
+
+				continue
+			}
+
+			succeeded++
+			metrics.IncTasksCreated()
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"succeeded": succeeded, "failed": failed}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+// csvCreateTaskInput builds CreateTaskInput from the CSV record using header columns.
+func csvCreateTaskInput(record []string, columns map[string]int) (dto.CreateTaskInput, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var input dto.CreateTaskInput
+
+	id, err := strconv.ParseInt(field("id"), 10, 64)
+	if err != nil {
+		return input, fmt.Errorf("invalid id %q", field("id"))
+	}
+
+	input.ID = domain.JSONID(id)
+	input.Title = field("title")
+	input.Description = field("description")
+
+	if tags := field("tags"); tags != "" {
+		for tag := range strings.SplitSeq(tags, ";") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				input.Tags = append(input.Tags, tag)
+			}
+		}
+	}
+
+	if dueDate := field("due_date"); dueDate != "" {
+		t, err := time.Parse(time.RFC3339, dueDate)
+		if err != nil {
+			return input, fmt.Errorf("invalid due_date %q: must be in RFC 3339 format", dueDate)
+		}
+		input.DueDate = &t
+	}
+
+	return input, nil
+}
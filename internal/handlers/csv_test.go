@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/handlers/mocks"
+	"github.com/vladgrskkh/todo/pkg/validator"
+)
+
+func TestNewExportTasksCSVHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("exports tasks as CSV", func(t *testing.T) {
+		first := domain.NewTask(2, "Task, with comma", "Multi\nline \"quoted\" description")
+		first.Tags = []string{"work", "home"}
+		second := domain.NewTask(1, "Task 1", "Description 1")
+
+		mockService := mocks.NewMockTaskGetter(nil, []*domain.Task{first, second}, nil, nil)
+		handler := NewExportTasksCSVHandler(logger, mockService)
+
+		req := httptest.NewRequest("GET", "/todos.csv", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+			t.Errorf("Expected Content-Type 'text/csv; charset=utf-8', got '%s'", w.Header().Get("Content-Type"))
+		}
+
+		records, err := csv.NewReader(w.Body).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse CSV: %v", err)
+		}
+
+		if len(records) != 3 {
+			t.Fatalf("Expected 3 rows(header + 2 tasks), got %d", len(records))
+		}
+		if !slices.Equal(records[0], csvHeader) {
+			t.Errorf("Expected header %v, got %v", csvHeader, records[0])
+		}
+		if records[1][0] != "1" || records[2][0] != "2" {
+			t.Errorf("Expected rows sorted by id, got %v and %v", records[1][0], records[2][0])
+		}
+		if records[2][1] != first.Title || records[2][2] != first.Description {
+			t.Errorf("Expected title and description to round trip, got %q and %q", records[2][1], records[2][2])
+		}
+		if records[2][4] != "work;home" {
+			t.Errorf("Expected tags 'work;home', got '%s'", records[2][4])
+		}
+	})
+
+	t.Run("returns server error when tasks fail to load", func(t *testing.T) {
+		mockService := mocks.NewMockTaskGetter(nil, nil, nil, errors.New("db error"))
+		handler := NewExportTasksCSVHandler(logger, mockService)
+
+		req := httptest.NewRequest("GET", "/todos.csv", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestNewImportTasksCSVHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	type failure struct {
+		Line  int `json:"line"`
+		Error any `json:"error"`
+	}
+
+	tests := []struct {
+		name              string
+		contentType       string
+		body              string
+		createErr         error
+		expectedCode      int
+		expectedSucceeded int
+		expectedLines     []int
+	}{
+		{
+			name:              "imports well-formed file",
+			contentType:       "text/csv",
+			body:              "id,title,description,tags,extra\n1,Task 1,\"Multi\nline\",work;home,x\n2,Task 2,,,y\n",
+			expectedCode:      http.StatusOK,
+			expectedSucceeded: 2,
+		},
+		{
+			name:              "reports bad rows without aborting",
+			contentType:       "text/csv; charset=utf-8",
+			body:              "id,title\n1,Task 1\nabc,Task 2\n3\n4,Task 4\n",
+			expectedCode:      http.StatusOK,
+			expectedSucceeded: 2,
+			expectedLines:     []int{3, 4},
+		},
+		{
+			name:              "reports validation errors",
+			contentType:       "text/csv",
+			body:              "id,title\n1,\n",
+			createErr:         validator.New(),
+			expectedCode:      http.StatusOK,
+			expectedSucceeded: 0,
+			expectedLines:     []int{2},
+		},
+		{
+			name:         "rejects missing header",
+			contentType:  "text/csv",
+			body:         "1,Task 1\n",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "rejects empty body",
+			contentType:  "text/csv",
+			body:         "",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "rejects wrong content type",
+			contentType:  "application/json",
+			body:         "id,title\n1,Task 1\n",
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskCreator(tt.createErr)
+			handler := NewImportTasksCSVHandler(logger, mockService)
+
+			req := httptest.NewRequest("POST", "/todos/import", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Succeeded int       `json:"succeeded"`
+				Failed    []failure `json:"failed"`
+			}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			if response.Succeeded != tt.expectedSucceeded {
+				t.Errorf("Expected %d succeeded, got %d", tt.expectedSucceeded, response.Succeeded)
+			}
+
+			lines := make([]int, 0, len(response.Failed))
+			for _, f := range response.Failed {
+				lines = append(lines, f.Line)
+			}
+			if len(lines) != len(tt.expectedLines) || (len(lines) > 0 && !slices.Equal(lines, tt.expectedLines)) {
+				t.Errorf("Expected failed lines %v, got %v", tt.expectedLines, response.Failed)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+	"github.com/vladgrskkh/todo/internal/paramutil"
+	"github.com/vladgrskkh/todo/internal/repository"
+	s "github.com/vladgrskkh/todo/internal/service"
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+	"github.com/vladgrskkh/todo/pkg/jsonhttp"
+)
+
+type DBStatsProvider interface {
+	DBStats() (inmemorydb.Stats, error)
+}
+
+// NewDebugStatsHandler returns runtime(goroutines, memory) and database stats.
+// Cheaper alternative to pprof for diagnosing leaks.
+func NewDebugStatsHandler(logger *slog.Logger, service DBStatsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dbStats, err := service.DBStats()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		data := jsonhttp.Envelope{
+			"goroutines": runtime.NumGoroutine(),
+			"memory": map[string]uint64{
+				"alloc":      m.Alloc,
+				"heap_inuse": m.HeapInuse,
+				"num_gc":     uint64(m.NumGC),
+			},
+			"db": dbStats,
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, data, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type Vacuumer interface {
+	Vacuum() (int64, error)
+}
+
+// NewVacuumHandler compacts the database file and returns the number of bytes reclaimed.
+func NewVacuumHandler(logger *slog.Logger, service Vacuumer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reclaimed, err := service.Vacuum()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"reclaimed_bytes": reclaimed}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+// NewDBStatusHandler returns the database stats: keys, file size, share of stale
+// log entries and the last compaction time.
+func NewDBStatusHandler(logger *slog.Logger, service DBStatsProvider) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := service.DBStats()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"db": stats}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type DBCompactor interface {
+	CompactDB() (int64, inmemorydb.Stats, error)
+}
+
+// NewCompactDBHandler compacts the database file and returns the number of bytes
+// reclaimed with the database stats after the compaction.
+func NewCompactDBHandler(logger *slog.Logger, service DBCompactor) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reclaimed, stats, err := service.CompactDB()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"reclaimed_bytes": reclaimed, "db": stats}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type TaskMigrator interface {
+	MigrateTasks() (int, error)
+}
+
+// NewMigrateHandler rewrites tasks stored with an older schema in the current one
+// and returns the number of rewritten tasks.
+func NewMigrateHandler(logger *slog.Logger, service TaskMigrator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		migrated, err := service.MigrateTasks()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"migrated": migrated}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type KeyLister interface {
+	ListKeys(prefix string) ([]repository.KeyInfo, error)
+}
+
+// NewListKeysHandler returns the raw database keys with their value sizes, including
+// non-task keys(idempotency records, counters), filtered by the prefix query parameter.
+func NewListKeysHandler(logger *slog.Logger, service KeyLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := service.ListKeys(r.URL.Query().Get("prefix"))
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"keys": keys}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type KeyDeleter interface {
+	DeleteKey(key string) error
+}
+
+// NewDeleteKeyHandler removes the raw database key given in the path. Only idempotency
+// records and trashed tasks can be deleted, other keys get 400.
+func NewDeleteKeyHandler(logger *slog.Logger, service KeyDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.PathValue("key")
+		if key == "" {
+			apierrors.BadRequestResponse(logger, w, r, errors.New("key must be provided"))
+			return
+		}
+
+		err := service.DeleteKey(key)
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrProtectedKey):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			case errors.Is(err, repository.ErrNotFound):
+				apierrors.NotFoundResponse(logger, w, r)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"message": "key successfully deleted"}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type RawTaskGetter interface {
+	GetRawTask(id int64) (*repository.RawTask, error)
+}
+
+// NewGetRawTaskHandler returns the bytes stored for the task(base64 encoded) with
+// its decoded form side by side. Values that can't be decoded are returned too,
+// with the decoding error, to diagnose encoding issues.
+func NewGetRawTaskHandler(logger *slog.Logger, service RawTaskGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := paramutil.ReadIDParam(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		raw, err := service.GetRawTask(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, s.ErrInvalidID):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			case errors.Is(err, repository.ErrNotFound):
+				apierrors.NotFoundResponse(logger, w, r)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"raw_task": raw}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/vladgrskkh/todo/internal/handlers/mocks"
+	"github.com/vladgrskkh/todo/internal/repository"
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+)
+
+func TestNewDebugStatsHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("returns runtime and db stats", func(t *testing.T) {
+		mockService := mocks.NewMockDBStatsProvider(inmemorydb.Stats{Keys: 3, FileSize: 128}, nil)
+		handler := NewDebugStatsHandler(logger, mockService)
+
+		req := httptest.NewRequest("GET", "/debug/stats", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Goroutines *float64            `json:"goroutines"`
+			Memory     map[string]*float64 `json:"memory"`
+			DB         map[string]*float64 `json:"db"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response.Goroutines == nil || *response.Goroutines < 1 {
+			t.Errorf("Expected positive goroutines count, got %v", response.Goroutines)
+		}
+		for _, field := range []string{"alloc", "heap_inuse", "num_gc"} {
+			if response.Memory[field] == nil {
+				t.Errorf("Expected memory.%s to be present", field)
+			}
+		}
+		if response.DB["keys"] == nil || *response.DB["keys"] != 3 {
+			t.Errorf("Expected db.keys 3, got %v", response.DB["keys"])
+		}
+		if response.DB["file_size"] == nil || *response.DB["file_size"] != 128 {
+			t.Errorf("Expected db.file_size 128, got %v", response.DB["file_size"])
+		}
+	})
+
+	t.Run("returns server error when stats fail", func(t *testing.T) {
+		mockService := mocks.NewMockDBStatsProvider(inmemorydb.Stats{}, errors.New("stats error"))
+		handler := NewDebugStatsHandler(logger, mockService)
+
+		req := httptest.NewRequest("GET", "/debug/stats", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestNewVacuumHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("returns reclaimed bytes", func(t *testing.T) {
+		handler := NewVacuumHandler(logger, mocks.NewMockVacuumer(512, nil))
+
+		req := httptest.NewRequest("POST", "/debug/vacuum", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]any
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response["reclaimed_bytes"] != float64(512) {
+			t.Errorf("Expected reclaimed_bytes 512, got %v", response["reclaimed_bytes"])
+		}
+	})
+
+	t.Run("returns server error when vacuum fails", func(t *testing.T) {
+		handler := NewVacuumHandler(logger, mocks.NewMockVacuumer(0, errors.New("vacuum error")))
+
+		req := httptest.NewRequest("POST", "/debug/vacuum", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestNewMigrateHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("returns migrated count", func(t *testing.T) {
+		handler := NewMigrateHandler(logger, mocks.NewMockTaskMigrator(3, nil))
+
+		req := httptest.NewRequest("POST", "/debug/migrate", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]int
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response["migrated"] != 3 {
+			t.Errorf("Expected migrated 3, got %d", response["migrated"])
+		}
+	})
+
+	t.Run("returns server error when migration fails", func(t *testing.T) {
+		handler := NewMigrateHandler(logger, mocks.NewMockTaskMigrator(0, errors.New("migrate error")))
+
+		req := httptest.NewRequest("POST", "/debug/migrate", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestKeysHandlers(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	keys := []repository.KeyInfo{
+		{Key: "1", Size: 120, Task: true},
+		{Key: "counter:tasks", Size: 8},
+		{Key: "idempotency:abc", Size: 150},
+	}
+
+	listKeys := func(t *testing.T, store KeyLister, url string) []repository.KeyInfo {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		NewListKeysHandler(logger, store)(w, httptest.NewRequest("GET", url, nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response struct {
+			Keys []repository.KeyInfo `json:"keys"`
+		}
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		return response.Keys
+	}
+
+	t.Run("lists task and non-task keys", func(t *testing.T) {
+		got := listKeys(t, mocks.NewMockKeyStore(slices.Clone(keys), nil), "/admin/keys")
+
+		if !reflect.DeepEqual(got, keys) {
+			t.Errorf("Expected keys %+v, got %+v", keys, got)
+		}
+	})
+
+	t.Run("filters keys by prefix", func(t *testing.T) {
+		got := listKeys(t, mocks.NewMockKeyStore(slices.Clone(keys), nil), "/admin/keys?prefix=idempotency:")
+
+		if len(got) != 1 || got[0].Key != "idempotency:abc" {
+			t.Errorf("Expected only the idempotency key, got %+v", got)
+		}
+	})
+
+	t.Run("deletes a key", func(t *testing.T) {
+		store := mocks.NewMockKeyStore(slices.Clone(keys), nil)
+		handler := NewDeleteKeyHandler(logger, store)
+
+		req := httptest.NewRequest("DELETE", "/admin/keys/idempotency:abc", nil)
+		req.SetPathValue("key", "idempotency:abc")
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		got := listKeys(t, store, "/admin/keys")
+		if len(got) != 2 || slices.ContainsFunc(got, func(k repository.KeyInfo) bool { return k.Key == "idempotency:abc" }) {
+			t.Errorf("Expected idempotency:abc to be deleted, got %+v", got)
+		}
+
+		w = httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d for deleted key, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+
+	t.Run("refuses a protected key", func(t *testing.T) {
+		handler := NewDeleteKeyHandler(logger, mocks.NewMockKeyStore(nil, repository.ErrProtectedKey))
+
+		req := httptest.NewRequest("DELETE", "/admin/keys/counter:tasks", nil)
+		req.SetPathValue("key", "counter:tasks")
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("returns server error", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		NewListKeysHandler(logger, mocks.NewMockKeyStore(nil, errors.New("db error")))(w, httptest.NewRequest("GET", "/admin/keys", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
@@ -1,13 +1,41 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+)
+
 type UpdateTaskInput struct {
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Done        bool   `json:"done"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Done        bool             `json:"done"`
+	Subtasks    []domain.Subtask `json:"subtasks"`
+	Tags        []string         `json:"tags"`
+	DueDate     *time.Time       `json:"due_date"`
+	Priority    string           `json:"priority"`
 }
 
 type CreateTaskInput struct {
-	ID          int64  `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
+	ID          domain.JSONID    `json:"id"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Subtasks    []domain.Subtask `json:"subtasks"`
+	Tags        []string         `json:"tags"`
+	DueDate     *time.Time       `json:"due_date"`
+	Priority    string           `json:"priority"`
+
+	// Version is accepted, so clients can send back a task they received,
+	// but it is ignored: new tasks always start with version 1.
+	Version int `json:"version,omitzero"`
+}
+
+// BulkStatusInput is the body of PATCH /todos. Done is required, so it's a pointer.
+type BulkStatusInput struct {
+	IDs  []domain.JSONID `json:"ids"`
+	Done *bool           `json:"done"`
+}
+
+type MoveTaskInput struct {
+	Position int `json:"position"`
 }
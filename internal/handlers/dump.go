@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/paramutil"
+	"github.com/vladgrskkh/todo/pkg/jsonhttp"
+	"github.com/vladgrskkh/todo/pkg/validator"
+)
+
+// gobContentType is the media type of the gob dump: a stream of gob-encoded domain.Task values.
+const gobContentType = "application/x-gob"
+
+type TaskDumper interface {
+	IterateTasks(fn func(task *domain.Task) error) error
+}
+
+// NewExportTasksHandler streams all tasks sorted by id as a backup dump keeping every
+// field(ids, timestamps, versions and positions). ?format=json(default) writes
+// {"tasks":[...]}, ?format=gob writes a stream of gob-encoded tasks. The dump can be
+// restored with POST /todos/import.
+//
+// The dump is built in memory and sent with a strong ETag(SHA-256 of the dump), so
+// requests with a Range header get the requested byte ranges(206) and an interrupted
+// download can be resumed with If-Range. The dump is the same for unchanged tasks,
+// a stale If-Range gets the whole new dump.
+func NewExportTasksHandler(logger *slog.Logger, service TaskDumper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := paramutil.ReadEnumQuery(r, "format", "json", "gob")
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		w.Header().Set("Accept-Ranges", "bytes")
+
+		var out bytes.Buffer
+
+		var write func(task *domain.Task) error
+		var finish func() error
+
+		switch format {
+		case "gob":
+			w.Header().Set("Content-Type", gobContentType)
+			w.Header().Set("Content-Disposition", `attachment; filename="todos.gob"`)
+
+			enc := gob.NewEncoder(&out)
+			write = func(task *domain.Task) error {
+				return enc.Encode(task)
+			}
+			finish = func() error { return nil }
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Content-Disposition", `attachment; filename="todos.json"`)
+
+			first := true
+			write = func(task *domain.Task) error {
+				prefix := ","
+				if first {
+					prefix = `{"tasks":[`
+					first = false
+				}
+
+				js, err := json.Marshal(task)
+				if err != nil {
+					return err
+				}
+
+				_, err = io.WriteString(&out, prefix+string(js))
+				return err
+			}
+			finish = func() error {
+				end := "]}\n"
+				if first {
+					end = `{"tasks":[]}` + "\n"
+				}
+
+				_, err := io.WriteString(&out, end)
+				return err
+			}
+		}
+
+		err = service.IterateTasks(write)
+		if err == nil {
+			err = finish()
+		}
+		if err != nil {
+			w.Header().Del("Content-Disposition")
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		w.Header().Set("ETag", contentETag(out.Bytes()))
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(out.Bytes()))
+	}
+}
+
+type TaskRestorer interface {
+	TaskImporter
+	RestoreTasks(tasks []*domain.Task) (int, error)
+}
+
+// NewImportTasksHandler imports tasks from the body depending on its Content-Type:
+// text/csv creates new tasks(see NewImportTasksCSVHandler), application/json and
+// application/x-gob restore a dump made by NewExportTasksHandler. A dump is restored
+// in one transaction, replacing tasks with the same ids, and nothing is stored if
+// any task is invalid.
+func NewImportTasksHandler(logger *slog.Logger, service TaskRestorer) http.HandlerFunc {
+	importCSV := NewImportTasksCSVHandler(logger, service)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil {
+			mediaType = ""
+		}
+
+		if mediaType == "text/csv" {
+			importCSV(w, r)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+		var tasks []*domain.Task
+		switch mediaType {
+		case "application/json":
+			tasks, err = decodeJSONDump(r.Body)
+		case gobContentType:
+			tasks, err = decodeGobDump(r.Body)
+		default:
+			apierrors.UnsupportedMediaTypeResponse(logger, w, r, "content type must be text/csv, application/json or "+gobContentType)
+			return
+		}
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		restored, err := service.RestoreTasks(tasks)
+		if err != nil {
+			var validationErr *validator.Validator
+			switch {
+			case errors.As(err, &validationErr):
+				apierrors.FailedValidationResponse(logger, w, r, validationErr)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"restored": restored}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+// decodeJSONDump reads the {"tasks":[...]} dump written by NewExportTasksHandler.
+// Unknown fields are ignored, as the export includes computed ones(progress).
+func decodeJSONDump(r io.Reader) ([]*domain.Task, error) {
+	var dump struct {
+		Tasks []*domain.Task `json:"tasks"`
+	}
+
+	err := json.NewDecoder(r).Decode(&dump)
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("body must not be empty")
+		}
+		return nil, fmt.Errorf("invalid json dump: %w", err)
+	}
+
+	for i, task := range dump.Tasks {
+		if task == nil {
+			return nil, fmt.Errorf("invalid json dump: tasks[%d] must not be null", i)
+		}
+	}
+
+	return dump.Tasks, nil
+}
+
+// decodeGobDump reads the stream of gob-encoded tasks written by NewExportTasksHandler.
+// An empty body is an empty dump.
+func decodeGobDump(r io.Reader) ([]*domain.Task, error) {
+	dec := gob.NewDecoder(r)
+
+	var tasks []*domain.Task
+	for {
+		var task domain.Task
+		err := dec.Decode(&task)
+		if errors.Is(err, io.EOF) {
+			return tasks, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid gob dump: %w", err)
+		}
+
+		tasks = append(tasks, &task)
+	}
+}
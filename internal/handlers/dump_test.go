@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/handlers/mocks"
+	"github.com/vladgrskkh/todo/pkg/validator"
+)
+
+func TestExportImportTasksRoundTrip(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	due := created.Add(48 * time.Hour)
+
+	full := &domain.Task{
+		ID:          1,
+		Title:       "Task 1",
+		Description: "Description 1",
+		Done:        true,
+		Subtasks:    []domain.Subtask{{Title: "Subtask", Done: true}},
+		Tags:        []string{"work", "home"},
+		DueDate:     &due,
+		Priority:    "high",
+		CreatedAt:   created,
+		UpdatedAt:   created.Add(time.Hour),
+		Version:     3,
+		Position:    7,
+	}
+	minimal := domain.NewTask(2, "Task 2", "")
+	minimal.CreatedAt = created
+	minimal.UpdatedAt = created
+	tasks := []*domain.Task{full, minimal}
+
+	tests := []struct {
+		name        string
+		format      string
+		contentType string
+	}{
+		{name: "json", format: "json", contentType: "application/json"},
+		{name: "default format is json", format: "", contentType: "application/json"},
+		{name: "gob", format: "gob", contentType: "application/x-gob"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := "/todos/export"
+			if tt.format != "" {
+				url += "?format=" + tt.format
+			}
+
+			export := NewExportTasksHandler(logger, mocks.NewMockTaskDumpStore(tasks, nil))
+
+			w := httptest.NewRecorder()
+			export(w, httptest.NewRequest("GET", url, nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if w.Header().Get("Content-Type") != tt.contentType {
+				t.Errorf("Expected Content-Type '%s', got '%s'", tt.contentType, w.Header().Get("Content-Type"))
+			}
+
+			store := mocks.NewMockTaskDumpStore(nil, nil)
+			importTasks := NewImportTasksHandler(logger, store)
+
+			req := httptest.NewRequest("POST", "/todos/import", bytes.NewReader(w.Body.Bytes()))
+			req.Header.Set("Content-Type", tt.contentType)
+			w = httptest.NewRecorder()
+
+			importTasks(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), `"restored": 2`) {
+				t.Errorf("Expected 2 restored tasks, got %s", w.Body.String())
+			}
+			if !reflect.DeepEqual(store.Restored(), tasks) {
+				t.Errorf("Expected tasks %+v, got %+v", tasks, store.Restored())
+			}
+		})
+	}
+}
+
+func TestNewExportTasksHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("empty dataset", func(t *testing.T) {
+		handler := NewExportTasksHandler(logger, mocks.NewMockTaskDumpStore(nil, nil))
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/todos/export", nil))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if strings.TrimSpace(w.Body.String()) != `{"tasks":[]}` {
+			t.Errorf(`Expected {"tasks":[]}, got %s`, w.Body.String())
+		}
+	})
+
+	t.Run("range request returns the slice", func(t *testing.T) {
+		tasks := []*domain.Task{domain.NewTask(1, "Task 1", ""), domain.NewTask(2, "Task 2", "")}
+		handler := NewExportTasksHandler(logger, mocks.NewMockTaskDumpStore(tasks, nil))
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/todos/export", nil))
+
+		if w.Header().Get("Accept-Ranges") != "bytes" {
+			t.Errorf("Expected Accept-Ranges 'bytes', got '%s'", w.Header().Get("Accept-Ranges"))
+		}
+		full := w.Body.String()
+
+		req := httptest.NewRequest("GET", "/todos/export", nil)
+		req.Header.Set("Range", "bytes=10-29")
+		w = httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusPartialContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusPartialContent, w.Code)
+		}
+		if w.Body.String() != full[10:30] {
+			t.Errorf("Expected body %q, got %q", full[10:30], w.Body.String())
+		}
+		expectedRange := fmt.Sprintf("bytes 10-29/%d", len(full))
+		if w.Header().Get("Content-Range") != expectedRange {
+			t.Errorf("Expected Content-Range '%s', got '%s'", expectedRange, w.Header().Get("Content-Range"))
+		}
+		if w.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("Expected Content-Type 'application/json', got '%s'", w.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("if-range resumes only the same dump", func(t *testing.T) {
+		tasks := []*domain.Task{domain.NewTask(1, "Task 1", ""), domain.NewTask(2, "Task 2", "")}
+		handler := NewExportTasksHandler(logger, mocks.NewMockTaskDumpStore(tasks, nil))
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/todos/export", nil))
+
+		etag := w.Header().Get("ETag")
+		if etag == "" || strings.HasPrefix(etag, "W/") {
+			t.Fatalf("Expected strong ETag, got '%s'", etag)
+		}
+
+		req := httptest.NewRequest("GET", "/todos/export", nil)
+		req.Header.Set("Range", "bytes=10-")
+		req.Header.Set("If-Range", etag)
+		w = httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusPartialContent {
+			t.Errorf("Expected status %d, got %d", http.StatusPartialContent, w.Code)
+		}
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("Expected ETag '%s', got '%s'", etag, w.Header().Get("ETag"))
+		}
+
+		// the tasks changed since the interrupted download
+		tasks = append(tasks, domain.NewTask(3, "Task 3", ""))
+		handler = NewExportTasksHandler(logger, mocks.NewMockTaskDumpStore(tasks, nil))
+
+		w = httptest.NewRecorder()
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d for stale If-Range, got %d", http.StatusOK, w.Code)
+		}
+		if w.Header().Get("ETag") == etag {
+			t.Error("Expected a new ETag for the changed dump")
+		}
+
+		var dump map[string][]domain.Task
+		err := json.Unmarshal(w.Body.Bytes(), &dump)
+		if err != nil {
+			t.Fatalf("Expected the whole dump, got %v", err)
+		}
+		if len(dump["tasks"]) != 3 {
+			t.Errorf("Expected 3 tasks, got %d", len(dump["tasks"]))
+		}
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		handler := NewExportTasksHandler(logger, mocks.NewMockTaskDumpStore(nil, nil))
+
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest("GET", "/todos/export?format=xml", nil))
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestNewImportTasksHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	v := validator.New()
+	v.AddError("tasks[0].title", "must be provided")
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		restoreErr     error
+		expectedStatus int
+	}{
+		{
+			name:           "unsupported content type",
+			contentType:    "application/xml",
+			body:           "<tasks/>",
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "csv is imported as new tasks",
+			contentType:    "text/csv",
+			body:           "id,title\n1,Task 1\n",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "malformed json",
+			contentType:    "application/json",
+			body:           `{"tasks":[`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "null task",
+			contentType:    "application/json",
+			body:           `{"tasks":[null]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "malformed gob",
+			contentType:    "application/x-gob",
+			body:           "not gob",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "invalid task",
+			contentType:    "application/json",
+			body:           `{"tasks":[{"id":1,"title":""}]}`,
+			restoreErr:     v,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "restore error",
+			contentType:    "application/json",
+			body:           `{"tasks":[]}`,
+			restoreErr:     errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewImportTasksHandler(logger, mocks.NewMockTaskDumpStore(nil, tt.restoreErr))
+
+			req := httptest.NewRequest("POST", "/todos/import", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+		})
+	}
+}
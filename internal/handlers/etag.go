@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+)
+
+// errInvalidIfMatch is returned for an If-Match header that can't match a task ETag.
+var errInvalidIfMatch = errors.New("invalid If-Match header")
+
+// taskETag returns the strong ETag of the task, derived from its version.
+func taskETag(task *domain.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// contentETag returns the strong ETag of the response body, derived from its SHA-256.
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// readIfMatchVersion reads the task version from the If-Match header holding an ETag
+// returned by taskETag, ok is false if the header is absent. "*" matches any version
+// and returns 0. Only a single ETag is supported, weak ETags never match(If-Match uses
+// strong comparison), both return errInvalidIfMatch.
+func readIfMatchVersion(r *http.Request) (version int, ok bool, err error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, false, nil
+	}
+
+	if header == "*" {
+		return 0, true, nil
+	}
+
+	unquoted, found := strings.CutPrefix(header, `"`)
+	if !found {
+		return 0, true, errInvalidIfMatch
+	}
+	unquoted, found = strings.CutSuffix(unquoted, `"`)
+	if !found {
+		return 0, true, errInvalidIfMatch
+	}
+
+	version, err = strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, true, errInvalidIfMatch
+	}
+
+	return version, true, nil
+}
@@ -5,10 +5,17 @@ import (
 	"net/http"
 
 	"github.com/vladgrskkh/todo/internal/apierrors"
+	"github.com/vladgrskkh/todo/internal/repository"
 	"github.com/vladgrskkh/todo/pkg/jsonhttp"
 )
 
-func NewHealthCheckHandler(logger *slog.Logger, env, version string) http.HandlerFunc {
+type IntegrityReporter interface {
+	IntegrityReport() *repository.IntegrityReport
+}
+
+// NewHealthCheckHandler returns the service status. If the startup integrity check
+// ran, its report is included and the status is "degraded" when corrupt tasks were found.
+func NewHealthCheckHandler(logger *slog.Logger, env, version string, service IntegrityReporter) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		data := jsonhttp.Envelope{
 			"status":  "avaliable",
@@ -16,6 +23,13 @@ func NewHealthCheckHandler(logger *slog.Logger, env, version string) http.Handle
 			"version": version,
 		}
 
+		if report := service.IntegrityReport(); report != nil {
+			data["integrity"] = report
+			if report.Corrupt > 0 {
+				data["status"] = "degraded"
+			}
+		}
+
 		err := jsonhttp.WriteJSON(w, http.StatusOK, data, nil)
 		if err != nil {
 			apierrors.ServerErrorResponse(logger, w, r, err)
@@ -6,7 +6,11 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+
+	"github.com/vladgrskkh/todo/internal/handlers/mocks"
+	"github.com/vladgrskkh/todo/internal/repository"
 )
 
 func TestNewHealthCheckHandler(t *testing.T) {
@@ -15,7 +19,7 @@ func TestNewHealthCheckHandler(t *testing.T) {
 	t.Run("returns health check data successfully", func(t *testing.T) {
 		env := "development"
 		version := "1.0.0"
-		handler := NewHealthCheckHandler(logger, env, version)
+		handler := NewHealthCheckHandler(logger, env, version, mocks.NewMockIntegrityReporter(nil))
 
 		req := httptest.NewRequest("GET", "/healthcheck", nil)
 		w := httptest.NewRecorder()
@@ -55,7 +59,7 @@ func TestNewHealthCheckHandler(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.env, func(t *testing.T) {
-				handler := NewHealthCheckHandler(logger, tc.env, tc.version)
+				handler := NewHealthCheckHandler(logger, tc.env, tc.version, mocks.NewMockIntegrityReporter(nil))
 
 				req := httptest.NewRequest("GET", "/healthcheck", nil)
 				w := httptest.NewRecorder()
@@ -81,4 +85,44 @@ func TestNewHealthCheckHandler(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("includes integrity report", func(t *testing.T) {
+		tests := []struct {
+			name           string
+			report         *repository.IntegrityReport
+			expectedStatus string
+		}{
+			{name: "clean store", report: &repository.IntegrityReport{Tasks: 2}, expectedStatus: "avaliable"},
+			{
+				name:           "corrupt store",
+				report:         &repository.IntegrityReport{Tasks: 2, Corrupt: 1, CorruptKeys: []string{"2"}},
+				expectedStatus: "degraded",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				handler := NewHealthCheckHandler(logger, "test", "1.0.0", mocks.NewMockIntegrityReporter(tt.report))
+
+				w := httptest.NewRecorder()
+				handler(w, httptest.NewRequest("GET", "/healthcheck", nil))
+
+				var response struct {
+					Status    string                      `json:"status"`
+					Integrity *repository.IntegrityReport `json:"integrity"`
+				}
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+
+				if response.Status != tt.expectedStatus {
+					t.Errorf("Expected status '%s', got '%s'", tt.expectedStatus, response.Status)
+				}
+				if !reflect.DeepEqual(response.Integrity, tt.report) {
+					t.Errorf("Expected integrity %+v, got %+v", tt.report, response.Integrity)
+				}
+			})
+		}
+	})
 }
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+)
+
+// RequireAdminToken returns a middleware function that allows only requests
+// with "Authorization: Bearer <token>" header matching the admin token.
+// Other requests are rejected with 401 Unauthorized.
+func RequireAdminToken(logger *slog.Logger, token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				apierrors.UnauthorizedResponse(logger, w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
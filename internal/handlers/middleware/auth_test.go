@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireAdminToken(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		token         string
+		authorization string
+		expectedCode  int
+	}{
+		{
+			name:          "allows valid token",
+			token:         "secret",
+			authorization: "Bearer secret",
+			expectedCode:  http.StatusOK,
+		},
+		{
+			name:          "rejects invalid token",
+			token:         "secret",
+			authorization: "Bearer wrong",
+			expectedCode:  http.StatusUnauthorized,
+		},
+		{
+			name:         "rejects missing header",
+			token:        "secret",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			name:          "rejects non-bearer scheme",
+			token:         "secret",
+			authorization: "Basic secret",
+			expectedCode:  http.StatusUnauthorized,
+		},
+		{
+			name:          "rejects everything when token is not configured",
+			authorization: "Bearer ",
+			expectedCode:  http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := RequireAdminToken(logger, tt.token)(okHandler)
+
+			req := httptest.NewRequest("GET", "/debug/stats", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
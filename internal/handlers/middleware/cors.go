@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Methods and headers allowed in cross-origin requests.
+const (
+	corsAllowedMethods = "GET, POST, PUT, PATCH, DELETE"
+	corsAllowedHeaders = "Authorization, Content-Type, Idempotency-Key, Accept-Language, If-Match"
+	corsExposedHeaders = "X-Total-Count, X-Request-ID, ETag"
+)
+
+// CORS returns a middleware function that allows cross-origin requests from
+// allowedOrigins("*" allows any origin). An origin with a "*." host prefix
+// (e.g. https://*.example.com) allows any subdomain, but not the domain itself.
+// Preflight requests are answered with 204 and Access-Control-Max-Age set to
+// maxAge(in seconds), so browsers cache them; 0 maxAge omits the header.
+// Requests from other origins are passed through without CORS headers, so
+// browsers block reading the response.
+func CORS(allowedOrigins []string, maxAge time.Duration) func(http.Handler) http.Handler {
+	allowAny := slices.Contains(allowedOrigins, "*")
+
+	var exact []string
+	var wildcards []originPattern
+	for _, origin := range allowedOrigins {
+		if pattern, ok := parseOriginPattern(origin); ok {
+			wildcards = append(wildcards, pattern)
+		} else {
+			exact = append(exact, origin)
+		}
+	}
+
+	allowed := func(origin string) bool {
+		if allowAny || slices.Contains(exact, origin) {
+			return true
+		}
+
+		return slices.ContainsFunc(wildcards, func(p originPattern) bool {
+			return p.match(origin)
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" || !allowed(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+
+			// preflight request
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Add("Vary", "Access-Control-Request-Method")
+				w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+				w.Header().Set("Access-Control-Allow-Methods", corsAllowedMethods)
+				w.Header().Set("Access-Control-Allow-Headers", corsAllowedHeaders)
+				if maxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+				}
+
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// originPattern is an allowed origin with a wildcard subdomain,
+// e.g. https://*.example.com is {scheme: "https://", domain: ".example.com"}.
+type originPattern struct {
+	scheme string
+	domain string
+}
+
+// parseOriginPattern parses an origin with a "*." host prefix.
+func parseOriginPattern(origin string) (originPattern, bool) {
+	scheme, host, ok := strings.Cut(origin, "://")
+	if !ok || !strings.HasPrefix(host, "*.") || len(host) == len("*.") {
+		return originPattern{}, false
+	}
+
+	return originPattern{scheme: scheme + "://", domain: host[1:]}, true
+}
+
+// match reports whether origin is a subdomain(of any depth) of the pattern domain
+// with the same scheme. The subdomain may only contain letters, digits, '-' and '.',
+// so e.g. https://evil.com?.example.com doesn't match.
+func (p originPattern) match(origin string) bool {
+	host, ok := strings.CutPrefix(origin, p.scheme)
+	if !ok {
+		return false
+	}
+
+	sub, ok := strings.CutSuffix(host, p.domain)
+	if !ok || sub == "" || strings.HasPrefix(sub, ".") || strings.HasSuffix(sub, ".") {
+		return false
+	}
+
+	return !strings.ContainsFunc(sub, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.')
+	})
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := CORS([]string{"https://app.example.com"}, 10*time.Minute)(next)
+
+	t.Run("preflight sets configured max age", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/todos", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+			t.Errorf("Expected Access-Control-Max-Age 600, got '%s'", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("Expected allowed origin, got '%s'", got)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Methods"); got == "" {
+			t.Error("Expected Access-Control-Allow-Methods to be set")
+		}
+	})
+
+	t.Run("zero max age omits header", func(t *testing.T) {
+		handler := CORS([]string{"*"}, 0)(next)
+
+		req := httptest.NewRequest(http.MethodOptions, "/todos", nil)
+		req.Header.Set("Origin", "https://other.example.com")
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if _, ok := w.Header()["Access-Control-Max-Age"]; ok {
+			t.Error("Expected no Access-Control-Max-Age header")
+		}
+	})
+
+	t.Run("simple request gets allow origin", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("Expected allowed origin, got '%s'", got)
+		}
+		if _, ok := w.Header()["Access-Control-Max-Age"]; ok {
+			t.Error("Expected no Access-Control-Max-Age header on non-preflight request")
+		}
+	})
+
+	t.Run("disallowed origin gets no CORS headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodOptions, "/todos", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		req.Header.Set("Access-Control-Request-Method", "DELETE")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+			t.Errorf("Expected no allowed origin, got '%s'", got)
+		}
+		if got := w.Header().Get("Access-Control-Max-Age"); got != "" {
+			t.Errorf("Expected no max age, got '%s'", got)
+		}
+	})
+}
+
+func TestCORSWildcardSubdomain(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := CORS([]string{"https://*.example.com"}, 0)(next)
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{name: "subdomain", origin: "https://app.example.com", allowed: true},
+		{name: "nested subdomain", origin: "https://a.b.example.com", allowed: true},
+		{name: "apex domain", origin: "https://example.com", allowed: false},
+		{name: "similar domain", origin: "https://evil-example.com", allowed: false},
+		{name: "domain suffix in subdomain", origin: "https://example.com.evil.com", allowed: false},
+		{name: "other scheme", origin: "http://app.example.com", allowed: false},
+		{name: "other port", origin: "https://app.example.com:8080", allowed: false},
+		{name: "invalid subdomain characters", origin: "https://evil.com?.example.com", allowed: false},
+		{name: "empty subdomain label", origin: "https://.example.com", allowed: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Errorf("Expected allowed origin '%s', got '%s'", tt.origin, got)
+			}
+			if !tt.allowed && got != "" {
+				t.Errorf("Expected no allowed origin, got '%s'", got)
+			}
+		})
+	}
+
+	t.Run("apex domain allowed when listed", func(t *testing.T) {
+		handler := CORS([]string{"https://*.example.com", "https://example.com"}, 0)(next)
+
+		req := httptest.NewRequest(http.MethodGet, "/todos", nil)
+		req.Header.Set("Origin", "https://example.com")
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://example.com" {
+			t.Errorf("Expected allowed origin, got '%s'", got)
+		}
+	})
+}
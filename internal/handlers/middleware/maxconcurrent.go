@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+)
+
+// MaxConcurrent returns a middleware function that limits the number of requests
+// processed at the same time to n. When the limit is reached, the request is
+// rejected with 503 Service Unavailable(and Retry-After header) instead of being queued.
+func MaxConcurrent(logger *slog.Logger, n int) func(http.Handler) http.Handler {
+	sem := make(chan struct{}, n)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case sem <- struct{}{}:
+				defer func() {
+					<-sem
+				}()
+
+				next.ServeHTTP(w, r)
+			default:
+				apierrors.ServiceUnavailableResponse(logger, w, r, 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestMaxConcurrent(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	const limit = 3
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	handler := MaxConcurrent(logger, limit)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var wg sync.WaitGroup
+	codes := make([]int, limit)
+
+	for i := range limit {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos", nil))
+			codes[i] = w.Code
+		}()
+	}
+
+	// waiting for all n requests to be in flight
+	for range limit {
+		<-started
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos", nil))
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("Expected Retry-After header to be set")
+	}
+
+	close(release)
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("Expected request %d to succeed, got %d", i, code)
+		}
+	}
+
+	// slots are released after requests are completed
+	go func() {
+		<-started
+	}()
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos", nil))
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d after release, got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds(in seconds) of the latency histogram buckets.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram counts observations in latencyBuckets, counts are not cumulative.
+// The last count is for observations above the largest bucket.
+type histogram struct {
+	Counts []int64 `json:"counts"`
+	Sum    float64 `json:"sum"`
+	Count  int64   `json:"count"`
+}
+
+// routeHistograms are request latency histograms by route.
+type routeHistograms struct {
+	mu     sync.Mutex
+	routes map[string]*histogram
+}
+
+func newRouteHistograms() *routeHistograms {
+	return &routeHistograms{routes: make(map[string]*histogram)}
+}
+
+// observe adds the request duration to the histogram of the route.
+func (h *routeHistograms) observe(route string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	hist, ok := h.routes[route]
+	if !ok {
+		hist = &histogram{Counts: make([]int64, len(latencyBuckets)+1)}
+		h.routes[route] = hist
+	}
+
+	seconds := d.Seconds()
+	i, _ := slices.BinarySearch(latencyBuckets, seconds)
+	hist.Counts[i]++
+	hist.Sum += seconds
+	hist.Count++
+}
+
+// snapshot returns a copy of the histograms.
+func (h *routeHistograms) snapshot() map[string]histogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	routes := make(map[string]histogram, len(h.routes))
+	for route, hist := range h.routes {
+		routes[route] = histogram{Counts: slices.Clone(hist.Counts), Sum: hist.Sum, Count: hist.Count}
+	}
+
+	return routes
+}
@@ -5,8 +5,15 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+	"github.com/vladgrskkh/todo/internal/handlers/middleware"
 )
 
+// unmatchedRoute is the route label of requests no route matched(e.g. 404s),
+// so unknown paths don't create new histograms.
+const unmatchedRoute = "unmatched"
+
 var (
 	totalRequests     *expvar.Int
 	totalResponses    *expvar.Int
@@ -14,21 +21,10 @@ var (
 	statusCounts      *expvar.Map
 	TotalTasksCreated *expvar.Int
 	TotalTasksDone    *expvar.Int
-)
+	TotalTasksDeleted *expvar.Int
 
-// Wrapped for http.ResponseWriter.
-// Main purpose is to track status code. In this application there will be no problems.
-// But when intoducing more complex logic(with external dependencies/using different interfaces),
-// it can cause bugs.
-type responseWriter struct {
-	http.ResponseWriter
-	statusCode int
-}
-
-func (rw *responseWriter) WriteHeader(code int) {
-	rw.statusCode = code
-	rw.ResponseWriter.WriteHeader(code)
-}
+	routeLatency *routeHistograms
+)
 
 func InitMetrics() {
 	totalRequests = expvar.NewInt("total_requests")
@@ -36,22 +32,80 @@ func InitMetrics() {
 	totalLatencyMs = expvar.NewInt("total_latency_ms")
 	statusCounts = expvar.NewMap("status_counts")
 
+	routeLatency = newRouteHistograms()
+	expvar.Publish("route_latency_seconds", expvar.Func(func() any {
+		return routeLatency.snapshot()
+	}))
+
 	// business metrics
 	TotalTasksCreated = expvar.NewInt("total_tasks_created")
 	TotalTasksDone = expvar.NewInt("total_tasks_done")
+	TotalTasksDeleted = expvar.NewInt("total_tasks_deleted")
+
+	// counted by apierrors, so clients' most common mistakes can be seen
+	expvar.Publish("validation_errors", apierrors.ValidationErrorFields)
+}
+
+// IncTasksCreated increments the number of created tasks.
+// Like the other helpers, it's a no-op before InitMetrics is called.
+func IncTasksCreated() {
+	addInt(TotalTasksCreated, 1)
 }
 
+// IncTasksDone increments the number of completed tasks.
+func IncTasksDone() {
+	addInt(TotalTasksDone, 1)
+}
+
+// AddTasksDone adds n to the number of completed tasks.
+func AddTasksDone(n int) {
+	addInt(TotalTasksDone, int64(n))
+}
+
+// IncTasksDeleted increments the number of deleted tasks.
+func IncTasksDeleted() {
+	addInt(TotalTasksDeleted, 1)
+}
+
+// AddTasksDeleted adds n to the number of deleted tasks.
+func AddTasksDeleted(n int) {
+	addInt(TotalTasksDeleted, int64(n))
+}
+
+// addInt adds delta to v if it's initialized.
+func addInt(v *expvar.Int, delta int64) {
+	if v != nil {
+		v.Add(delta)
+	}
+}
+
+// Metrics returns a middleware function counting requests, responses by status and
+// latency. The latency is also observed in the histogram of the route reported by
+// middleware.RecordRoute down the chain.
 func Metrics(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		totalRequests.Add(1)
+		addInt(totalRequests, 1)
+
+		// shared wrapper, so the request logger down the chain doesn't wrap it again
+		rw := middleware.WrapResponseWriter(w)
 
-		rw := &responseWriter{w, http.StatusOK}
+		ctx, route := middleware.WithRouteHolder(r.Context())
+		next.ServeHTTP(rw, r.WithContext(ctx))
 
-		next.ServeHTTP(rw, r)
+		duration := time.Since(start)
+		if statusCounts != nil {
+			statusCounts.Add(strconv.Itoa(rw.Status()), 1)
+		}
+		addInt(totalResponses, 1)
+		addInt(totalLatencyMs, duration.Milliseconds())
 
-		statusCounts.Add(strconv.Itoa(rw.statusCode), 1)
-		totalResponses.Add(1)
-		totalLatencyMs.Add(time.Since(start).Milliseconds())
+		if routeLatency != nil {
+			pattern := unmatchedRoute
+			if p := route.Load(); p != nil {
+				pattern = *p
+			}
+			routeLatency.observe(pattern, duration)
+		}
 	})
 }
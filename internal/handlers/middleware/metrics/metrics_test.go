@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUninitializedMetrics(t *testing.T) {
+	if TotalTasksCreated != nil {
+		t.Skip("metrics are already initialized")
+	}
+
+	IncTasksCreated()
+	IncTasksDone()
+
+	handler := Metrics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Expected status %d, got %d", http.StatusTeapot, w.Code)
+	}
+}
+
+func TestInitializedMetrics(t *testing.T) {
+	if TotalTasksCreated == nil {
+		InitMetrics()
+	}
+
+	before := TotalTasksCreated.Value()
+	IncTasksCreated()
+
+	if got := TotalTasksCreated.Value(); got != before+1 {
+		t.Errorf("Expected %d tasks created, got %d", before+1, got)
+	}
+}
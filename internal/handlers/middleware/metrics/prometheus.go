@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+)
+
+// prometheusContentType is the media type of the Prometheus text exposition format.
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler returns the /metrics handler. Clients accepting the Prometheus text format
+// (text/plain or application/openmetrics-text in Accept, as Prometheus scrapers send)
+// get it, others get the expvar JSON, so existing consumers keep working.
+// Both formats are built from the same counters.
+func Handler() http.Handler {
+	expvarHandler := expvar.Handler()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !acceptsPrometheus(r) {
+			expvarHandler.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", prometheusContentType)
+
+		bw := bufio.NewWriter(w)
+		writePrometheus(bw)
+		_ = bw.Flush()
+	})
+}
+
+// acceptsPrometheus reports whether Accept lists a Prometheus text format.
+func acceptsPrometheus(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept") {
+		for part := range strings.SplitSeq(value, ",") {
+			mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+
+			if mediaType == "text/plain" || mediaType == "application/openmetrics-text" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// writePrometheus writes the metrics in the Prometheus text exposition format.
+// Metrics not initialized by InitMetrics are reported as zero.
+func writePrometheus(w io.Writer) {
+	writeCounter(w, "todo_http_requests_total", "Total number of HTTP requests received.", intValue(totalRequests))
+
+	statuses := mapValues(statusCounts)
+	var serverErrors int64
+	for status, count := range statuses {
+		if code, err := strconv.Atoi(status); err == nil && code >= 500 {
+			serverErrors += count
+		}
+	}
+
+	writeHeader(w, "todo_http_responses_total", "Total number of HTTP responses by status code.", "counter")
+	for _, status := range sortedKeys(statuses) {
+		fmt.Fprintf(w, "todo_http_responses_total{status=\"%s\"} %d\n", escapeLabel(status), statuses[status])
+	}
+
+	writeCounter(w, "todo_http_errors_total", "Total number of HTTP responses with 5xx status.", serverErrors)
+
+	writeHeader(w, "todo_http_request_duration_seconds", "HTTP request latency by route.", "histogram")
+	if routeLatency != nil {
+		routes := routeLatency.snapshot()
+		for _, route := range sortedKeys(routes) {
+			hist := routes[route]
+			label := escapeLabel(route)
+
+			var cumulative int64
+			for i, bound := range latencyBuckets {
+				cumulative += hist.Counts[i]
+				fmt.Fprintf(w, "todo_http_request_duration_seconds_bucket{route=\"%s\",le=\"%s\"} %d\n",
+					label, strconv.FormatFloat(bound, 'g', -1, 64), cumulative)
+			}
+			fmt.Fprintf(w, "todo_http_request_duration_seconds_bucket{route=\"%s\",le=\"+Inf\"} %d\n", label, hist.Count)
+			fmt.Fprintf(w, "todo_http_request_duration_seconds_sum{route=\"%s\"} %s\n", label, strconv.FormatFloat(hist.Sum, 'g', -1, 64))
+			fmt.Fprintf(w, "todo_http_request_duration_seconds_count{route=\"%s\"} %d\n", label, hist.Count)
+		}
+	}
+
+	writeCounter(w, "todo_tasks_created_total", "Total number of created tasks.", intValue(TotalTasksCreated))
+	writeCounter(w, "todo_tasks_done_total", "Total number of completed tasks.", intValue(TotalTasksDone))
+	writeCounter(w, "todo_tasks_deleted_total", "Total number of deleted tasks.", intValue(TotalTasksDeleted))
+
+	fields := mapValues(apierrors.ValidationErrorFields)
+	writeHeader(w, "todo_validation_errors_total", "Total number of validation errors by field.", "counter")
+	for _, field := range sortedKeys(fields) {
+		fmt.Fprintf(w, "todo_validation_errors_total{field=\"%s\"} %d\n", escapeLabel(field), fields[field])
+	}
+}
+
+func writeHeader(w io.Writer, name, help, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeCounter(w io.Writer, name, help string, value int64) {
+	writeHeader(w, name, help, "counter")
+	fmt.Fprintf(w, "%s %d\n", name, value)
+}
+
+// intValue returns the value of v, 0 if it's not initialized.
+func intValue(v *expvar.Int) int64 {
+	if v == nil {
+		return 0
+	}
+
+	return v.Value()
+}
+
+// mapValues returns the integer values of m, other values are skipped.
+func mapValues(m *expvar.Map) map[string]int64 {
+	values := make(map[string]int64)
+	if m == nil {
+		return values
+	}
+
+	m.Do(func(kv expvar.KeyValue) {
+		if v, ok := kv.Value.(*expvar.Int); ok {
+			values[kv.Key] = v.Value()
+		}
+	})
+
+	return values
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	return slices.Sorted(maps.Keys(m))
+}
+
+// labelEscaper escapes a label value as required by the exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/vladgrskkh/todo/internal/handlers/middleware"
+)
+
+var (
+	sampleLine = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{(?:[a-zA-Z_][a-zA-Z0-9_]*="(?:[^"\\]|\\.)*",?)*\})? (\S+)$`)
+	typeLine   = regexp.MustCompile(`^# TYPE ([a-zA-Z_:][a-zA-Z0-9_:]*) (counter|gauge|histogram|summary|untyped)$`)
+)
+
+// parseExposition parses the Prometheus text format, failing the test on invalid lines.
+// Samples are keyed by name with labels, e.g. `todo_http_responses_total{status="200"}`.
+func parseExposition(t *testing.T, body string) map[string]float64 {
+	t.Helper()
+
+	types := make(map[string]string)
+	samples := make(map[string]float64)
+
+	sc := bufio.NewScanner(strings.NewReader(body))
+	for sc.Scan() {
+		line := sc.Text()
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "# HELP "):
+			continue
+		case strings.HasPrefix(line, "#"):
+			m := typeLine.FindStringSubmatch(line)
+			if m == nil {
+				t.Fatalf("Invalid comment line: %q", line)
+			}
+			types[m[1]] = m[2]
+			continue
+		}
+
+		m := sampleLine.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("Invalid sample line: %q", line)
+		}
+
+		family := m[1]
+		if _, ok := types[family]; !ok {
+			for _, suffix := range []string{"_bucket", "_sum", "_count"} {
+				family = strings.TrimSuffix(family, suffix)
+			}
+		}
+		if _, ok := types[family]; !ok {
+			t.Errorf("Sample %s has no TYPE", m[1])
+		}
+
+		value, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			t.Fatalf("Invalid sample value in %q: %v", line, err)
+		}
+		samples[m[1]+m[2]] = value
+	}
+
+	return samples
+}
+
+func scrape(t *testing.T) map[string]float64 {
+	t.Helper()
+
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0;q=0.75,text/plain;version=0.0.4;q=0.5,*/*;q=0.1")
+	w := httptest.NewRecorder()
+
+	Handler().ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Type") != prometheusContentType {
+		t.Fatalf("Expected Content-Type %q, got %q", prometheusContentType, w.Header().Get("Content-Type"))
+	}
+
+	return parseExposition(t, w.Body.String())
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	if TotalTasksCreated == nil {
+		InitMetrics()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		IncTasksDone()
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("DELETE /todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		IncTasksDeleted()
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	handler := Metrics(middleware.RecordRoute(mux))
+
+	before := scrape(t)
+
+	for _, target := range []struct{ method, path string }{
+		{"GET", "/todos/1"},
+		{"GET", "/todos/2"},
+		{"DELETE", "/todos/1"},
+		{"GET", "/unknown"},
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(target.method, target.path, nil))
+	}
+
+	after := scrape(t)
+
+	tests := []struct {
+		sample   string
+		increase float64
+	}{
+		{`todo_http_requests_total`, 4},
+		{`todo_http_responses_total{status="200"}`, 2},
+		{`todo_http_responses_total{status="404"}`, 1},
+		{`todo_http_errors_total`, 1},
+		{`todo_tasks_done_total`, 2},
+		{`todo_tasks_deleted_total`, 1},
+		{`todo_http_request_duration_seconds_count{route="GET /todos/{id}"}`, 2},
+		{`todo_http_request_duration_seconds_bucket{route="GET /todos/{id}",le="+Inf"}`, 2},
+		{`todo_http_request_duration_seconds_count{route="DELETE /todos/{id}"}`, 1},
+		{`todo_http_request_duration_seconds_count{route="unmatched"}`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sample, func(t *testing.T) {
+			got, ok := after[tt.sample]
+			if !ok {
+				t.Fatalf("Expected sample %s to be exposed", tt.sample)
+			}
+			if got-before[tt.sample] != tt.increase {
+				t.Errorf("Expected %s to increase by %v, got %v -> %v", tt.sample, tt.increase, before[tt.sample], got)
+			}
+		})
+	}
+}
+
+func TestHandlerServesExpvarByDefault(t *testing.T) {
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	w := httptest.NewRecorder()
+
+	Handler().ServeHTTP(w, req)
+
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		t.Errorf("Expected expvar JSON, got Content-Type %q", w.Header().Get("Content-Type"))
+	}
+}
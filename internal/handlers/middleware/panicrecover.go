@@ -1,23 +1,61 @@
 package middleware
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"regexp"
+	"runtime/debug"
+	"strings"
 
 	"github.com/vladgrskkh/todo/internal/apierrors"
 )
 
+// panicBodyLogLimit limits the part of the request body logged on panic.
+const panicBodyLogLimit = 1024
+
+// secretFieldRe matches JSON string fields that may hold secrets(password, token, etc.),
+// their values are redacted in the logged request body.
+var secretFieldRe = regexp.MustCompile(`(?i)("[^"]*(?:password|token|secret|authorization|api_?key)[^"]*"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
 // RecoverPanic returns a middleware function that recovers from panics and
-// returns a 500 Internal Server Error response to the client.
-func RecoverPanic(logger *slog.Logger) func(http.Handler) http.Handler {
+// returns a 500 Internal Server Error response to the client. The panic is logged
+// with the request path and the beginning of the request body(the part read by
+// the handler or left unread, up to panicBodyLogLimit bytes, with secret fields redacted).
+// If showDetails is true(for development), the panic value and stack are also sent
+// in the response.
+func RecoverPanic(logger *slog.Logger, showDetails bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the body is captured while the handler reads it, so it's not
+			// buffered upfront and the handler body limits still apply
+			var body *bodyCapture
+			if r.Body != nil && r.Body != http.NoBody {
+				body = &bodyCapture{ReadCloser: r.Body, limit: panicBodyLogLimit}
+				r.Body = body
+			}
+
 			defer func() {
 				if err := recover(); err != nil {
 					w.Header().Set("Connection", "Close")
 
-					apierrors.ServerErrorResponse(logger, w, r, fmt.Errorf("%s", err))
+					attrs := []any{slog.String("request_path", r.URL.Path)}
+					if body != nil {
+						body.fill()
+						attrs = append(attrs, slog.String("request_body", body.String()))
+					}
+
+					var details map[string]string
+					if showDetails {
+						details = map[string]string{
+							"panic": fmt.Sprint(err),
+							"stack": string(debug.Stack()),
+						}
+					}
+
+					apierrors.ServerErrorDetailsResponse(logger.With(attrs...), w, r, fmt.Errorf("%s", err), details)
 				}
 			}()
 
@@ -25,3 +63,42 @@ func RecoverPanic(logger *slog.Logger) func(http.Handler) http.Handler {
 		})
 	}
 }
+
+// bodyCapture keeps up to limit bytes read from the request body.
+type bodyCapture struct {
+	io.ReadCloser
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *bodyCapture) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+
+	room := c.limit - c.buf.Len()
+	if n > room {
+		c.truncated = true
+	}
+	c.buf.Write(p[:min(n, room)])
+
+	return n, err
+}
+
+// fill reads the body the handler left unread until the limit is reached.
+func (c *bodyCapture) fill() {
+	if c.truncated {
+		return
+	}
+
+	// one more byte to find out if the body is truncated
+	_, _ = io.CopyN(io.Discard, c, int64(c.limit-c.buf.Len())+1)
+}
+
+// String returns the captured body with secret fields redacted.
+func (c *bodyCapture) String() string {
+	s := secretFieldRe.ReplaceAllString(strings.ToValidUTF8(c.buf.String(), "?"), `$1"[REDACTED]"`)
+	if c.truncated {
+		s += "...(truncated)"
+	}
+	return s
+}
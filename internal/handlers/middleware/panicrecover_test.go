@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		readBody     bool
+		expectedBody string
+	}{
+		{
+			name:         "logs body read by the handler",
+			body:         `{"title":"Task","password":"hunter2","api_key":"k\"ey"}`,
+			readBody:     true,
+			expectedBody: `{"title":"Task","password":"[REDACTED]","api_key":"[REDACTED]"}`,
+		},
+		{
+			name:         "logs body left unread",
+			body:         `{"title":"Task"}`,
+			expectedBody: `{"title":"Task"}`,
+		},
+		{
+			name:         "truncates large body",
+			body:         strings.Repeat("a", panicBodyLogLimit+10),
+			readBody:     true,
+			expectedBody: strings.Repeat("a", panicBodyLogLimit) + "...(truncated)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+			var read []byte
+			handler := RecoverPanic(logger, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.readBody {
+					read, _ = io.ReadAll(r.Body)
+				}
+				panic("boom")
+			}))
+
+			req := httptest.NewRequest("POST", "/todos?token=secret", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+			}
+			if tt.readBody && string(read) != tt.body {
+				t.Errorf("Expected handler to read the full body, got %q", read)
+			}
+
+			var entry map[string]any
+			err := json.Unmarshal(buf.Bytes(), &entry)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal log entry: %v", err)
+			}
+
+			if entry["msg"] != "boom" {
+				t.Errorf("Expected msg 'boom', got %v", entry["msg"])
+			}
+			if entry["request_path"] != "/todos" {
+				t.Errorf("Expected request_path '/todos', got %v", entry["request_path"])
+			}
+			if entry["request_body"] != tt.expectedBody {
+				t.Errorf("Expected request_body %q, got %v", tt.expectedBody, entry["request_body"])
+			}
+		})
+	}
+
+	t.Run("sends panic details only when enabled", func(t *testing.T) {
+		for _, showDetails := range []bool{false, true} {
+			logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+			handler := RecoverPanic(logger, showDetails)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos", nil))
+
+			var response struct {
+				Error map[string]string `json:"error"`
+			}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			if response.Error["message"] == "" {
+				t.Error("Expected generic error message")
+			}
+
+			panicValue, hasPanic := response.Error["panic"]
+			stack, hasStack := response.Error["stack"]
+			if !showDetails {
+				if hasPanic || hasStack {
+					t.Errorf("Expected no panic details, got %v", response.Error)
+				}
+				continue
+			}
+
+			if panicValue != "boom" {
+				t.Errorf("Expected panic 'boom', got %q", panicValue)
+			}
+			if !strings.Contains(stack, "panicrecover") {
+				t.Errorf("Expected stack trace, got %q", stack)
+			}
+		}
+	})
+
+	t.Run("passes through without panic", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		handler := RecoverPanic(logger, false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos", nil))
+
+		if w.Code != http.StatusNoContent {
+			t.Errorf("Expected status %d, got %d", http.StatusNoContent, w.Code)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("Expected no log entries, got %s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+)
+
+// Rate describes a token bucket: Limit tokens are added per second up to Burst tokens.
+// Zero Limit disables rate limiting.
+type Rate struct {
+	Limit float64
+	Burst int
+}
+
+// RateLimit returns a middleware function that limits requests per client(by IP).
+// Mutating methods(POST, PUT, PATCH, DELETE) are limited by write rate, others by read rate,
+// with separate buckets, so a client exhausting writes can still read.
+// When the limit is exceeded, 429 Too Many Requests is returned.
+func RateLimit(logger *slog.Logger, read, write Rate) func(http.Handler) http.Handler {
+	readLimiter := newLimiter(read)
+	writeLimiter := newLimiter(write)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			l := readLimiter
+			if isWriteMethod(r.Method) {
+				l = writeLimiter
+			}
+
+			if l != nil && !l.allow(clientIP(r), time.Now()) {
+				apierrors.RateLimitExceededResponse(logger, w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// clientIP returns the IP part of r.RemoteAddr.
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
+
+// cleanupInterval is how often idle clients are removed from the limiter.
+const cleanupInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// limiter is a per-client token bucket rate limiter.
+type limiter struct {
+	mu          sync.Mutex
+	rate        Rate
+	clients     map[string]*bucket
+	lastCleanup time.Time
+}
+
+// newLimiter returns nil if the rate limiting is disabled.
+func newLimiter(rate Rate) *limiter {
+	if rate.Limit <= 0 {
+		return nil
+	}
+
+	return &limiter{
+		rate:        rate,
+		clients:     make(map[string]*bucket),
+		lastCleanup: time.Now(),
+	}
+}
+
+// allow reports whether the client can make a request at the moment now, consuming a token if so.
+func (l *limiter) allow(client string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastCleanup) > cleanupInterval {
+		l.cleanup(now)
+	}
+
+	b, ok := l.clients[client]
+	if !ok {
+		b = &bucket{tokens: float64(l.rate.Burst), last: now}
+		l.clients[client] = b
+	}
+
+	b.tokens = min(float64(l.rate.Burst), b.tokens+now.Sub(b.last).Seconds()*l.rate.Limit)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// cleanup removes clients whose buckets are full again(they are equal to new ones).
+func (l *limiter) cleanup(now time.Time) {
+	for client, b := range l.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate.Limit >= float64(l.rate.Burst) {
+			delete(l.clients, client)
+		}
+	}
+
+	l.lastCleanup = now
+}
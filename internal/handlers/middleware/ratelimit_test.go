@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	okHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	t.Run("throttles writes but not reads", func(t *testing.T) {
+		handler := RateLimit(logger,
+			Rate{Limit: 100, Burst: 100},
+			Rate{Limit: 0.001, Burst: 2},
+		)(okHandler)
+
+		for i := range 3 {
+			req := httptest.NewRequest("POST", "/todos", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			expected := http.StatusOK
+			if i == 2 {
+				expected = http.StatusTooManyRequests
+			}
+			if w.Code != expected {
+				t.Errorf("Write %d: expected status %d, got %d", i, expected, w.Code)
+			}
+		}
+
+		for i := range 10 {
+			req := httptest.NewRequest("GET", "/todos", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Read %d: expected status %d, got %d", i, http.StatusOK, w.Code)
+			}
+		}
+	})
+
+	t.Run("keys limits by client", func(t *testing.T) {
+		handler := RateLimit(logger, Rate{}, Rate{Limit: 0.001, Burst: 1})(okHandler)
+
+		for _, addr := range []string{"10.0.0.1:1", "10.0.0.1:2", "10.0.0.2:1"} {
+			req := httptest.NewRequest("DELETE", "/todos/1", nil)
+			req.RemoteAddr = addr
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			expected := http.StatusOK
+			if addr == "10.0.0.1:2" {
+				expected = http.StatusTooManyRequests
+			}
+			if w.Code != expected {
+				t.Errorf("%s: expected status %d, got %d", addr, expected, w.Code)
+			}
+		}
+	})
+}
+
+func TestLimiterRefill(t *testing.T) {
+	l := newLimiter(Rate{Limit: 1, Burst: 1})
+	now := time.Now()
+
+	if !l.allow("client", now) {
+		t.Fatal("Expected first request to be allowed")
+	}
+	if l.allow("client", now) {
+		t.Error("Expected second request to be throttled")
+	}
+	if !l.allow("client", now.Add(time.Second)) {
+		t.Error("Expected request to be allowed after refill")
+	}
+}
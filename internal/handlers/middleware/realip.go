@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP returns a middleware function that replaces r.RemoteAddr with the client IP
+// found by ClientIP, so the following middlewares(RequestLogger, RateLimit) see the
+// client instead of the reverse proxy it connected through. RemoteAddr is left as is
+// when the request didn't come through a trusted proxy.
+func RealIP(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := ClientIP(r, trustedProxies)
+			if ip != clientIP(r) {
+				r = r.Clone(r.Context())
+				r.RemoteAddr = ip
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ClientIP returns the IP of the client that made the request. Forwarding headers are
+// only used when the direct peer(r.RemoteAddr) is in trustedProxies, otherwise any
+// client could spoof them. X-Forwarded-For is read from right to left skipping trusted
+// proxies, so the first untrusted address is the one the proxy chain saw the request
+// from. X-Real-IP is used when X-Forwarded-For is absent. Without usable headers the
+// peer IP is returned.
+func ClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	peer, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return clientIP(r)
+	}
+
+	if !isTrusted(peer.Addr(), trustedProxies) {
+		return clientIP(r)
+	}
+
+	if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		hops := strings.Split(strings.Join(values, ","), ",")
+
+		client := peer.Addr().Unmap()
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// the rest of the chain can't be trusted
+				break
+			}
+
+			client = addr.Unmap()
+			if !isTrusted(client, trustedProxies) {
+				break
+			}
+		}
+
+		return client.String()
+	}
+
+	if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+		addr, err := netip.ParseAddr(realIP)
+		if err == nil {
+			return addr.Unmap().String()
+		}
+	}
+
+	return clientIP(r)
+}
+
+func isTrusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.5/32"),
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		expected     string
+	}{
+		{
+			name:       "no headers",
+			remoteAddr: "203.0.113.7:1234",
+			expected:   "203.0.113.7",
+		},
+		{
+			name:         "untrusted peer's forwarded header is ignored",
+			remoteAddr:   "203.0.113.7:1234",
+			forwardedFor: []string{"198.51.100.1"},
+			realIP:       "198.51.100.2",
+			expected:     "203.0.113.7",
+		},
+		{
+			name:         "trusted peer's forwarded header is honored",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.1"},
+			expected:     "198.51.100.1",
+		},
+		{
+			name:         "trusted hops are skipped from the right",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"1.1.1.1, 198.51.100.1, 192.168.1.5", "10.0.0.2"},
+			expected:     "198.51.100.1",
+		},
+		{
+			name:         "spoofed leftmost address is ignored",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"10.9.9.9, 198.51.100.1"},
+			expected:     "198.51.100.1",
+		},
+		{
+			name:         "invalid hop stops at the last valid address",
+			remoteAddr:   "10.0.0.1:1234",
+			forwardedFor: []string{"198.51.100.1, unknown, 10.0.0.2"},
+			expected:     "10.0.0.2",
+		},
+		{
+			name:       "trusted peer's X-Real-IP is honored",
+			remoteAddr: "10.0.0.1:1234",
+			realIP:     "198.51.100.2",
+			expected:   "198.51.100.2",
+		},
+		{
+			name:       "invalid X-Real-IP is ignored",
+			remoteAddr: "10.0.0.1:1234",
+			realIP:     "unknown",
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "IPv6 peer",
+			remoteAddr: "[2001:db8::1]:1234",
+			realIP:     "198.51.100.2",
+			expected:   "2001:db8::1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/todos", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.forwardedFor {
+				req.Header.Add("X-Forwarded-For", v)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			ip := ClientIP(req, trusted)
+			if ip != tt.expected {
+				t.Errorf("Expected client IP %s, got %s", tt.expected, ip)
+			}
+		})
+	}
+}
+
+func TestRealIP(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	var remoteAddr string
+	handler := RealIP(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remoteAddr = r.RemoteAddr
+	}))
+
+	t.Run("replaces RemoteAddr behind a trusted proxy", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/todos", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if remoteAddr != "198.51.100.1" {
+			t.Errorf("Expected RemoteAddr 198.51.100.1, got %s", remoteAddr)
+		}
+	})
+
+	t.Run("keeps RemoteAddr of an untrusted peer", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/todos", nil)
+		req.RemoteAddr = "203.0.113.7:1234"
+		req.Header.Set("X-Forwarded-For", "198.51.100.1")
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if remoteAddr != "203.0.113.7:1234" {
+			t.Errorf("Expected RemoteAddr 203.0.113.7:1234, got %s", remoteAddr)
+		}
+	})
+
+	t.Run("rate limits clients behind the proxy separately", func(t *testing.T) {
+		limited := RealIP(trusted)(RateLimit(logger,
+			Rate{Limit: 0.001, Burst: 1},
+			Rate{},
+		)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})))
+
+		for _, client := range []string{"198.51.100.1", "198.51.100.2"} {
+			req := httptest.NewRequest("GET", "/todos", nil)
+			req.RemoteAddr = "10.0.0.1:1234"
+			req.Header.Set("X-Forwarded-For", client)
+			w := httptest.NewRecorder()
+
+			limited.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Errorf("Client %s: expected status %d, got %d", client, http.StatusOK, w.Code)
+			}
+		}
+	})
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+)
+
+// RejectBody returns a middleware function that rejects GET and DELETE requests
+// with a body(non-zero Content-Length or chunked body) with 400 Bad Request.
+// The API doesn't read bodies of these requests, so a body is a client error.
+func RejectBody(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method == http.MethodGet || r.Method == http.MethodDelete) && r.ContentLength != 0 {
+				apierrors.BadRequestResponse(logger, w, r, fmt.Errorf("%s request must not have a body", r.Method))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRejectBody(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	handler := RejectBody(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name         string
+		method       string
+		body         string
+		expectedCode int
+	}{
+		{
+			name:         "GET without body passes",
+			method:       "GET",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "GET with body is rejected",
+			method:       "GET",
+			body:         `{"title":"x"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "DELETE with body is rejected",
+			method:       "DELETE",
+			body:         `{"title":"x"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "POST with body passes",
+			method:       "POST",
+			body:         `{"title":"x"}`,
+			expectedCode: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader
+			if tt.body != "" {
+				body = strings.NewReader(tt.body)
+			}
+			req := httptest.NewRequest(tt.method, "/todos/1", body)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
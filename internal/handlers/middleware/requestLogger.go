@@ -4,22 +4,47 @@ import (
 	"log/slog"
 	"net/http"
 	"time"
+
+	"github.com/vladgrskkh/todo/internal/requestid"
 )
 
 // RequestLogger returns a middleware function that logs the request
-// method, path, remote address and duration after the request is completed.
-func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+// method, path, remote address, response status, response size, duration
+// and request id(set by RequestID) after the request is completed.
+// Requests taking longer than slowThreshold are logged at warn level as
+// "slow request" with the route reported by RecordRoute down the chain,
+// 0 disables the check.
+func RequestLogger(logger *slog.Logger, slowThreshold time.Duration) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
-			next.ServeHTTP(w, r)
+			rw := WrapResponseWriter(w)
+
+			ctx, route := WithRouteHolder(r.Context())
+			next.ServeHTTP(rw, r.WithContext(ctx))
 
-			logger.Info("request completed",
+			duration := time.Since(start)
+			attrs := []any{
 				slog.String("method", r.Method),
 				slog.String("path", r.URL.Path),
 				slog.String("remote_addr", r.RemoteAddr),
-				slog.String("duration", time.Since(start).String()))
+				slog.Int("status", rw.Status()),
+				slog.Int("bytes", rw.Size()),
+				slog.String("duration", duration.String()),
+				slog.String("request_id", requestid.FromContext(r.Context())),
+			}
+
+			if slowThreshold > 0 && duration > slowThreshold {
+				attrs = append(attrs, slog.String("slow_threshold", slowThreshold.String()))
+				if pattern := route.Load(); pattern != nil {
+					attrs = append(attrs, slog.String("route", *pattern))
+				}
+				logger.Warn("slow request", attrs...)
+				return
+			}
+
+			logger.Info("request completed", attrs...)
 		})
 	}
 }
@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestLogger(t *testing.T) {
+	t.Run("logs status and size of the response", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		handler := RequestLogger(logger, 0)(http.HandlerFunc(http.NotFound))
+
+		req := httptest.NewRequest("GET", "/todos/1", nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		var entry map[string]any
+		err := json.Unmarshal(buf.Bytes(), &entry)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal log entry: %v", err)
+		}
+
+		if entry["status"] != float64(http.StatusNotFound) {
+			t.Errorf("Expected status %d, got %v", http.StatusNotFound, entry["status"])
+		}
+		if entry["bytes"] != float64(w.Body.Len()) {
+			t.Errorf("Expected bytes %d, got %v", w.Body.Len(), entry["bytes"])
+		}
+		if entry["path"] != "/todos/1" {
+			t.Errorf("Expected path '/todos/1', got %v", entry["path"])
+		}
+	})
+
+	t.Run("logs 200 when handler does not call WriteHeader", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		handler := RequestLogger(logger, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+		var entry map[string]any
+		err := json.Unmarshal(buf.Bytes(), &entry)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal log entry: %v", err)
+		}
+
+		if entry["status"] != float64(http.StatusOK) {
+			t.Errorf("Expected status %d, got %v", http.StatusOK, entry["status"])
+		}
+		if entry["bytes"] != float64(2) {
+			t.Errorf("Expected bytes 2, got %v", entry["bytes"])
+		}
+	})
+}
+
+func TestRequestLoggerSlowRequests(t *testing.T) {
+	tests := []struct {
+		name          string
+		sleep         time.Duration
+		expectedLevel string
+		expectedMsg   string
+	}{
+		{
+			name:          "fast request is logged at info",
+			expectedLevel: "INFO",
+			expectedMsg:   "request completed",
+		},
+		{
+			name:          "slow request is logged at warn",
+			sleep:         50 * time.Millisecond,
+			expectedLevel: "WARN",
+			expectedMsg:   "slow request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+			router := http.NewServeMux()
+			router.HandleFunc("GET /todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tt.sleep)
+			})
+			handler := RequestLogger(logger, 20*time.Millisecond)(RecordRoute(router))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/todos/1", nil))
+
+			var entry map[string]any
+			err := json.Unmarshal(buf.Bytes(), &entry)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal log entry: %v", err)
+			}
+
+			if entry["level"] != tt.expectedLevel {
+				t.Errorf("Expected level %s, got %v", tt.expectedLevel, entry["level"])
+			}
+			if entry["msg"] != tt.expectedMsg {
+				t.Errorf("Expected message %q, got %v", tt.expectedMsg, entry["msg"])
+			}
+
+			if tt.expectedLevel == "WARN" {
+				if entry["route"] != "GET /todos/{id}" {
+					t.Errorf("Expected route 'GET /todos/{id}', got %v", entry["route"])
+				}
+				if entry["status"] != float64(http.StatusOK) {
+					t.Errorf("Expected status %d, got %v", http.StatusOK, entry["status"])
+				}
+			}
+		})
+	}
+}
+
+func TestWrapResponseWriter(t *testing.T) {
+	t.Run("does not wrap twice", func(t *testing.T) {
+		rw := WrapResponseWriter(httptest.NewRecorder())
+
+		if WrapResponseWriter(rw) != rw {
+			t.Error("Expected already wrapped writer to be returned as is")
+		}
+	})
+
+	t.Run("keeps first status code", func(t *testing.T) {
+		rw := WrapResponseWriter(httptest.NewRecorder())
+
+		rw.WriteHeader(http.StatusNotFound)
+		rw.WriteHeader(http.StatusInternalServerError)
+
+		if rw.Status() != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, rw.Status())
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+	"github.com/vladgrskkh/todo/internal/requestid"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		reused   bool
+	}{
+		{name: "generates id", reused: false},
+		{name: "reuses valid client id", incoming: "client-id_1.2", reused: true},
+		{name: "replaces invalid client id", incoming: "bad id\n", reused: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ctxID string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				ctxID = requestid.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/todos", nil)
+			if tt.incoming != "" {
+				req.Header.Set(requestid.Header, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			id := w.Header().Get(requestid.Header)
+			if !requestid.Valid(id) {
+				t.Fatalf("Expected valid request id, got %q", id)
+			}
+			if ctxID != id {
+				t.Errorf("Expected context id %q to match header, got %q", id, ctxID)
+			}
+			if tt.reused != (id == tt.incoming) {
+				t.Errorf("Expected reused %v, got id %q for incoming %q", tt.reused, id, tt.incoming)
+			}
+		})
+	}
+
+	t.Run("server error includes logged request id", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+		handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apierrors.ServerErrorResponse(logger, w, r, errors.New("db error"))
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+
+		var response struct {
+			Error map[string]string `json:"error"`
+			Code  string            `json:"code"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		var entry map[string]any
+		if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to unmarshal log entry: %v", err)
+		}
+
+		id := response.Error["request_id"]
+		if id == "" || id != w.Header().Get(requestid.Header) {
+			t.Errorf("Expected request id from header in body, got %q", id)
+		}
+		if entry["request_id"] != id {
+			t.Errorf("Expected logged request id %q, got %v", id, entry["request_id"])
+		}
+		if response.Error["message"] == "" || response.Code != "internal_error" {
+			t.Errorf("Unexpected response: %+v", response)
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+)
+
+// LimitRequestSize returns a middleware function that rejects requests with
+// URL longer than maxURLLength or headers larger than maxHeaderBytes with
+// 431 Request Header Fields Too Large. 0 disables the corresponding check.
+// Complements http.MaxBytesReader, which only limits the body.
+func LimitRequestSize(logger *slog.Logger, maxURLLength, maxHeaderBytes int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if maxURLLength > 0 && len(r.URL.RequestURI()) > maxURLLength {
+				apierrors.RequestHeaderFieldsTooLargeResponse(logger, w, r, "request url is too long")
+				return
+			}
+
+			if maxHeaderBytes > 0 && headerSize(r.Header) > maxHeaderBytes {
+				apierrors.RequestHeaderFieldsTooLargeResponse(logger, w, r, "request headers are too large")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// headerSize returns approximate size of the headers as sent on the wire
+// ("Name: value\r\n" for every value).
+func headerSize(h http.Header) int {
+	size := 0
+	for name, values := range h {
+		for _, v := range values {
+			size += len(name) + len(v) + 4
+		}
+	}
+
+	return size
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitRequestSize(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	handler := LimitRequestSize(logger, 100, 200)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name         string
+		url          string
+		header       string
+		expectedCode int
+	}{
+		{
+			name:         "normal request passes",
+			url:          "/todos?due_within=24h",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "oversized query string is rejected",
+			url:          "/todos?ids=" + strings.Repeat("1,", 100),
+			expectedCode: http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name:         "oversized header is rejected",
+			url:          "/todos",
+			header:       strings.Repeat("a", 300),
+			expectedCode: http.StatusRequestHeaderFieldsTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Custom", tt.header)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+
+	t.Run("zero limits disable checks", func(t *testing.T) {
+		handler := LimitRequestSize(logger, 0, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		req := httptest.NewRequest("GET", "/todos?ids="+strings.Repeat("1,", 1000), nil)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import "net/http"
+
+// ResponseWriter wraps http.ResponseWriter and records the status code and
+// the number of bytes written to the client.
+// It is shared between middlewares (request logger, metrics), so the
+// http.ResponseWriter is wrapped only once per request.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+// WrapResponseWriter returns w as *ResponseWriter. If w is already wrapped,
+// it is returned as is to avoid double-wrapping.
+func WrapResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	if rw, ok := w.(*ResponseWriter); ok {
+		return rw
+	}
+
+	return &ResponseWriter{
+		ResponseWriter: w,
+		status:         http.StatusOK,
+	}
+}
+
+func (rw *ResponseWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += n
+
+	return n, err
+}
+
+// Unwrap returns the original http.ResponseWriter, used by http.ResponseController.
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Status returns the status code sent to the client.
+func (rw *ResponseWriter) Status() int {
+	return rw.status
+}
+
+// Size returns the number of bytes of the response body written so far.
+func (rw *ResponseWriter) Size() int {
+	return rw.size
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+type routeKey struct{}
+
+// WithRouteHolder returns a context carrying a holder RecordRoute stores the route in,
+// reusing the holder already in ctx. The holder is shared by all copies of the request
+// made down the chain(e.g. by Timeout), so middlewares wrapping them can read the route.
+func WithRouteHolder(ctx context.Context) (context.Context, *atomic.Pointer[string]) {
+	holder, ok := ctx.Value(routeKey{}).(*atomic.Pointer[string])
+	if ok {
+		return ctx, holder
+	}
+
+	holder = new(atomic.Pointer[string])
+	return context.WithValue(ctx, routeKey{}, holder), holder
+}
+
+// RecordRoute returns a middleware function that reports the route pattern matched by
+// mux(e.g. "GET /todos/{id}") to the holder of WithRouteHolder, used by the request logger
+// and the latency histograms. It must wrap the mux directly, as ServeMux sets the pattern
+// only on the request it gets.
+func RecordRoute(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// set even if the handler panics
+		defer func() {
+			holder, ok := r.Context().Value(routeKey{}).(*atomic.Pointer[string])
+			if ok && r.Pattern != "" {
+				pattern := r.Pattern
+				holder.Store(&pattern)
+			}
+		}()
+
+		mux.ServeHTTP(w, r)
+	})
+}
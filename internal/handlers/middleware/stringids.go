@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+)
+
+// StringIDs returns a middleware function that encodes task ids(the "id" fields and
+// the "ids" arrays) of JSON responses as strings, so clients parsing JSON numbers as
+// float64(e.g. JavaScript) don't lose precision. JSON responses are buffered to be
+// rewritten. Other responses and the ones served in byte ranges(with Accept-Ranges,
+// e.g. the export dump, which must stay the same for resumed downloads) are passed
+// through as is.
+func StringIDs() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sw := &stringIDsWriter{ResponseWriter: w}
+			next.ServeHTTP(sw, r)
+			sw.finish()
+		})
+	}
+}
+
+// stringIDsWriter buffers JSON responses until the handler finishes.
+type stringIDsWriter struct {
+	http.ResponseWriter
+	status    int
+	decided   bool
+	buffering bool
+	buf       bytes.Buffer
+}
+
+// decide chooses whether the response is buffered, once the headers are set.
+func (sw *stringIDsWriter) decide() {
+	if sw.decided {
+		return
+	}
+
+	sw.decided = true
+	header := sw.Header()
+	sw.buffering = strings.HasPrefix(header.Get("Content-Type"), "application/json") && header.Get("Accept-Ranges") == ""
+}
+
+func (sw *stringIDsWriter) WriteHeader(code int) {
+	sw.decide()
+	if !sw.buffering {
+		sw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	if sw.status == 0 {
+		sw.status = code
+	}
+}
+
+func (sw *stringIDsWriter) Write(b []byte) (int, error) {
+	sw.decide()
+	if !sw.buffering {
+		return sw.ResponseWriter.Write(b)
+	}
+
+	return sw.buf.Write(b)
+}
+
+// Unwrap returns the original http.ResponseWriter, used by http.ResponseController.
+func (sw *stringIDsWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
+
+// finish writes the buffered response with the ids quoted.
+func (sw *stringIDsWriter) finish() {
+	if !sw.buffering {
+		return
+	}
+
+	if sw.status == 0 {
+		sw.status = http.StatusOK
+	}
+
+	// the length changes with the quotes
+	sw.Header().Del("Content-Length")
+	sw.ResponseWriter.WriteHeader(sw.status)
+	_, _ = sw.ResponseWriter.Write(quoteIDs(sw.buf.Bytes()))
+}
+
+// quoteIDs returns the JSON js with the numbers of "id" fields and "ids" arrays
+// quoted, keeping the rest of it(and its formatting) as is.
+func quoteIDs(js []byte) []byte {
+	out := make([]byte, 0, len(js)+len(js)/8)
+
+	// lastString is the last string read, the field name if it's followed by ':'
+	// field is the name of the field which value is being read
+	var lastString, field string
+	inIDs := false
+
+	for i := 0; i < len(js); i++ {
+		c := js[i]
+
+		switch {
+		case c == '"':
+			end := stringEnd(js, i)
+			lastString = string(js[i+1 : end])
+			out = append(out, js[i:end+1]...)
+			i = end
+			continue
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := numberEnd(js, i)
+			if field == "id" || inIDs {
+				out = append(out, '"')
+				out = append(out, js[i:end]...)
+				out = append(out, '"')
+			} else {
+				out = append(out, js[i:end]...)
+			}
+			i = end - 1
+			continue
+		case c == ':':
+			field = lastString
+		case c == '[':
+			inIDs = field == "ids"
+		case c == ']':
+			inIDs = false
+			field = ""
+		case c == ',' || c == '{' || c == '}':
+			if !inIDs {
+				field = ""
+			}
+		}
+
+		out = append(out, c)
+	}
+
+	return out
+}
+
+// stringEnd returns the index of the quote closing the JSON string starting at i.
+func stringEnd(js []byte, i int) int {
+	for j := i + 1; j < len(js); j++ {
+		switch js[j] {
+		case '\\':
+			j++
+		case '"':
+			return j
+		}
+	}
+
+	return len(js) - 1
+}
+
+// numberEnd returns the index after the JSON number starting at i.
+func numberEnd(js []byte, i int) int {
+	j := i
+	for j < len(js) && strings.IndexByte("+-0123456789.eE", js[j]) >= 0 {
+		j++
+	}
+
+	return j
+}
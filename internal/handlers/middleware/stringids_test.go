@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStringIDs(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		acceptRange bool
+		body        string
+		expected    string
+	}{
+		{
+			name:        "task",
+			contentType: "application/json",
+			body:        `{"task":{"id":9007199254740993,"title":"id: 1","priority":2}}`,
+			expected:    `{"task":{"id":"9007199254740993","title":"id: 1","priority":2}}`,
+		},
+		{
+			name:        "ids array",
+			contentType: "application/json",
+			body:        "{\n\t\"ids\": [1, 2],\n\t\"count\": 2\n}",
+			expected:    "{\n\t\"ids\": [\"1\", \"2\"],\n\t\"count\": 2\n}",
+		},
+		{
+			name:        "list",
+			contentType: "application/json",
+			body:        `{"tasks":[{"id":1,"subtasks":[{"title":"a\"id\":","done":false}]},{"id":-2}],"total":2}`,
+			expected:    `{"tasks":[{"id":"1","subtasks":[{"title":"a\"id\":","done":false}]},{"id":"-2"}],"total":2}`,
+		},
+		{
+			name:        "not json",
+			contentType: "text/csv",
+			body:        "id\n1\n",
+			expected:    "id\n1\n",
+		},
+		{
+			name:        "ranges",
+			contentType: "application/json",
+			acceptRange: true,
+			body:        `{"tasks":[{"id":1}]}`,
+			expected:    `{"tasks":[{"id":1}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := StringIDs()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				if tt.acceptRange {
+					w.Header().Set("Accept-Ranges", "bytes")
+				}
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+
+			req := httptest.NewRequest("GET", "/todos", nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+			}
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
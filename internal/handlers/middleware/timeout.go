@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+)
+
+// ErrHandlerTimeout is returned by Write on the response writer of a timed out request.
+var ErrHandlerTimeout = errors.New("http: handler timeout")
+
+// Timeout returns a middleware function that limits request processing time to d.
+// The request context is canceled after d and, if the handler hasn't finished by then,
+// the client gets 503 Service Unavailable. Like http.TimeoutHandler, the handler
+// response is buffered and only sent if the handler finishes in time, its later
+// writes fail with ErrHandlerTimeout.
+//
+// Requests to skipPaths are passed through without the timeout, for streamed responses
+// (e.g. exports) the buffering would break.
+func Timeout(logger *slog.Logger, d time.Duration, skipPaths ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(skipPaths, strings.TrimSuffix(r.URL.Path, "/")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{header: make(http.Header), status: http.StatusOK}
+			done := make(chan struct{})
+			panicChan := make(chan any, 1)
+
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicChan <- p
+					}
+				}()
+
+				next.ServeHTTP(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicChan:
+				// handled by the server or an outer RecoverPanic
+				panic(p)
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				maps.Copy(w.Header(), tw.header)
+				w.WriteHeader(tw.status)
+				_, _ = w.Write(tw.buf.Bytes())
+			case <-ctx.Done():
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+
+				tw.timedOut = true
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					apierrors.TimeoutResponse(logger, w, r)
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the handler response until it finishes.
+type timeoutWriter struct {
+	mu          sync.Mutex
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+
+	tw.status = code
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, ErrHandlerTimeout
+	}
+
+	tw.wroteHeader = true
+	return tw.buf.Write(b)
+}
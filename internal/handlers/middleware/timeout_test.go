@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("passes through fast handler response", func(t *testing.T) {
+		handler := Timeout(logger, time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Custom", "value")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte("created"))
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("POST", "/todos", nil))
+
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+		if w.Header().Get("X-Custom") != "value" {
+			t.Errorf("Expected X-Custom header, got %q", w.Header().Get("X-Custom"))
+		}
+		if w.Body.String() != "created" {
+			t.Errorf("Expected body 'created', got %q", w.Body.String())
+		}
+	})
+
+	t.Run("returns 503 for slow handler", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		written := make(chan error, 1)
+		handler := Timeout(logger, 10*time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			<-release
+
+			_, err := w.Write([]byte("late"))
+			written <- err
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos", nil))
+
+		if w.Code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+		}
+
+		var response map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if response["code"] != "request_timeout" {
+			t.Errorf("Expected code 'request_timeout', got %q", response["code"])
+		}
+
+		release <- struct{}{}
+		if err := <-written; err != ErrHandlerTimeout {
+			t.Errorf("Expected ErrHandlerTimeout for late write, got %v", err)
+		}
+	})
+
+	t.Run("skipped paths write through", func(t *testing.T) {
+		handler := Timeout(logger, 10*time.Millisecond, "/todos.csv")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := w.(http.Flusher); !ok {
+				t.Error("Expected the response writer to be a flusher")
+			}
+
+			time.Sleep(20 * time.Millisecond)
+			_, _ = w.Write([]byte("streamed"))
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos.csv", nil))
+
+		if w.Code != http.StatusOK || w.Body.String() != "streamed" {
+			t.Errorf("Expected streamed response, got %d %q", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("propagates panics", func(t *testing.T) {
+		handler := Timeout(logger, time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		defer func() {
+			if p := recover(); p != "boom" {
+				t.Errorf("Expected panic 'boom', got %v", p)
+			}
+		}()
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/todos", nil))
+	})
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+)
+
+// StripTrailingSlash returns a middleware function that removes the trailing slash
+// from the request path(e.g. /todos/ -> /todos, /todos/1/ -> /todos/1) before
+// passing the request to the next handler, so both forms reach the same route.
+// The path is rewritten internally instead of redirecting, so it works for
+// non-GET requests too.
+func StripTrailingSlash(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.URL.Path) > 1 && strings.HasSuffix(r.URL.Path, "/") {
+			r2 := r.Clone(r.Context())
+			r2.URL.Path = strings.TrimRight(r.URL.Path, "/")
+			if r2.URL.Path == "" {
+				r2.URL.Path = "/"
+			}
+			r2.URL.RawPath = strings.TrimRight(r.URL.RawPath, "/")
+			r2.RequestURI = r2.URL.RequestURI()
+
+			r = r2
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStripTrailingSlash(t *testing.T) {
+	router := http.NewServeMux()
+	router.HandleFunc("GET /todos", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("list"))
+	})
+	router.HandleFunc("GET /todos/{id}", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("get " + r.PathValue("id")))
+	})
+	router.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("root"))
+	})
+
+	handler := StripTrailingSlash(router)
+
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"/todos", "list"},
+		{"/todos/", "list"},
+		{"/todos/1", "get 1"},
+		{"/todos/1/", "get 1"},
+		{"/todos//", "list"},
+		{"/", "root"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+			}
+			if w.Body.String() != tt.expected {
+				t.Errorf("Expected '%s', got '%s'", tt.expected, w.Body.String())
+			}
+		})
+	}
+}
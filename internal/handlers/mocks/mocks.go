@@ -1,8 +1,15 @@
 package mocks
 
 import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
 	"github.com/vladgrskkh/todo/internal/domain"
 	"github.com/vladgrskkh/todo/internal/handlers/dto"
+	"github.com/vladgrskkh/todo/internal/repository"
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
 )
 
 type mockTaskGetter struct {
@@ -23,6 +30,14 @@ func (m *mockTaskGetter) GetTask(id int64) (*domain.Task, error) {
 	return m.task, nil
 }
 
+// GetTaskHistory returns the task as its only snapshot.
+func (m *mockTaskGetter) GetTaskHistory(id int64) ([]*domain.Task, error) {
+	if m.getErr != nil {
+		return nil, m.getErr
+	}
+	return []*domain.Task{m.task}, nil
+}
+
 func (m *mockTaskGetter) GetAllTasks() ([]*domain.Task, error) {
 	if m.getAllErr != nil {
 		return nil, m.getAllErr
@@ -30,6 +45,45 @@ func (m *mockTaskGetter) GetAllTasks() ([]*domain.Task, error) {
 	return m.tasks, nil
 }
 
+func (m *mockTaskGetter) SearchTasks(filter domain.TaskFilter) ([]*domain.Task, error) {
+	if m.getAllErr != nil {
+		return nil, m.getAllErr
+	}
+	return filter.Apply(m.tasks), nil
+}
+
+type mockTaskBoardGetter struct {
+	tasks []*domain.Task
+	err   error
+}
+
+func NewMockTaskBoardGetter(tasks []*domain.Task, err error) *mockTaskBoardGetter {
+	return &mockTaskBoardGetter{tasks, err}
+}
+
+func (m *mockTaskBoardGetter) GetTaskBoard(filter domain.TaskFilter) (domain.TaskBoard, error) {
+	if m.err != nil {
+		return domain.TaskBoard{}, m.err
+	}
+	return domain.GroupByStatus(filter.Apply(m.tasks)), nil
+}
+
+type mockTaskHistoryGetter struct {
+	history    []*domain.Task
+	historyErr error
+}
+
+func NewMockTaskHistoryGetter(history []*domain.Task, historyErr error) *mockTaskHistoryGetter {
+	return &mockTaskHistoryGetter{history, historyErr}
+}
+
+func (m *mockTaskHistoryGetter) GetTaskHistory(id int64) ([]*domain.Task, error) {
+	if m.historyErr != nil {
+		return nil, m.historyErr
+	}
+	return m.history, nil
+}
+
 type mockTaskCreater struct {
 	createErr error
 }
@@ -42,6 +96,13 @@ func (m *mockTaskCreater) CreateTask(task *domain.Task) error {
 	return m.createErr
 }
 
+func (m *mockTaskCreater) CreateTaskIdempotent(key string, task *domain.Task) (*domain.Task, bool, error) {
+	if m.createErr != nil {
+		return nil, false, m.createErr
+	}
+	return task, false, nil
+}
+
 type mockTaskUpdater struct {
 	task      *domain.Task
 	updateErr error
@@ -59,6 +120,72 @@ func (m *mockTaskUpdater) UpdateTask(id int64, input dto.UpdateTaskInput) (*doma
 	m.task.Title = input.Title
 	m.task.Description = input.Description
 	m.task.Done = input.Done
+	m.task.Subtasks = input.Subtasks
+	return m.task, nil
+}
+
+// UpsertTask creates a new task if the mock update error is repository.ErrNotFound.
+func (m *mockTaskUpdater) UpsertTask(id int64, input dto.UpdateTaskInput) (*domain.Task, bool, error) {
+	if errors.Is(m.updateErr, repository.ErrNotFound) {
+		task := domain.NewTask(id, input.Title, input.Description)
+		task.Done = input.Done
+		task.Subtasks = input.Subtasks
+		return task, true, nil
+	}
+
+	task, err := m.UpdateTask(id, input)
+	return task, false, err
+}
+
+type mockTaskDuplicator struct {
+	task         *domain.Task
+	duplicateErr error
+}
+
+func NewMockTaskDuplicator(task *domain.Task, duplicateErr error) *mockTaskDuplicator {
+	return &mockTaskDuplicator{task, duplicateErr}
+}
+
+func (m *mockTaskDuplicator) DuplicateTask(id int64) (*domain.Task, error) {
+	if m.duplicateErr != nil {
+		return nil, m.duplicateErr
+	}
+
+	return m.task, nil
+}
+
+type mockTaskPatcher struct {
+	task     *domain.Task
+	patchErr error
+}
+
+func NewMockTaskPatcher(task *domain.Task, patchErr error) *mockTaskPatcher {
+	return &mockTaskPatcher{task, patchErr}
+}
+
+func (m *mockTaskPatcher) PatchTask(id int64, patch []byte, version int) (*domain.Task, error) {
+	if m.patchErr != nil {
+		return nil, m.patchErr
+	}
+
+	return m.task, nil
+}
+
+type mockTaskMover struct {
+	task    *domain.Task
+	moveErr error
+}
+
+func NewMockTaskMover(task *domain.Task, moveErr error) *mockTaskMover {
+	return &mockTaskMover{task, moveErr}
+}
+
+func (m *mockTaskMover) MoveTask(id int64, position int) (*domain.Task, error) {
+	if m.moveErr != nil {
+		return nil, m.moveErr
+	}
+
+	m.task.Position = position
 	return m.task, nil
 }
 
@@ -73,3 +200,277 @@ func NewMockTaskDeleter(deleteErr error) *mockTaskDeleter {
 func (m *mockTaskDeleter) DeleteTask(id int64) error {
 	return m.deleteErr
 }
+
+type mockTrashStore struct {
+	trash      []*domain.Task
+	restoreErr error
+}
+
+func NewMockTrashStore(trash []*domain.Task, restoreErr error) *mockTrashStore {
+	return &mockTrashStore{trash, restoreErr}
+}
+
+func (m *mockTrashStore) GetTrash() ([]*domain.Task, error) {
+	return m.trash, nil
+}
+
+func (m *mockTrashStore) RestoreTask(id int64) (*domain.Task, error) {
+	if m.restoreErr != nil {
+		return nil, m.restoreErr
+	}
+
+	for _, task := range m.trash {
+		if task.ID == id {
+			restored := *task
+			restored.DeletedAt = nil
+			return &restored, nil
+		}
+	}
+
+	return nil, repository.ErrNotFound
+}
+
+type mockIntegrityReporter struct {
+	report *repository.IntegrityReport
+}
+
+func NewMockIntegrityReporter(report *repository.IntegrityReport) *mockIntegrityReporter {
+	return &mockIntegrityReporter{report}
+}
+
+func (m *mockIntegrityReporter) IntegrityReport() *repository.IntegrityReport {
+	return m.report
+}
+
+type mockDBStatsProvider struct {
+	stats    inmemorydb.Stats
+	statsErr error
+}
+
+func NewMockDBStatsProvider(stats inmemorydb.Stats, statsErr error) *mockDBStatsProvider {
+	return &mockDBStatsProvider{stats, statsErr}
+}
+
+func (m *mockDBStatsProvider) DBStats() (inmemorydb.Stats, error) {
+	return m.stats, m.statsErr
+}
+
+type mockVacuumer struct {
+	reclaimed int64
+	vacuumErr error
+}
+
+func NewMockVacuumer(reclaimed int64, vacuumErr error) *mockVacuumer {
+	return &mockVacuumer{reclaimed, vacuumErr}
+}
+
+func (m *mockVacuumer) Vacuum() (int64, error) {
+	return m.reclaimed, m.vacuumErr
+}
+
+type mockTaskMigrator struct {
+	migrated   int
+	migrateErr error
+}
+
+func NewMockTaskMigrator(migrated int, migrateErr error) *mockTaskMigrator {
+	return &mockTaskMigrator{migrated, migrateErr}
+}
+
+func (m *mockTaskMigrator) MigrateTasks() (int, error) {
+	return m.migrated, m.migrateErr
+}
+
+type mockTaskStatsGetter struct {
+	stats domain.TaskStats
+	err   error
+}
+
+func NewMockTaskStatsGetter(stats domain.TaskStats, err error) *mockTaskStatsGetter {
+	return &mockTaskStatsGetter{stats, err}
+}
+
+func (m *mockTaskStatsGetter) GetTaskStats() (domain.TaskStats, error) {
+	return m.stats, m.err
+}
+
+type mockDailyStatsGetter struct {
+	err error
+}
+
+func NewMockDailyStatsGetter(err error) *mockDailyStatsGetter {
+	return &mockDailyStatsGetter{err}
+}
+
+// GetDailyStats returns days empty buckets dated from 2025-01-01.
+func (m *mockDailyStatsGetter) GetDailyStats(days int) ([]domain.DailyStats, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	stats := make([]domain.DailyStats, days)
+	for i := range stats {
+		stats[i].Date = start.AddDate(0, 0, i).Format(time.DateOnly)
+	}
+
+	return stats, nil
+}
+
+type mockOpenTaskCounter struct {
+	open int
+	err  error
+}
+
+func NewMockOpenTaskCounter(open int, err error) *mockOpenTaskCounter {
+	return &mockOpenTaskCounter{open, err}
+}
+
+func (m *mockOpenTaskCounter) CountOpenTasks() (int, error) {
+	return m.open, m.err
+}
+
+type mockTasksDueTodayGetter struct {
+	tasks []*domain.Task
+	err   error
+}
+
+func NewMockTasksDueTodayGetter(tasks []*domain.Task, err error) *mockTasksDueTodayGetter {
+	return &mockTasksDueTodayGetter{tasks, err}
+}
+
+func (m *mockTasksDueTodayGetter) GetTasksDueToday() ([]*domain.Task, error) {
+	return m.tasks, m.err
+}
+
+type mockNextTaskGetter struct {
+	task *domain.Task
+	err  error
+}
+
+func NewMockNextTaskGetter(task *domain.Task, err error) *mockNextTaskGetter {
+	return &mockNextTaskGetter{task, err}
+}
+
+func (m *mockNextTaskGetter) GetNextTask() (*domain.Task, error) {
+	return m.task, m.err
+}
+
+type mockTaskCompleter struct {
+	completed   int
+	completeErr error
+	tag         string
+}
+
+func NewMockTaskCompleter(completed int, completeErr error) *mockTaskCompleter {
+	return &mockTaskCompleter{completed: completed, completeErr: completeErr}
+}
+
+func (m *mockTaskCompleter) CompleteTasks(tag string) (int, error) {
+	m.tag = tag
+	return m.completed, m.completeErr
+}
+
+// Tag returns the tag CompleteTasks was last called with.
+func (m *mockTaskCompleter) Tag() string {
+	return m.tag
+}
+
+type mockCompletedTaskDeleter struct {
+	deleted   int
+	deleteErr error
+}
+
+func NewMockCompletedTaskDeleter(deleted int, deleteErr error) *mockCompletedTaskDeleter {
+	return &mockCompletedTaskDeleter{deleted, deleteErr}
+}
+
+func (m *mockCompletedTaskDeleter) DeleteCompletedTasks() (int, error) {
+	return m.deleted, m.deleteErr
+}
+
+type mockTaskDumpStore struct {
+	tasks      []*domain.Task
+	restoreErr error
+	restored   []*domain.Task
+}
+
+func NewMockTaskDumpStore(tasks []*domain.Task, restoreErr error) *mockTaskDumpStore {
+	return &mockTaskDumpStore{tasks: tasks, restoreErr: restoreErr}
+}
+
+func (m *mockTaskDumpStore) IterateTasks(fn func(task *domain.Task) error) error {
+	for _, task := range m.tasks {
+		err := fn(task)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mockTaskDumpStore) RestoreTasks(tasks []*domain.Task) (int, error) {
+	if m.restoreErr != nil {
+		return 0, m.restoreErr
+	}
+	m.restored = tasks
+	return len(tasks), nil
+}
+
+func (m *mockTaskDumpStore) CreateTask(task *domain.Task) error {
+	return nil
+}
+
+// Restored returns the tasks RestoreTasks was last called with.
+func (m *mockTaskDumpStore) Restored() []*domain.Task {
+	return m.restored
+}
+
+type mockTaskStatusBulkUpdater struct {
+	results   []domain.BulkResult
+	updateErr error
+}
+
+func NewMockTaskStatusBulkUpdater(results []domain.BulkResult, updateErr error) *mockTaskStatusBulkUpdater {
+	return &mockTaskStatusBulkUpdater{results, updateErr}
+}
+
+func (m *mockTaskStatusBulkUpdater) UpdateTasksStatus(input dto.BulkStatusInput) ([]domain.BulkResult, error) {
+	return m.results, m.updateErr
+}
+
+type mockKeyStore struct {
+	keys []repository.KeyInfo
+	err  error
+}
+
+func NewMockKeyStore(keys []repository.KeyInfo, err error) *mockKeyStore {
+	return &mockKeyStore{keys, err}
+}
+
+func (m *mockKeyStore) ListKeys(prefix string) ([]repository.KeyInfo, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+
+	keys := make([]repository.KeyInfo, 0)
+	for _, k := range m.keys {
+		if strings.HasPrefix(k.Key, prefix) {
+			keys = append(keys, k)
+		}
+	}
+	return keys, nil
+}
+
+func (m *mockKeyStore) DeleteKey(key string) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	i := slices.IndexFunc(m.keys, func(k repository.KeyInfo) bool { return k.Key == key })
+	if i == -1 {
+		return repository.ErrNotFound
+	}
+	m.keys = slices.Delete(m.keys, i, i+1)
+	return nil
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec is a hand-maintained OpenAPI 3 document describing the API.
+// Keep it in sync with routes and dto package.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func NewOpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(openAPISpec)
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewOpenAPIHandler(t *testing.T) {
+	handler := NewOpenAPIHandler()
+
+	req := httptest.NewRequest("GET", "/openapi.json", nil)
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var spec struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &spec)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal spec: %v", err)
+	}
+
+	if spec.OpenAPI == "" {
+		t.Error("Expected openapi version to be set")
+	}
+
+	routes := []struct {
+		path   string
+		method string
+	}{
+		{"/todos", "get"},
+		{"/todos", "post"},
+		{"/todos/{id}", "get"},
+		{"/todos/{id}", "put"},
+		{"/todos/{id}", "delete"},
+	}
+
+	for _, route := range routes {
+		if _, ok := spec.Paths[route.path][route.method]; !ok {
+			t.Errorf("Expected %s %s to be described in spec", route.method, route.path)
+		}
+	}
+}
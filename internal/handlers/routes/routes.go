@@ -1,32 +1,131 @@
 package routes
 
 import (
-	"expvar"
 	"log/slog"
 	"net/http"
 
+	"github.com/vladgrskkh/todo/config"
 	"github.com/vladgrskkh/todo/internal/handlers"
 	"github.com/vladgrskkh/todo/internal/handlers/middleware"
 	"github.com/vladgrskkh/todo/internal/handlers/middleware/metrics"
+	"github.com/vladgrskkh/todo/internal/paramutil"
 	"github.com/vladgrskkh/todo/internal/service"
 )
 
-func Routes(logger *slog.Logger, service *service.TodoService, env, version string) http.Handler {
+func Routes(logger *slog.Logger, service *service.TodoService, cfg *config.Config) http.Handler {
 	router := http.NewServeMux()
 
 	// middleware init
-	requestLogger := middleware.RequestLogger(logger)
-	recoverPanic := middleware.RecoverPanic(logger)
+	requestLogger := middleware.RequestLogger(logger, cfg.SlowRequestThreshold)
+	recoverPanic := middleware.RecoverPanic(logger, cfg.PanicDetails)
 
-	router.HandleFunc("GET /healthcheck", handlers.NewHealthCheckHandler(logger, env, version))
+	router.HandleFunc("GET /healthcheck", handlers.NewHealthCheckHandler(logger, cfg.Env, cfg.Version, service))
 
-	router.HandleFunc("GET /todos/{id}", handlers.NewGetTaskHandler(logger, service))
-	router.HandleFunc("GET /todos", handlers.NewGetAllTasksHandler(logger, service))
-	router.HandleFunc("POST /todos", handlers.NewPostTaskHandler(logger, service))
-	router.HandleFunc("PUT /todos/{id}", handlers.NewTaskUpdater(logger, service))
-	router.HandleFunc("DELETE /todos/{id}", handlers.NewDeleteTaskHandler(logger, service))
+	// task routes are mounted under base path
+	taskRouter := TaskRoutes(logger, service, cfg)
+	router.Handle(cfg.BasePath+"/todos", taskRouter)
+	router.Handle(cfg.BasePath+"/todos/", taskRouter)
+	router.Handle(cfg.BasePath+"/todos.csv", taskRouter)
+	router.HandleFunc("GET "+cfg.BasePath+"/stats", handlers.NewGetStatsHandler(logger, service))
+	router.HandleFunc("GET "+cfg.BasePath+"/stats/daily", handlers.NewGetDailyStatsHandler(logger, service))
 
-	router.Handle("GET /metrics", expvar.Handler())
+	router.Handle("GET /metrics", metrics.Handler())
+	router.HandleFunc("GET /openapi.json", handlers.NewOpenAPIHandler())
 
-	return metrics.Metrics(requestLogger(recoverPanic(router)))
+	// admin endpoints are registered only when admin token is configured
+	if cfg.AdminToken != "" {
+		requireAdmin := middleware.RequireAdminToken(logger, cfg.AdminToken)
+
+		router.Handle("GET /debug/stats", requireAdmin(handlers.NewDebugStatsHandler(logger, service)))
+		router.Handle("POST /debug/vacuum", requireAdmin(handlers.NewVacuumHandler(logger, service)))
+		router.Handle("POST /debug/migrate", requireAdmin(handlers.NewMigrateHandler(logger, service)))
+		router.Handle("GET /admin/db/status", requireAdmin(handlers.NewDBStatusHandler(logger, service)))
+		router.Handle("POST /admin/db/compact", requireAdmin(handlers.NewCompactDBHandler(logger, service)))
+		router.Handle("GET /admin/keys", requireAdmin(handlers.NewListKeysHandler(logger, service)))
+		router.Handle("DELETE /admin/keys/{key}", requireAdmin(handlers.NewDeleteKeyHandler(logger, service)))
+		router.Handle("GET /admin/todos/{id}/raw", requireAdmin(handlers.NewGetRawTaskHandler(logger, service)))
+	}
+
+	var handler http.Handler = middleware.StripTrailingSlash(middleware.RecordRoute(router))
+
+	if cfg.StringIDs {
+		handler = middleware.StringIDs()(handler)
+	}
+
+	handler = recoverPanic(handler)
+
+	if cfg.RejectBodyOnGetDelete {
+		handler = middleware.RejectBody(logger)(handler)
+	}
+
+	if cfg.RequestTimeout > 0 {
+		// exports are streamed(with Range support), so they are not buffered by Timeout
+		handler = middleware.Timeout(logger, cfg.RequestTimeout,
+			cfg.BasePath+"/todos.csv", cfg.BasePath+"/todos/export")(handler)
+	}
+
+	if cfg.ReadRateLimit > 0 || cfg.WriteRateLimit > 0 {
+		handler = middleware.RateLimit(logger,
+			middleware.Rate{Limit: cfg.ReadRateLimit, Burst: cfg.ReadRateBurst},
+			middleware.Rate{Limit: cfg.WriteRateLimit, Burst: cfg.WriteRateBurst},
+		)(handler)
+	}
+
+	if cfg.MaxConcurrentRequests > 0 {
+		handler = middleware.MaxConcurrent(logger, cfg.MaxConcurrentRequests)(handler)
+	}
+
+	if cfg.MaxURLLength > 0 || cfg.MaxHeaderBytes > 0 {
+		handler = middleware.LimitRequestSize(logger, cfg.MaxURLLength, cfg.MaxHeaderBytes)(handler)
+	}
+
+	if len(cfg.CORSAllowedOrigins) > 0 {
+		handler = middleware.CORS(cfg.CORSAllowedOrigins, cfg.CORSMaxAge)(handler)
+	}
+
+	handler = middleware.RequestID(requestLogger(handler))
+
+	// the client IP is resolved before logging and rate limiting
+	if len(cfg.TrustedProxies) > 0 {
+		handler = middleware.RealIP(cfg.TrustedProxies)(handler)
+	}
+
+	return metrics.Metrics(handler)
+}
+
+// TaskRoutes returns a mux with the /todos routes registered under cfg.BasePath(e.g. /v1),
+// so they can be embedded into another mux. Empty base path mounts them at the root.
+func TaskRoutes(logger *slog.Logger, service *service.TodoService, cfg *config.Config) *http.ServeMux {
+	router := http.NewServeMux()
+
+	basePath := cfg.BasePath
+	pageLimits := paramutil.PageLimits{
+		DefaultPageSize: cfg.DefaultPageSize,
+		MaxPageSize:     cfg.MaxPageSize,
+	}
+
+	router.HandleFunc("GET "+basePath+"/todos/{id}", handlers.NewGetTaskHandler(logger, service, cfg.TaskCacheMaxAge))
+	router.HandleFunc("GET "+basePath+"/todos/{id}/history", handlers.NewGetTaskHistoryHandler(logger, service))
+	router.HandleFunc("GET "+basePath+"/todos", handlers.NewGetAllTasksHandler(logger, service, pageLimits))
+	router.HandleFunc("GET "+basePath+"/todos/tags", handlers.NewGetTagsHandler(logger, service))
+	router.HandleFunc("GET "+basePath+"/todos/board", handlers.NewGetTaskBoardHandler(logger, service))
+	router.HandleFunc("GET "+basePath+"/todos/today", handlers.NewGetTasksDueTodayHandler(logger, service))
+	router.HandleFunc("GET "+basePath+"/todos/next", handlers.NewGetNextTaskHandler(logger, service))
+	router.HandleFunc("GET "+basePath+"/todos/open/count", handlers.NewGetOpenCountHandler(logger, service))
+	router.HandleFunc("GET "+basePath+"/todos.csv", handlers.NewExportTasksCSVHandler(logger, service))
+	router.HandleFunc("GET "+basePath+"/todos/export", handlers.NewExportTasksHandler(logger, service))
+	router.HandleFunc("GET "+basePath+"/todos/trash", handlers.NewGetTrashHandler(logger, service))
+	router.HandleFunc("POST "+basePath+"/todos", handlers.NewPostTaskHandler(logger, service))
+	router.HandleFunc("POST "+basePath+"/todos/import", handlers.NewImportTasksHandler(logger, service))
+	router.HandleFunc("POST "+basePath+"/todos/done", handlers.NewCompleteTasksHandler(logger, service))
+	router.HandleFunc("PUT "+basePath+"/todos/{id}", handlers.NewTaskUpdater(logger, service))
+	router.HandleFunc("PATCH "+basePath+"/todos", handlers.NewBulkStatusHandler(logger, service))
+	router.HandleFunc("PATCH "+basePath+"/todos/{id}", handlers.NewPatchTaskHandler(logger, service, cfg.PatchRequireIfMatch))
+	router.HandleFunc("POST "+basePath+"/todos/{id}/move", handlers.NewMoveTaskHandler(logger, service))
+	router.HandleFunc("POST "+basePath+"/todos/{id}/duplicate", handlers.NewDuplicateTaskHandler(logger, service))
+	router.HandleFunc("POST "+basePath+"/todos/{id}/restore", handlers.NewRestoreTaskHandler(logger, service))
+	router.HandleFunc("DELETE "+basePath+"/todos/completed", handlers.NewDeleteCompletedTasksHandler(logger, service))
+	router.HandleFunc("DELETE "+basePath+"/todos/{id}", handlers.NewDeleteTaskHandler(logger, service))
+
+	return router
 }
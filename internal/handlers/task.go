@@ -1,9 +1,17 @@
 package handlers
 
 import (
+	"cmp"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"log/slog"
+	"mime"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/vladgrskkh/todo/internal/apierrors"
 	"github.com/vladgrskkh/todo/internal/domain"
@@ -18,10 +26,23 @@ import (
 
 type TaskGetter interface {
 	GetTask(id int64) (*domain.Task, error)
+	GetTaskHistory(id int64) ([]*domain.Task, error)
 	GetAllTasks() ([]*domain.Task, error)
+	SearchTasks(filter domain.TaskFilter) ([]*domain.Task, error)
 }
 
-func NewGetTaskHandler(logger *slog.Logger, service TaskGetter) http.HandlerFunc {
+// NewGetTaskHandler returns a task by id with its ETag. When cacheMaxAge is positive,
+// successful responses carry Cache-Control: private, max-age=N, error responses are never cached.
+// With ?include=history the response also has the task snapshots(see NewGetTaskHistoryHandler),
+// the log is scanned only when asked for.
+func NewGetTaskHandler(logger *slog.Logger, service TaskGetter, cacheMaxAge time.Duration) http.HandlerFunc {
+	var headers http.Header
+	if cacheMaxAge > 0 {
+		headers = http.Header{
+			"Cache-Control": []string{fmt.Sprintf("private, max-age=%d", int(cacheMaxAge.Seconds()))},
+		}
+	}
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := paramutil.ReadIDParam(r)
 		if err != nil {
@@ -29,6 +50,12 @@ func NewGetTaskHandler(logger *slog.Logger, service TaskGetter) http.HandlerFunc
 			return
 		}
 
+		include, err := paramutil.ReadEnumQuery(r, "include", "history")
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
 		task, err := service.GetTask(id)
 		if err != nil {
 			switch {
@@ -36,6 +63,8 @@ func NewGetTaskHandler(logger *slog.Logger, service TaskGetter) http.HandlerFunc
 				apierrors.BadRequestResponse(logger, w, r, err)
 			case errors.Is(err, repository.ErrNotFound):
 				apierrors.NotFoundResponse(logger, w, r)
+			case errors.Is(err, repository.ErrCorrupt):
+				apierrors.CorruptDataResponse(logger, w, r, err)
 			default:
 				apierrors.ServerErrorResponse(logger, w, r, err)
 			}
@@ -43,16 +72,392 @@ func NewGetTaskHandler(logger *slog.Logger, service TaskGetter) http.HandlerFunc
 			return
 		}
 
-		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"task": task}, nil)
+		taskHeaders := headers.Clone()
+		if taskHeaders == nil {
+			taskHeaders = make(http.Header)
+		}
+		taskHeaders.Set("ETag", taskETag(task))
+
+		data := jsonhttp.Envelope{"task": task}
+		if include == "history" {
+			history, err := service.GetTaskHistory(id)
+			switch {
+			// the task exists, the log just has no entries for it(e.g. memory-only db)
+			case errors.Is(err, repository.ErrNotFound):
+				history = []*domain.Task{}
+			case err != nil:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+				return
+			}
+
+			data["history"] = history
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, data, taskHeaders)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type TaskHistoryGetter interface {
+	GetTaskHistory(id int64) ([]*domain.Task, error)
+}
+
+// NewGetTaskHistoryHandler returns task snapshots, oldest first.
+func NewGetTaskHistoryHandler(logger *slog.Logger, service TaskHistoryGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := paramutil.ReadIDParam(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		history, err := service.GetTaskHistory(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, s.ErrInvalidID):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			case errors.Is(err, repository.ErrNotFound):
+				apierrors.NotFoundResponse(logger, w, r)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"history": history}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+// NewGetAllTasksHandler returns the list of tasks. The list is paginated with page
+// and page_size query parameters, limited by pageLimits. The filters(see readTaskFilter)
+// are combined with AND.
+func NewGetAllTasksHandler(logger *slog.Logger, service TaskGetter, pageLimits paramutil.PageLimits) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sort := r.URL.Query().Get("sort")
+		if sort != "" && sort != "position" {
+			apierrors.BadRequestResponse(logger, w, r, fmt.Errorf("invalid sort value %q", sort))
+			return
+		}
+
+		page, err := paramutil.ReadPageQuery(r, pageLimits)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		fields, err := readFieldsQuery(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		filter, err := readTaskFilter(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		tasks, err := service.SearchTasks(filter)
+		if err != nil {
+			switch {
+			case errors.Is(err, s.ErrInvalidTimeRange):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		switch {
+		case sort == "position":
+			domain.SortByPosition(tasks)
+		case filter.DueWithin > 0:
+			// due_within results are sorted by due date, others by id, so pages are stable
+			slices.SortFunc(tasks, func(a, b *domain.Task) int {
+				return cmp.Or(a.DueDate.Compare(*b.DueDate), cmp.Compare(a.ID, b.ID))
+			})
+		default:
+			slices.SortFunc(tasks, func(a, b *domain.Task) int {
+				return cmp.Compare(a.ID, b.ID)
+			})
+		}
+
+		// total count is sent in header to keep the response body compatible
+		headers := http.Header{"X-Total-Count": []string{strconv.Itoa(len(tasks))}}
+
+		tasks = paramutil.Paginate(tasks, page)
+
+		var data jsonhttp.Envelope
+		if len(fields) > 0 {
+			projected, err := projectTasks(tasks, fields)
+			if err != nil {
+				apierrors.ServerErrorResponse(logger, w, r, err)
+				return
+			}
+
+			data = jsonhttp.Envelope{"tasks": projected}
+		} else {
+			data = jsonhttp.Envelope{"tasks": tasks}
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, data, headers)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+// readTaskFilter reads the done, priority, tag, q, due_within, created_from and
+// created_to query parameters, each parameter is optional and validated on its own.
+func readTaskFilter(r *http.Request) (domain.TaskFilter, error) {
+	query := r.URL.Query()
+	filter := domain.TaskFilter{
+		Tag:   query.Get("tag"),
+		Query: query.Get("q"),
+	}
+
+	if query.Has("done") {
+		done, err := paramutil.ReadBoolQuery(r, "done")
+		if err != nil {
+			return domain.TaskFilter{}, err
+		}
+		filter.Done = &done
+	}
+
+	priority, err := paramutil.ReadEnumQuery(r, "priority", domain.Priorities...)
+	if err != nil {
+		return domain.TaskFilter{}, err
+	}
+	filter.Priority = priority
+
+	if query.Has("due_within") {
+		filter.DueWithin, err = paramutil.ReadDurationQuery(r, "due_within")
+		if err != nil {
+			return domain.TaskFilter{}, err
+		}
+	}
+
+	if query.Has("created_from") {
+		filter.CreatedFrom, err = paramutil.ReadTimeQuery(r, "created_from")
+		if err != nil {
+			return domain.TaskFilter{}, err
+		}
+	}
+
+	if query.Has("created_to") {
+		filter.CreatedTo, err = paramutil.ReadTimeQuery(r, "created_to")
+		if err != nil {
+			return domain.TaskFilter{}, err
+		}
+	}
+
+	return filter, nil
+}
+
+type TaskBoardGetter interface {
+	GetTaskBoard(filter domain.TaskFilter) (domain.TaskBoard, error)
+}
+
+// NewGetTaskBoardHandler returns open and done tasks in separate lists, filtered
+// like the flat list.
+func NewGetTaskBoardHandler(logger *slog.Logger, service TaskBoardGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := readTaskFilter(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		board, err := service.GetTaskBoard(filter)
+		if err != nil {
+			switch {
+			case errors.Is(err, s.ErrInvalidTimeRange):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"open": board.Open, "done": board.Done}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+// taskFields is the set of task JSON fields that can be requested with the fields parameter.
+var taskFields = map[string]struct{}{
+	"id":          {},
+	"title":       {},
+	"description": {},
+	"done":        {},
+	"subtasks":    {},
+	"tags":        {},
+	"due_date":    {},
+	"priority":    {},
+	"created_at":  {},
+	"updated_at":  {},
+	"version":     {},
+	"position":    {},
+	"progress":    {},
+}
+
+// readFieldsQuery reads comma-separated fields query parameter(e.g. fields=id,title).
+// Returns nil if the parameter is absent.
+func readFieldsQuery(r *http.Request) ([]string, error) {
+	param := r.URL.Query().Get("fields")
+	if param == "" {
+		return nil, nil
+	}
+
+	fields := strings.Split(param, ",")
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if _, ok := taskFields[field]; !ok {
+			return nil, fmt.Errorf("invalid fields parameter: unknown field %q", field)
+		}
+		fields[i] = field
+	}
+
+	return fields, nil
+}
+
+// projectTasks returns tasks with only the requested fields. Empty optional fields
+// (e.g. due_date) are omitted like in the full task.
+func projectTasks(tasks []*domain.Task, fields []string) ([]map[string]any, error) {
+	projected := make([]map[string]any, 0, len(tasks))
+
+	for _, task := range tasks {
+		js, err := json.Marshal(task)
+		if err != nil {
+			return nil, err
+		}
+
+		var all map[string]any
+		err = json.Unmarshal(js, &all)
+		if err != nil {
+			return nil, err
+		}
+
+		p := make(map[string]any, len(fields))
+		for _, field := range fields {
+			if v, ok := all[field]; ok {
+				p[field] = v
+			}
+		}
+
+		projected = append(projected, p)
+	}
+
+	return projected, nil
+}
+
+type TagCounter interface {
+	GetTagCounts() ([]domain.TagCount, error)
+}
+
+func NewGetTagsHandler(logger *slog.Logger, service TagCounter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tags, err := service.GetTagCounts()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"tags": tags}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type TaskStatsGetter interface {
+	GetTaskStats() (domain.TaskStats, error)
+}
+
+// NewGetStatsHandler returns total, done, open and completed today task counts.
+func NewGetStatsHandler(logger *slog.Logger, service TaskStatsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats, err := service.GetTaskStats()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"stats": stats}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+// maxStatsDays limits the days parameter of GET /stats/daily.
+const maxStatsDays = 366
+
+type DailyStatsGetter interface {
+	GetDailyStats(days int) ([]domain.DailyStats, error)
+}
+
+// NewGetDailyStatsHandler returns the numbers of tasks created and completed on each
+// of the last ?days(default 7) days, including today, oldest first.
+func NewGetDailyStatsHandler(logger *slog.Logger, service DailyStatsGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		days, err := paramutil.ReadIntQuery(r, "days", 7, 1, maxStatsDays)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		stats, err := service.GetDailyStats(days)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"days": stats}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type OpenTaskCounter interface {
+	CountOpenTasks() (int, error)
+}
+
+// NewGetOpenCountHandler returns the number of open tasks(e.g. for a badge).
+func NewGetOpenCountHandler(logger *slog.Logger, service OpenTaskCounter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		open, err := service.CountOpenTasks()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"open": open}, nil)
 		if err != nil {
 			apierrors.ServerErrorResponse(logger, w, r, err)
 		}
 	}
 }
 
-func NewGetAllTasksHandler(logger *slog.Logger, service TaskGetter) http.HandlerFunc {
+type TasksDueTodayGetter interface {
+	GetTasksDueToday() ([]*domain.Task, error)
+}
+
+// NewGetTasksDueTodayHandler returns tasks due on the current day, sorted by due date.
+func NewGetTasksDueTodayHandler(logger *slog.Logger, service TasksDueTodayGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		tasks, err := service.GetAllTasks()
+		tasks, err := service.GetTasksDueToday()
 		if err != nil {
 			apierrors.ServerErrorResponse(logger, w, r, err)
 			return
@@ -65,10 +470,40 @@ func NewGetAllTasksHandler(logger *slog.Logger, service TaskGetter) http.Handler
 	}
 }
 
+type NextTaskGetter interface {
+	GetNextTask() (*domain.Task, error)
+}
+
+// NewGetNextTaskHandler returns the oldest open task, or 404 when all tasks are done.
+func NewGetNextTaskHandler(logger *slog.Logger, service NextTaskGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, err := service.GetNextTask()
+		if err != nil {
+			switch {
+			case errors.Is(err, repository.ErrNotFound):
+				apierrors.NotFoundResponse(logger, w, r)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"task": task}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
 type TaskCreater interface {
 	CreateTask(task *domain.Task) error
+	CreateTaskIdempotent(key string, task *domain.Task) (*domain.Task, bool, error)
 }
 
+// maxIdempotencyKeyLength limits the size of the Idempotency-Key header.
+const maxIdempotencyKeyLength = 255
+
 func NewPostTaskHandler(logger *slog.Logger, service TaskCreater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var input dto.CreateTaskInput
@@ -79,16 +514,75 @@ func NewPostTaskHandler(logger *slog.Logger, service TaskCreater) http.HandlerFu
 			return
 		}
 
-		task := domain.NewTask(input.ID, input.Title, input.Description)
+		task := domain.NewTask(int64(input.ID), input.Title, input.Description)
+		task.Subtasks = input.Subtasks
+		task.Tags = input.Tags
+		task.DueDate = input.DueDate
+		task.Priority = input.Priority
 
-		err = service.CreateTask(task)
+		replayed := false
+		if key := r.Header.Get("Idempotency-Key"); key != "" {
+			if len(key) > maxIdempotencyKeyLength {
+				apierrors.BadRequestResponse(logger, w, r, fmt.Errorf("idempotency key must not be more than %d bytes long", maxIdempotencyKeyLength))
+				return
+			}
+
+			task, replayed, err = service.CreateTaskIdempotent(key, task)
+		} else {
+			err = service.CreateTask(task)
+		}
 		if err != nil {
 			var validationErr *validator.Validator
 			switch {
 			case errors.As(err, &validationErr):
-				apierrors.FailedValidationResponse(logger, w, r, validationErr.Errors)
+				apierrors.FailedValidationResponse(logger, w, r, validationErr)
 			case errors.Is(err, s.ErrTaskExists):
 				apierrors.DuplicateTaskResponse(logger, w, r)
+			case errors.Is(err, s.ErrDuplicateTitle):
+				apierrors.DuplicateTitleResponse(logger, w, r)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		if !replayed {
+			metrics.IncTasksCreated()
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusCreated, jsonhttp.Envelope{"task": task}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type TaskDuplicator interface {
+	DuplicateTask(id int64) (*domain.Task, error)
+}
+
+// NewDuplicateTaskHandler creates a copy of the task with a new id.
+func NewDuplicateTaskHandler(logger *slog.Logger, service TaskDuplicator) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := paramutil.ReadIDParam(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		task, err := service.DuplicateTask(id)
+		if err != nil {
+			var validationErr *validator.Validator
+			switch {
+			case errors.As(err, &validationErr):
+				apierrors.FailedValidationResponse(logger, w, r, validationErr)
+			case errors.Is(err, s.ErrInvalidID):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			case errors.Is(err, repository.ErrNotFound):
+				apierrors.NotFoundResponse(logger, w, r)
+			case errors.Is(err, s.ErrDuplicateTitle):
+				apierrors.DuplicateTitleResponse(logger, w, r)
 			default:
 				apierrors.ServerErrorResponse(logger, w, r, err)
 			}
@@ -96,7 +590,7 @@ func NewPostTaskHandler(logger *slog.Logger, service TaskCreater) http.HandlerFu
 			return
 		}
 
-		metrics.TotalTasksCreated.Add(1)
+		metrics.IncTasksCreated()
 
 		err = jsonhttp.WriteJSON(w, http.StatusCreated, jsonhttp.Envelope{"task": task}, nil)
 		if err != nil {
@@ -107,8 +601,11 @@ func NewPostTaskHandler(logger *slog.Logger, service TaskCreater) http.HandlerFu
 
 type TaskUpdater interface {
 	UpdateTask(id int64, input dto.UpdateTaskInput) (*domain.Task, error)
+	UpsertTask(id int64, input dto.UpdateTaskInput) (*domain.Task, bool, error)
 }
 
+// NewTaskUpdater replaces the task with the given id. With ?upsert=true, the task
+// is created(with the path id) if it doesn't exist, responding with 201 instead of 200.
 func NewTaskUpdater(logger *slog.Logger, service TaskUpdater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := paramutil.ReadIDParam(r)
@@ -117,6 +614,12 @@ func NewTaskUpdater(logger *slog.Logger, service TaskUpdater) http.HandlerFunc {
 			return
 		}
 
+		upsert, err := paramutil.ReadBoolQuery(r, "upsert")
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
 		var input dto.UpdateTaskInput
 
 		err = jsonhttp.ReadJSON(w, r, &input)
@@ -125,12 +628,143 @@ func NewTaskUpdater(logger *slog.Logger, service TaskUpdater) http.HandlerFunc {
 			return
 		}
 
-		task, err := service.UpdateTask(id, input)
+		var task *domain.Task
+		var created bool
+		if upsert {
+			task, created, err = service.UpsertTask(id, input)
+		} else {
+			task, err = service.UpdateTask(id, input)
+		}
+		if err != nil {
+			var validationErr *validator.Validator
+			switch {
+			case errors.As(err, &validationErr):
+				apierrors.FailedValidationResponse(logger, w, r, validationErr)
+			case errors.Is(err, s.ErrInvalidID):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			case errors.Is(err, repository.ErrNotFound):
+				apierrors.NotFoundResponse(logger, w, r)
+			case errors.Is(err, s.ErrDuplicateTitle):
+				apierrors.DuplicateTitleResponse(logger, w, r)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		status := http.StatusOK
+		if created {
+			metrics.IncTasksCreated()
+			status = http.StatusCreated
+		}
+
+		err = jsonhttp.WriteJSON(w, status, jsonhttp.Envelope{"task": task}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type TaskPatcher interface {
+	PatchTask(id int64, patch []byte, version int) (*domain.Task, error)
+}
+
+// mergePatchContentType is the media type of JSON Merge Patch(RFC 7386).
+const mergePatchContentType = "application/merge-patch+json"
+
+// NewPatchTaskHandler partially updates a task with JSON Merge Patch
+// (Content-Type: application/merge-patch+json). The patch is only applied if the
+// If-Match header matches the task ETag, otherwise 412 is returned. Requests without
+// If-Match get 428 if requireIfMatch is true and are applied unconditionally otherwise.
+func NewPatchTaskHandler(logger *slog.Logger, service TaskPatcher, requireIfMatch bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != mergePatchContentType {
+			apierrors.UnsupportedMediaTypeResponse(logger, w, r, "content type must be "+mergePatchContentType)
+			return
+		}
+
+		id, err := paramutil.ReadIDParam(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		version, conditional, err := readIfMatchVersion(r)
+		switch {
+		case !conditional && requireIfMatch:
+			apierrors.PreconditionRequiredResponse(logger, w, r)
+			return
+		case err != nil:
+			apierrors.PreconditionFailedResponse(logger, w, r)
+			return
+		}
+
+		var patch json.RawMessage
+
+		err = jsonhttp.ReadJSON(w, r, &patch)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		task, err := service.PatchTask(id, patch, version)
+		if err != nil {
+			var validationErr *validator.Validator
+			switch {
+			case errors.As(err, &validationErr):
+				apierrors.FailedValidationResponse(logger, w, r, validationErr)
+			case errors.Is(err, s.ErrInvalidID), errors.Is(err, s.ErrInvalidPatch):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			case errors.Is(err, s.ErrVersionMismatch):
+				apierrors.PreconditionFailedResponse(logger, w, r)
+			case errors.Is(err, repository.ErrNotFound):
+				apierrors.NotFoundResponse(logger, w, r)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		headers := make(http.Header)
+		headers.Set("ETag", taskETag(task))
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"task": task}, headers)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type TaskMover interface {
+	MoveTask(id int64, position int) (*domain.Task, error)
+}
+
+func NewMoveTaskHandler(logger *slog.Logger, service TaskMover) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := paramutil.ReadIDParam(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		var input dto.MoveTaskInput
+
+		err = jsonhttp.ReadJSON(w, r, &input)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		task, err := service.MoveTask(id, input.Position)
 		if err != nil {
 			var validationErr *validator.Validator
 			switch {
 			case errors.As(err, &validationErr):
-				apierrors.FailedValidationResponse(logger, w, r, validationErr.Errors)
+				apierrors.FailedValidationResponse(logger, w, r, validationErr)
+			case errors.Is(err, s.ErrInvalidID):
+				apierrors.BadRequestResponse(logger, w, r, err)
 			case errors.Is(err, repository.ErrNotFound):
 				apierrors.NotFoundResponse(logger, w, r)
 			default:
@@ -147,6 +781,112 @@ func NewTaskUpdater(logger *slog.Logger, service TaskUpdater) http.HandlerFunc {
 	}
 }
 
+type TaskStatusBulkUpdater interface {
+	UpdateTasksStatus(input dto.BulkStatusInput) ([]domain.BulkResult, error)
+}
+
+// NewBulkStatusHandler sets the done status of several tasks from {"ids":[...],"done":bool}
+// and returns a result per id(updated, notfound or conflict). The update is best-effort:
+// tasks that can't be updated are reported and don't abort the others.
+func NewBulkStatusHandler(logger *slog.Logger, service TaskStatusBulkUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input dto.BulkStatusInput
+
+		err := jsonhttp.ReadJSON(w, r, &input)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		results, err := service.UpdateTasksStatus(input)
+		if err != nil {
+			var validationErr *validator.Validator
+			switch {
+			case errors.As(err, &validationErr):
+				apierrors.FailedValidationResponse(logger, w, r, validationErr)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+			return
+		}
+
+		if *input.Done {
+			completed := 0
+			for _, result := range results {
+				if result.Status == domain.BulkUpdated {
+					completed++
+				}
+			}
+			metrics.AddTasksDone(completed)
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"results": results}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type TaskCompleter interface {
+	CompleteTasks(tag string) (int, error)
+}
+
+// NewCompleteTasksHandler marks the open tasks with the tag query parameter as done,
+// or all open tasks with all=true, and returns the number of completed tasks.
+func NewCompleteTasksHandler(logger *slog.Logger, service TaskCompleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tag := r.URL.Query().Get("tag")
+
+		all, err := paramutil.ReadBoolQuery(r, "all")
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		// completing every task must be asked for explicitly, so a request with
+		// a missing or empty tag doesn't complete them by mistake
+		if tag == "" && !all {
+			apierrors.BadRequestResponse(logger, w, r, fmt.Errorf("tag or all=true parameter is required"))
+			return
+		}
+
+		completed, err := service.CompleteTasks(tag)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		metrics.AddTasksDone(completed)
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"completed": completed}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type CompletedTaskDeleter interface {
+	DeleteCompletedTasks() (int, error)
+}
+
+// NewDeleteCompletedTasksHandler deletes all done tasks and returns the number of deleted tasks.
+func NewDeleteCompletedTasksHandler(logger *slog.Logger, service CompletedTaskDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		deleted, err := service.DeleteCompletedTasks()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		metrics.AddTasksDeleted(deleted)
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"deleted": deleted}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
 type TaskDeleter interface {
 	DeleteTask(id int64) error
 }
@@ -173,6 +913,8 @@ func NewDeleteTaskHandler(logger *slog.Logger, service TaskDeleter) http.Handler
 			return
 		}
 
+		metrics.IncTasksDeleted()
+
 		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"message": "task successfully deleted"}, nil)
 		if err != nil {
 			apierrors.ServerErrorResponse(logger, w, r, err)
@@ -3,28 +3,27 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/vladgrskkh/todo/internal/domain"
 	"github.com/vladgrskkh/todo/internal/handlers/dto"
-	"github.com/vladgrskkh/todo/internal/handlers/middleware/metrics"
 	"github.com/vladgrskkh/todo/internal/handlers/mocks"
+	"github.com/vladgrskkh/todo/internal/paramutil"
 	"github.com/vladgrskkh/todo/internal/repository"
 	"github.com/vladgrskkh/todo/internal/service"
 	"github.com/vladgrskkh/todo/pkg/validator"
 )
 
-func init() {
-	if metrics.TotalTasksCreated == nil {
-		metrics.InitMetrics()
-	}
-}
-
 func TestNewGetTaskHandler(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
@@ -60,7 +59,7 @@ func TestNewGetTaskHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := mocks.NewMockTaskGetter(domain.NewTask(tt.taskId, tt.tastTitle, tt.taskDescription), nil, tt.getErr, tt.getAllErr)
-			handler := NewGetTaskHandler(logger, mockService)
+			handler := NewGetTaskHandler(logger, mockService, 0)
 
 			req := httptest.NewRequest("GET", tt.url, nil)
 			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
@@ -74,9 +73,40 @@ func TestNewGetTaskHandler(t *testing.T) {
 		})
 	}
 
+	t.Run("returns server error for corrupt task", func(t *testing.T) {
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+		getErr := fmt.Errorf("error getting task with 1 id: %w: gob: bad data", repository.ErrCorrupt)
+		mockService := mocks.NewMockTaskGetter(nil, nil, getErr, nil)
+		handler := NewGetTaskHandler(logger, mockService, 0)
+
+		req := httptest.NewRequest("GET", "/todos/1", nil)
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), `"code": "data_corrupt"`) {
+			t.Errorf("Expected data_corrupt code, got %s", w.Body.String())
+		}
+
+		var entry map[string]any
+		err := json.Unmarshal(logs.Bytes(), &entry)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal log entry: %v", err)
+		}
+		if entry["corrupt"] != true || entry["msg"] != getErr.Error() {
+			t.Errorf("Expected corrupt error to be logged, got %v", entry)
+		}
+	})
+
 	t.Run("returns task successfully", func(t *testing.T) {
 		mockService := mocks.NewMockTaskGetter(domain.NewTask(1, "Test Task", "Test Description"), nil, nil, nil)
-		handler := NewGetTaskHandler(logger, mockService)
+		handler := NewGetTaskHandler(logger, mockService, 0)
 
 		req := httptest.NewRequest("GET", "/todos/1", nil)
 		req.SetPathValue("id", "1")
@@ -98,9 +128,180 @@ func TestNewGetTaskHandler(t *testing.T) {
 		if task.ID != 1 || task.Title != "Test Task" {
 			t.Error("Task data incorrect")
 		}
+
+		if cc := w.Header().Get("Cache-Control"); cc != "" {
+			t.Errorf("Expected no Cache-Control header, got %q", cc)
+		}
+		if etag := w.Header().Get("ETag"); etag != `"1"` {
+			t.Errorf("Expected ETag %q, got %q", `"1"`, etag)
+		}
+	})
+
+	t.Run("includes history only when asked for", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			url           string
+			expectHistory bool
+			expectedCode  int
+		}{
+			{name: "without include", url: "/todos/1", expectedCode: http.StatusOK},
+			{name: "with include=history", url: "/todos/1?include=history", expectHistory: true, expectedCode: http.StatusOK},
+			{name: "unknown include", url: "/todos/1?include=comments", expectedCode: http.StatusBadRequest},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := mocks.NewMockTaskGetter(domain.NewTask(1, "Test Task", ""), nil, nil, nil)
+				handler := NewGetTaskHandler(logger, mockService, 0)
+
+				req := httptest.NewRequest("GET", tt.url, nil)
+				req.SetPathValue("id", "1")
+				w := httptest.NewRecorder()
+
+				handler(w, req)
+
+				if w.Code != tt.expectedCode {
+					t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+				}
+				if tt.expectedCode != http.StatusOK {
+					return
+				}
+
+				var response map[string]json.RawMessage
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+
+				if _, ok := response["task"]; !ok {
+					t.Error("Expected task in response")
+				}
+
+				raw, ok := response["history"]
+				if ok != tt.expectHistory {
+					t.Fatalf("Expected history in response %v, got %v", tt.expectHistory, ok)
+				}
+				if !ok {
+					return
+				}
+
+				var history []domain.Task
+				err = json.Unmarshal(raw, &history)
+				if err != nil {
+					t.Fatalf("Failed to unmarshal history: %v", err)
+				}
+				if len(history) != 1 || history[0].ID != 1 {
+					t.Errorf("Expected history with task 1, got %v", history)
+				}
+			})
+		}
+	})
+
+	t.Run("sets Cache-Control only on success", func(t *testing.T) {
+		tests := []struct {
+			name          string
+			getErr        error
+			expectedCode  int
+			expectedCache string
+		}{
+			{
+				name:          "found",
+				expectedCode:  http.StatusOK,
+				expectedCache: "private, max-age=60",
+			},
+			{
+				name:          "not found",
+				getErr:        repository.ErrNotFound,
+				expectedCode:  http.StatusNotFound,
+				expectedCache: "no-store",
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := mocks.NewMockTaskGetter(domain.NewTask(1, "Test Task", ""), nil, tt.getErr, nil)
+				handler := NewGetTaskHandler(logger, mockService, time.Minute)
+
+				req := httptest.NewRequest("GET", "/todos/1", nil)
+				req.SetPathValue("id", "1")
+				w := httptest.NewRecorder()
+
+				handler(w, req)
+
+				if w.Code != tt.expectedCode {
+					t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+				}
+
+				if cc := w.Header().Get("Cache-Control"); cc != tt.expectedCache {
+					t.Errorf("Expected Cache-Control %q, got %q", tt.expectedCache, cc)
+				}
+			})
+		}
 	})
 }
 
+func TestNewGetTaskHistoryHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	created := domain.NewTask(1, "Task", "Description")
+	updated := domain.NewTask(1, "Updated", "Description")
+	updated.Version = 2
+
+	tests := []struct {
+		name         string
+		historyErr   error
+		expectedCode int
+		url          string
+	}{
+		{
+			name:         "returns task history",
+			expectedCode: http.StatusOK,
+			url:          "/todos/1/history",
+		},
+		{
+			name:         "returns bad request for invalid ID",
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/invalid/history",
+		},
+		{
+			name:         "returns not found for missing task",
+			historyErr:   repository.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			url:          "/todos/1/history",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskHistoryGetter([]*domain.Task{created, updated}, tt.historyErr)
+			handler := NewGetTaskHistoryHandler(logger, mockService)
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+
+			if tt.expectedCode == http.StatusOK {
+				var response map[string][]domain.Task
+				err := json.Unmarshal(w.Body.Bytes(), &response)
+				if err != nil {
+					t.Fatalf("Failed to unmarshal response: %v", err)
+				}
+
+				history := response["history"]
+				if len(history) != 2 || history[0].Title != "Task" || history[1].Title != "Updated" {
+					t.Errorf("Unexpected history: %v", history)
+				}
+			}
+		})
+	}
+}
+
 func TestNewGetAllTasksHandler(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
@@ -127,7 +328,7 @@ func TestNewGetAllTasksHandler(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockService := mocks.NewMockTaskGetter(nil, tt.tasks, nil, tt.getAllErr)
-			handler := NewGetAllTasksHandler(logger, mockService)
+			handler := NewGetAllTasksHandler(logger, mockService, paramutil.PageLimits{})
 
 			req := httptest.NewRequest("GET", "/todos", nil)
 			w := httptest.NewRecorder()
@@ -150,71 +351,144 @@ func TestNewGetAllTasksHandler(t *testing.T) {
 	}
 }
 
-func TestNewPostTaskHandler(t *testing.T) {
+func TestNewGetAllTasksHandlerSort(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	first := domain.NewTask(1, "Task 1", "Description 1")
+	first.Position = 2
+	second := domain.NewTask(2, "Task 2", "Description 2")
+	second.Position = 1
+
+	t.Run("sorts by position", func(t *testing.T) {
+		mockService := mocks.NewMockTaskGetter(nil, []*domain.Task{first, second}, nil, nil)
+		handler := NewGetAllTasksHandler(logger, mockService, paramutil.PageLimits{})
+
+		req := httptest.NewRequest("GET", "/todos?sort=position", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string][]domain.Task
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+		if len(response["tasks"]) != 2 || response["tasks"][0].ID != 2 || response["tasks"][1].ID != 1 {
+			t.Errorf("Expected tasks sorted by position, got %v", response["tasks"])
+		}
+	})
+
+	t.Run("rejects unknown sort value", func(t *testing.T) {
+		mockService := mocks.NewMockTaskGetter(nil, nil, nil, nil)
+		handler := NewGetAllTasksHandler(logger, mockService, paramutil.PageLimits{})
+
+		req := httptest.NewRequest("GET", "/todos?sort=unknown", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestNewGetAllTasksHandlerPagination(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
+	tasks := make([]*domain.Task, 0, 5)
+	for i := 5; i >= 1; i-- {
+		tasks = append(tasks, domain.NewTask(int64(i), "Task", "Description"))
+	}
+
+	mockService := mocks.NewMockTaskGetter(nil, tasks, nil, nil)
+	handler := NewGetAllTasksHandler(logger, mockService, paramutil.PageLimits{DefaultPageSize: 2, MaxPageSize: 3})
+
 	tests := []struct {
 		name         string
-		input        dto.CreateTaskInput
-		createErr    error
+		url          string
 		expectedCode int
+		expectedIDs  []int64
 	}{
-		{
-			name: "creates task successfully",
-			input: dto.CreateTaskInput{
-				ID:          1,
-				Title:       "New Task",
-				Description: "New Description",
-			},
-			expectedCode: http.StatusCreated,
-		},
-		{
-			name: "returns conflict for duplicate task",
-			input: dto.CreateTaskInput{
-				ID:          1,
-				Title:       "Duplicate",
-				Description: "Duplicate",
-			},
-			createErr:    service.ErrTaskExists,
-			expectedCode: http.StatusConflict,
-		},
-		{
-			name: "failed validation for task",
-			input: dto.CreateTaskInput{
-				ID:          -1,
-				Description: "Duplicate",
-			},
-			createErr:    validator.New(),
-			expectedCode: http.StatusBadRequest,
-		},
+		{"applies default page size", "/todos", http.StatusOK, []int64{1, 2}},
+		{"returns requested page", "/todos?page=2", http.StatusOK, []int64{3, 4}},
+		{"clamps page size", "/todos?page_size=100", http.StatusOK, []int64{1, 2, 3}},
+		{"rejects negative page size", "/todos?page_size=-1", http.StatusBadRequest, nil},
+		{"huge page is empty", "/todos?page=9223372036854775807&page_size=3", http.StatusOK, []int64{}},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := mocks.NewMockTaskCreator(tt.createErr)
-			handler := NewPostTaskHandler(logger, mockService)
-
-			body, err := json.Marshal(tt.input)
-			if err != nil {
-				t.Fatalf("Failed to marshal to JSON: %v", err)
-			}
-
-			req := httptest.NewRequest("POST", "/todos", bytes.NewReader(body))
+			req := httptest.NewRequest("GET", tt.url, nil)
 			w := httptest.NewRecorder()
 
 			handler(w, req)
 
 			if w.Code != tt.expectedCode {
-				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response map[string][]domain.Task
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			ids := make([]int64, 0, len(response["tasks"]))
+			for _, task := range response["tasks"] {
+				ids = append(ids, task.ID)
+			}
+			if !slices.Equal(ids, tt.expectedIDs) {
+				t.Errorf("Expected ids %v, got %v", tt.expectedIDs, ids)
+			}
+			if w.Header().Get("X-Total-Count") != "5" {
+				t.Errorf("Expected X-Total-Count 5, got '%s'", w.Header().Get("X-Total-Count"))
 			}
 		})
 	}
+}
 
-	t.Run("returns bad request for invalid JSON", func(t *testing.T) {
-		mockService := mocks.NewMockTaskCreator(nil)
-		handler := NewPostTaskHandler(logger, mockService)
+func TestNewGetAllTasksHandlerFields(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
-		req := httptest.NewRequest("POST", "/todos", bytes.NewReader([]byte("invalid")))
+	mockService := mocks.NewMockTaskGetter(nil, []*domain.Task{domain.NewTask(1, "Task", "Description")}, nil, nil)
+	handler := NewGetAllTasksHandler(logger, mockService, paramutil.PageLimits{})
+
+	t.Run("returns only requested fields", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/todos?fields=id,title", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string][]map[string]any
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if len(response["tasks"]) != 1 {
+			t.Fatalf("Expected 1 task, got %d", len(response["tasks"]))
+		}
+
+		task := response["tasks"][0]
+		if len(task) != 2 || task["id"] != float64(1) || task["title"] != "Task" {
+			t.Errorf("Expected only id and title, got %v", task)
+		}
+	})
+
+	t.Run("rejects unknown field", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/todos?fields=id,secret", nil)
 		w := httptest.NewRecorder()
 
 		handler(w, req)
@@ -223,55 +497,200 @@ func TestNewPostTaskHandler(t *testing.T) {
 			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
 		}
 	})
+
+	t.Run("known fields match task JSON", func(t *testing.T) {
+		dueDate := time.Now()
+		task := domain.NewTask(1, "Task", "Description")
+		task.Subtasks = []domain.Subtask{{Title: "Subtask"}}
+		task.Tags = []string{"tag"}
+		task.DueDate = &dueDate
+		task.Priority = domain.PriorityHigh
+
+		js, err := json.Marshal(task)
+		if err != nil {
+			t.Fatalf("Failed to marshal task: %v", err)
+		}
+
+		var all map[string]any
+		err = json.Unmarshal(js, &all)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal task: %v", err)
+		}
+
+		if len(all) != len(taskFields) {
+			t.Errorf("Expected %d fields, got %d", len(taskFields), len(all))
+		}
+		for field := range all {
+			if _, ok := taskFields[field]; !ok {
+				t.Errorf("Field %q is missing from taskFields", field)
+			}
+		}
+	})
 }
 
-func TestNewTaskUpdater(t *testing.T) {
+func TestNewGetAllTasksHandlerDueWithin(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
 	tests := []struct {
-		name         string
-		task         *domain.Task
-		input        dto.UpdateTaskInput
-		updateErr    error
-		expectedCode int
-		url          string
+		name          string
+		url           string
+		excpectedCode int
 	}{
 		{
-			name: "updates task successfully",
-			task: domain.NewTask(1, "Updated", "Updated Description"),
-			input: dto.UpdateTaskInput{
-				Title:       "Updated",
-				Description: "Updated Description",
-				Done:        true,
-			},
-			expectedCode: http.StatusOK,
-			url:          "/todos/1",
+			name:          "returns due tasks",
+			url:           "/todos?due_within=24h",
+			excpectedCode: http.StatusOK,
 		},
 		{
-			name:         "returns bad request for invalid ID",
-			expectedCode: http.StatusBadRequest,
-			url:          "/todos/invalid",
+			name:          "returns bad request for invalid duration",
+			url:           "/todos?due_within=garbage",
+			excpectedCode: http.StatusBadRequest,
 		},
 		{
-			name:         "returns not found for missing task",
-			expectedCode: http.StatusNotFound,
-			updateErr:    repository.ErrNotFound,
-			url:          "/todos/1",
+			name:          "returns bad request for negative duration",
+			url:           "/todos?due_within=-5m",
+			excpectedCode: http.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockService := mocks.NewMockTaskUpdater(tt.task, tt.updateErr)
-			handler := NewTaskUpdater(logger, mockService)
+			mockService := mocks.NewMockTaskGetter(nil, []*domain.Task{domain.NewTask(1, "Task", "Description")}, nil, nil)
+			handler := NewGetAllTasksHandler(logger, mockService, paramutil.PageLimits{})
 
-			body, err := json.Marshal(tt.input)
-			if err != nil {
-				t.Fatalf("Failed to marshal to JSON: %v", err)
-			}
-
-			req := httptest.NewRequest("PUT", tt.url, bytes.NewReader(body))
-			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.excpectedCode {
+				t.Errorf("Expected status %d, got %d", tt.excpectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestNewGetAllTasksHandlerFilters(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	report := domain.NewTask(1, "Write report", "Description")
+	report.Priority = domain.PriorityHigh
+	report.Tags = []string{"work"}
+
+	done := domain.NewTask(2, "Send report", "Description")
+	done.Priority = domain.PriorityHigh
+	done.Tags = []string{"work"}
+	done.Done = true
+
+	home := domain.NewTask(3, "Clean", "Description")
+	home.Tags = []string{"home"}
+
+	day := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, task := range []*domain.Task{report, done, home} {
+		task.CreatedAt = day.AddDate(0, 0, i)
+	}
+
+	tests := []struct {
+		name         string
+		url          string
+		expectedCode int
+		expectedIDs  []int64
+	}{
+		{"no filters", "/todos", http.StatusOK, []int64{1, 2, 3}},
+		{"combined filters", "/todos?done=false&priority=high&tag=work&q=report", http.StatusOK, []int64{1}},
+		{"done and tag", "/todos?done=true&tag=work", http.StatusOK, []int64{2}},
+		{"query", "/todos?q=CLEAN", http.StatusOK, []int64{3}},
+		{"invalid done", "/todos?done=maybe", http.StatusBadRequest, nil},
+		{"invalid priority", "/todos?priority=urgent", http.StatusBadRequest, nil},
+		{"created range and tag", "/todos?created_from=2025-01-02T00:00:00Z&created_to=2025-01-03T00:00:00Z&tag=home", http.StatusOK, []int64{3}},
+		{"created to and done", "/todos?created_to=2025-01-02T00:00:00Z&done=false", http.StatusOK, []int64{1}},
+		{"invalid created_to", "/todos?created_to=tomorrow", http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskGetter(nil, []*domain.Task{report, done, home}, nil, nil)
+			handler := NewGetAllTasksHandler(logger, mockService, paramutil.PageLimits{})
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Tasks []domain.Task `json:"tasks"`
+			}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			ids := make([]int64, 0, len(response.Tasks))
+			for _, task := range response.Tasks {
+				ids = append(ids, task.ID)
+			}
+			if !slices.Equal(ids, tt.expectedIDs) {
+				t.Errorf("Expected tasks %v, got %v", tt.expectedIDs, ids)
+			}
+		})
+	}
+}
+
+func TestErrorResponsesNotCached(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name           string
+		url            string
+		getErr         error
+		expectedCode   int
+		expectedCache  string
+		expectedPragma string
+	}{
+		{
+			name:         "ok",
+			url:          "/todos/1",
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:           "bad request",
+			url:            "/todos/invalid",
+			expectedCode:   http.StatusBadRequest,
+			expectedCache:  "no-store",
+			expectedPragma: "no-cache",
+		},
+		{
+			name:           "not found",
+			url:            "/todos/1",
+			getErr:         repository.ErrNotFound,
+			expectedCode:   http.StatusNotFound,
+			expectedCache:  "no-store",
+			expectedPragma: "no-cache",
+		},
+		{
+			name:           "server error",
+			url:            "/todos/1",
+			getErr:         errors.New("db error"),
+			expectedCode:   http.StatusInternalServerError,
+			expectedCache:  "no-store",
+			expectedPragma: "no-cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskGetter(domain.NewTask(1, "Test Task", ""), nil, tt.getErr, nil)
+			handler := NewGetTaskHandler(logger, mockService, 0)
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
 			w := httptest.NewRecorder()
 
 			handler(w, req)
@@ -279,16 +698,1095 @@ func TestNewTaskUpdater(t *testing.T) {
 			if w.Code != tt.expectedCode {
 				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
 			}
+			if got := w.Header().Get("Cache-Control"); got != tt.expectedCache {
+				t.Errorf("Expected Cache-Control %q, got %q", tt.expectedCache, got)
+			}
+			if got := w.Header().Get("Pragma"); got != tt.expectedPragma {
+				t.Errorf("Expected Pragma %q, got %q", tt.expectedPragma, got)
+			}
+		})
+	}
+}
 
-			if tt.task != nil {
-				var response map[string]*domain.Task
-				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
-					t.Fatalf("Failed to unmarshal: %v", err)
-				}
+func TestNewGetTaskHandlerLanguage(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
-				if response["task"].Title != tt.task.Title || response["task"].Description != tt.task.Description || response["task"].Done != tt.task.Done {
-					t.Errorf("Expected task %v, got %v", tt.task, response["task"])
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		expectedError  string
+	}{
+		{
+			name:          "english by default",
+			expectedError: "requested resource could not be found",
+		},
+		{
+			name:           "translates to requested language",
+			acceptLanguage: "es-ES,es;q=0.9,en;q=0.8",
+			expectedError:  "no se pudo encontrar el recurso solicitado",
+		},
+		{
+			name:           "falls back to english for unsupported language",
+			acceptLanguage: "de",
+			expectedError:  "requested resource could not be found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskGetter(nil, nil, repository.ErrNotFound, nil)
+			handler := NewGetTaskHandler(logger, mockService, 0)
+
+			req := httptest.NewRequest("GET", "/todos/1", nil)
+			req.SetPathValue("id", "1")
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			var response map[string]string
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			if response["error"] != tt.expectedError {
+				t.Errorf("Expected error '%s', got '%s'", tt.expectedError, response["error"])
+			}
+			if response["code"] != "not_found" {
+				t.Errorf("Expected code 'not_found', got '%s'", response["code"])
+			}
+		})
+	}
+}
+
+func TestNewGetStatsHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("returns stats", func(t *testing.T) {
+		stats := domain.TaskStats{Total: 3, Done: 2, Open: 1, CompletedToday: 1}
+		handler := NewGetStatsHandler(logger, mocks.NewMockTaskStatsGetter(stats, nil))
+
+		req := httptest.NewRequest("GET", "/stats", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]domain.TaskStats
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response["stats"] != stats {
+			t.Errorf("Expected %+v, got %+v", stats, response["stats"])
+		}
+	})
+
+	t.Run("returns server error", func(t *testing.T) {
+		handler := NewGetStatsHandler(logger, mocks.NewMockTaskStatsGetter(domain.TaskStats{}, errors.New("db error")))
+
+		req := httptest.NewRequest("GET", "/stats", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestNewGetDailyStatsHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		url          string
+		statsErr     error
+		expectedCode int
+		expectedDays int
+	}{
+		{name: "default days", url: "/stats/daily", expectedCode: http.StatusOK, expectedDays: 7},
+		{name: "custom days", url: "/stats/daily?days=30", expectedCode: http.StatusOK, expectedDays: 30},
+		{name: "zero days", url: "/stats/daily?days=0", expectedCode: http.StatusBadRequest},
+		{name: "too many days", url: "/stats/daily?days=367", expectedCode: http.StatusBadRequest},
+		{name: "invalid days", url: "/stats/daily?days=week", expectedCode: http.StatusBadRequest},
+		{name: "server error", url: "/stats/daily", statsErr: errors.New("db error"), expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGetDailyStatsHandler(logger, mocks.NewMockDailyStatsGetter(tt.statsErr))
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest("GET", tt.url, nil))
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response map[string][]domain.DailyStats
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+			if len(response["days"]) != tt.expectedDays {
+				t.Errorf("Expected %d days, got %d", tt.expectedDays, len(response["days"]))
+			}
+		})
+	}
+}
+
+func TestNewGetOpenCountHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("returns open count", func(t *testing.T) {
+		handler := NewGetOpenCountHandler(logger, mocks.NewMockOpenTaskCounter(3, nil))
+
+		req := httptest.NewRequest("GET", "/todos/open/count", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]int
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+
+		if response["open"] != 3 {
+			t.Errorf("Expected 3 open tasks, got %d", response["open"])
+		}
+	})
+
+	t.Run("returns server error", func(t *testing.T) {
+		handler := NewGetOpenCountHandler(logger, mocks.NewMockOpenTaskCounter(0, errors.New("db error")))
+
+		req := httptest.NewRequest("GET", "/todos/open/count", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestNewGetTaskBoardHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	report := domain.NewTask(1, "Write report", "Description")
+	report.Priority = domain.PriorityHigh
+	report.Tags = []string{"work"}
+
+	done := domain.NewTask(2, "Send report", "Description")
+	done.Tags = []string{"work"}
+	done.Done = true
+
+	home := domain.NewTask(3, "Clean", "Description")
+	home.Tags = []string{"home"}
+
+	tests := []struct {
+		name         string
+		url          string
+		err          error
+		expectedCode int
+		expectedOpen []int64
+		expectedDone []int64
+	}{
+		{name: "no filters", url: "/todos/board", expectedCode: http.StatusOK, expectedOpen: []int64{1, 3}, expectedDone: []int64{2}},
+		{name: "tag", url: "/todos/board?tag=work", expectedCode: http.StatusOK, expectedOpen: []int64{1}, expectedDone: []int64{2}},
+		{name: "priority", url: "/todos/board?priority=high", expectedCode: http.StatusOK, expectedOpen: []int64{1}, expectedDone: []int64{}},
+		{name: "invalid priority", url: "/todos/board?priority=urgent", expectedCode: http.StatusBadRequest},
+		{name: "server error", url: "/todos/board", err: errors.New("db error"), expectedCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGetTaskBoardHandler(logger, mocks.NewMockTaskBoardGetter([]*domain.Task{report, done, home}, tt.err))
+
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response map[string][]domain.Task
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			ids := func(tasks []domain.Task) []int64 {
+				ids := make([]int64, 0, len(tasks))
+				for _, task := range tasks {
+					ids = append(ids, task.ID)
 				}
+				return ids
+			}
+			if got := ids(response["open"]); !slices.Equal(got, tt.expectedOpen) {
+				t.Errorf("Expected open tasks %v, got %v", tt.expectedOpen, got)
+			}
+			if got := ids(response["done"]); !slices.Equal(got, tt.expectedDone) {
+				t.Errorf("Expected done tasks %v, got %v", tt.expectedDone, got)
+			}
+		})
+	}
+}
+
+func TestNewGetTasksDueTodayHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("returns tasks due today", func(t *testing.T) {
+		tasks := []*domain.Task{domain.NewTask(1, "Task 1", ""), domain.NewTask(2, "Task 2", "")}
+		handler := NewGetTasksDueTodayHandler(logger, mocks.NewMockTasksDueTodayGetter(tasks, nil))
+
+		req := httptest.NewRequest("GET", "/todos/today", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string][]domain.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+
+		if len(response["tasks"]) != 2 {
+			t.Errorf("Expected 2 tasks, got %d", len(response["tasks"]))
+		}
+	})
+
+	t.Run("returns server error", func(t *testing.T) {
+		handler := NewGetTasksDueTodayHandler(logger, mocks.NewMockTasksDueTodayGetter(nil, errors.New("db error")))
+
+		req := httptest.NewRequest("GET", "/todos/today", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestNewGetNextTaskHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		task         *domain.Task
+		err          error
+		expectedCode int
+	}{
+		{
+			name:         "returns next task",
+			task:         domain.NewTask(2, "Next", ""),
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "returns not found when all tasks are done",
+			err:          repository.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "returns server error",
+			err:          errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewGetNextTaskHandler(logger, mocks.NewMockNextTaskGetter(tt.task, tt.err))
+
+			req := httptest.NewRequest("GET", "/todos/next", nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+
+			if tt.task != nil {
+				var response map[string]domain.Task
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal: %v", err)
+				}
+
+				if response["task"].ID != tt.task.ID {
+					t.Errorf("Expected task %d, got %d", tt.task.ID, response["task"].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestNewPostTaskHandlerWithoutMetrics(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	// metrics.InitMetrics is not called in handlers tests
+	handler := NewPostTaskHandler(logger, mocks.NewMockTaskCreator(nil))
+
+	req := httptest.NewRequest("POST", "/todos", strings.NewReader(`{"id":1,"title":"Task"}`))
+	w := httptest.NewRecorder()
+
+	handler(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestNewPostTaskHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		input        dto.CreateTaskInput
+		createErr    error
+		expectedCode int
+	}{
+		{
+			name: "creates task successfully",
+			input: dto.CreateTaskInput{
+				ID:          1,
+				Title:       "New Task",
+				Description: "New Description",
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "returns conflict for duplicate task",
+			input: dto.CreateTaskInput{
+				ID:          1,
+				Title:       "Duplicate",
+				Description: "Duplicate",
+			},
+			createErr:    service.ErrTaskExists,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "returns conflict for duplicate title",
+			input: dto.CreateTaskInput{
+				ID:          2,
+				Title:       "Duplicate",
+				Description: "Duplicate",
+			},
+			createErr:    service.ErrDuplicateTitle,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name: "failed validation for task",
+			input: dto.CreateTaskInput{
+				ID:          -1,
+				Description: "Duplicate",
+			},
+			createErr:    validator.New(),
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskCreator(tt.createErr)
+			handler := NewPostTaskHandler(logger, mockService)
+
+			body, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to marshal to JSON: %v", err)
+			}
+
+			req := httptest.NewRequest("POST", "/todos", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+
+	t.Run("translates validation errors", func(t *testing.T) {
+		v := validator.New()
+		v.CheckCode(false, "title", "too_long", "must not be more than %d symbols long", 100)
+		v.AddError("other", "message without code")
+
+		mockService := mocks.NewMockTaskCreator(v)
+		handler := NewPostTaskHandler(logger, mockService)
+
+		body, err := json.Marshal(dto.CreateTaskInput{ID: 1, Title: "Title"})
+		if err != nil {
+			t.Fatalf("Failed to marshal to JSON: %v", err)
+		}
+
+		req := httptest.NewRequest("POST", "/todos", bytes.NewReader(body))
+		req.Header.Set("Accept-Language", "es")
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		var response struct {
+			Error map[string]string `json:"error"`
+		}
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response.Error["title"] != "no debe tener más de 100 símbolos" {
+			t.Errorf("Unexpected title error: '%s'", response.Error["title"])
+		}
+		if response.Error["other"] != "message without code" {
+			t.Errorf("Unexpected other error: '%s'", response.Error["other"])
+		}
+	})
+
+	t.Run("returns bad request for invalid JSON", func(t *testing.T) {
+		mockService := mocks.NewMockTaskCreator(nil)
+		handler := NewPostTaskHandler(logger, mockService)
+
+		req := httptest.NewRequest("POST", "/todos", bytes.NewReader([]byte("invalid")))
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+
+	t.Run("rejects oversized Content-Length without reading the body", func(t *testing.T) {
+		mockService := mocks.NewMockTaskCreator(nil)
+		handler := NewPostTaskHandler(logger, mockService)
+
+		body := &countingReader{Reader: strings.NewReader(`{"id":1,"title":"Task"}`)}
+		req := httptest.NewRequest("POST", "/todos", body)
+		req.ContentLength = 2 << 20
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status %d, got %d", http.StatusRequestEntityTooLarge, w.Code)
+		}
+		if body.n != 0 {
+			t.Errorf("Expected body not to be read, got %d bytes read", body.n)
+		}
+		if !strings.Contains(w.Body.String(), `"body_too_large"`) {
+			t.Errorf("Expected body_too_large code, got %s", w.Body.String())
+		}
+	})
+}
+
+// countingReader counts the bytes read from Reader.
+type countingReader struct {
+	io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestNewTaskUpdater(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		task         *domain.Task
+		input        dto.UpdateTaskInput
+		updateErr    error
+		expectedCode int
+		url          string
+	}{
+		{
+			name: "updates task successfully",
+			task: domain.NewTask(1, "Updated", "Updated Description"),
+			input: dto.UpdateTaskInput{
+				Title:       "Updated",
+				Description: "Updated Description",
+				Done:        true,
+			},
+			expectedCode: http.StatusOK,
+			url:          "/todos/1",
+		},
+		{
+			name:         "returns bad request for invalid ID",
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/invalid",
+		},
+		{
+			name:         "returns not found for missing task",
+			expectedCode: http.StatusNotFound,
+			updateErr:    repository.ErrNotFound,
+			url:          "/todos/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskUpdater(tt.task, tt.updateErr)
+			handler := NewTaskUpdater(logger, mockService)
+
+			body, err := json.Marshal(tt.input)
+			if err != nil {
+				t.Fatalf("Failed to marshal to JSON: %v", err)
+			}
+
+			req := httptest.NewRequest("PUT", tt.url, bytes.NewReader(body))
+			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+
+			if tt.task != nil {
+				var response map[string]*domain.Task
+				if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+					t.Fatalf("Failed to unmarshal: %v", err)
+				}
+
+				if response["task"].Title != tt.task.Title || response["task"].Description != tt.task.Description || response["task"].Done != tt.task.Done {
+					t.Errorf("Expected task %v, got %v", tt.task, response["task"])
+				}
+			}
+		})
+	}
+
+	t.Run("upsert", func(t *testing.T) {
+		tests := []struct {
+			name         string
+			url          string
+			updateErr    error
+			expectedCode int
+		}{
+			{
+				name:         "creates missing task",
+				url:          "/todos/7?upsert=true",
+				updateErr:    repository.ErrNotFound,
+				expectedCode: http.StatusCreated,
+			},
+			{
+				name:         "replaces existing task",
+				url:          "/todos/7?upsert=true",
+				expectedCode: http.StatusOK,
+			},
+			{
+				name:         "returns not found without upsert",
+				url:          "/todos/7",
+				updateErr:    repository.ErrNotFound,
+				expectedCode: http.StatusNotFound,
+			},
+			{
+				name:         "returns bad request for invalid upsert value",
+				url:          "/todos/7?upsert=maybe",
+				expectedCode: http.StatusBadRequest,
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				mockService := mocks.NewMockTaskUpdater(domain.NewTask(7, "Old", ""), tt.updateErr)
+				handler := NewTaskUpdater(logger, mockService)
+
+				req := httptest.NewRequest("PUT", tt.url, strings.NewReader(`{"title":"Task"}`))
+				req.SetPathValue("id", "7")
+				w := httptest.NewRecorder()
+
+				handler(w, req)
+
+				if w.Code != tt.expectedCode {
+					t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+				}
+
+				if w.Code == http.StatusOK || w.Code == http.StatusCreated {
+					var response map[string]domain.Task
+					if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+						t.Fatalf("Failed to unmarshal: %v", err)
+					}
+					if response["task"].ID != 7 || response["task"].Title != "Task" {
+						t.Errorf("Unexpected task: %+v", response["task"])
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("rejects unknown fields", func(t *testing.T) {
+		mockService := mocks.NewMockTaskUpdater(domain.NewTask(1, "Updated", ""), nil)
+		handler := NewTaskUpdater(logger, mockService)
+
+		body := `{"title":"Updated","client_color":"red"}`
+		req := httptest.NewRequest("PUT", "/todos/1", strings.NewReader(body))
+		req.SetPathValue("id", "1")
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+		}
+	})
+}
+
+func TestNewCompleteTasksHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("returns number of completed tasks", func(t *testing.T) {
+		mockService := mocks.NewMockTaskCompleter(2, nil)
+		handler := NewCompleteTasksHandler(logger, mockService)
+
+		req := httptest.NewRequest("POST", "/todos/done?tag=work", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]int
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response["completed"] != 2 {
+			t.Errorf("Expected 2 completed, got %d", response["completed"])
+		}
+		if mockService.Tag() != "work" {
+			t.Errorf("Expected tag 'work', got '%s'", mockService.Tag())
+		}
+	})
+
+	t.Run("completes all tasks with all=true", func(t *testing.T) {
+		mockService := mocks.NewMockTaskCompleter(3, nil)
+		handler := NewCompleteTasksHandler(logger, mockService)
+
+		req := httptest.NewRequest("POST", "/todos/done?all=true", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		if mockService.Tag() != "" {
+			t.Errorf("Expected empty tag, got '%s'", mockService.Tag())
+		}
+	})
+
+	for _, url := range []string{"/todos/done", "/todos/done?tag=", "/todos/done?all=false", "/todos/done?all=yes"} {
+		t.Run("rejects "+url, func(t *testing.T) {
+			mockService := mocks.NewMockTaskCompleter(3, nil)
+			handler := NewCompleteTasksHandler(logger, mockService)
+
+			req := httptest.NewRequest("POST", url, nil)
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+			}
+		})
+	}
+
+	t.Run("returns server error", func(t *testing.T) {
+		handler := NewCompleteTasksHandler(logger, mocks.NewMockTaskCompleter(0, errors.New("db error")))
+
+		req := httptest.NewRequest("POST", "/todos/done?all=true", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestNewBulkStatusHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	v := validator.New()
+	v.AddError("ids", "must be provided")
+
+	results := []domain.BulkResult{
+		{ID: 1, Status: domain.BulkUpdated},
+		{ID: 2, Status: domain.BulkNotFound},
+		{ID: 3, Status: domain.BulkConflict, Errors: map[string]string{"done": "cannot modify a completed task"}},
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		updateErr      error
+		expectedStatus int
+	}{
+		{
+			name:           "returns per-id results",
+			body:           `{"ids":[1,2,3],"done":true}`,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "invalid json",
+			body:           `{"ids":`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "validation error",
+			body:           `{"ids":[],"done":true}`,
+			updateErr:      v,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "server error",
+			body:           `{"ids":[1],"done":true}`,
+			updateErr:      errors.New("db error"),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewBulkStatusHandler(logger, mocks.NewMockTaskStatusBulkUpdater(results, tt.updateErr))
+
+			req := httptest.NewRequest("PATCH", "/todos", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedStatus {
+				t.Fatalf("Expected status %d, got %d", tt.expectedStatus, w.Code)
+			}
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var response struct {
+				Results []domain.BulkResult `json:"results"`
+			}
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response: %v", err)
+			}
+
+			if !reflect.DeepEqual(response.Results, results) {
+				t.Errorf("Expected results %+v, got %+v", results, response.Results)
+			}
+		})
+	}
+}
+
+func TestNewDeleteCompletedTasksHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("returns number of deleted tasks", func(t *testing.T) {
+		handler := NewDeleteCompletedTasksHandler(logger, mocks.NewMockCompletedTaskDeleter(3, nil))
+
+		req := httptest.NewRequest("DELETE", "/todos/completed", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+
+		var response map[string]int
+		err := json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal response: %v", err)
+		}
+
+		if response["deleted"] != 3 {
+			t.Errorf("Expected 3 deleted, got %d", response["deleted"])
+		}
+	})
+
+	t.Run("returns server error", func(t *testing.T) {
+		handler := NewDeleteCompletedTasksHandler(logger, mocks.NewMockCompletedTaskDeleter(0, errors.New("db error")))
+
+		req := httptest.NewRequest("DELETE", "/todos/completed", nil)
+		w := httptest.NewRecorder()
+
+		handler(w, req)
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, w.Code)
+		}
+	})
+}
+
+func TestNewDuplicateTaskHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		duplicateErr error
+		expectedCode int
+		url          string
+	}{
+		{
+			name:         "duplicates task successfully",
+			expectedCode: http.StatusCreated,
+			url:          "/todos/1/duplicate",
+		},
+		{
+			name:         "returns bad request for invalid ID",
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/invalid/duplicate",
+		},
+		{
+			name:         "returns not found for missing task",
+			duplicateErr: repository.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			url:          "/todos/1/duplicate",
+		},
+		{
+			name:         "returns bad request for failed validation",
+			duplicateErr: validator.New(),
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/1/duplicate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskDuplicator(domain.NewTask(2, "Task (copy)", "Description"), tt.duplicateErr)
+			handler := NewDuplicateTaskHandler(logger, mockService)
+
+			req := httptest.NewRequest("POST", tt.url, nil)
+			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestNewPatchTaskHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		patchErr     error
+		expectedCode int
+		url          string
+	}{
+		{
+			name:         "patches task successfully",
+			contentType:  "application/merge-patch+json",
+			body:         `{"done":true}`,
+			expectedCode: http.StatusOK,
+			url:          "/todos/1",
+		},
+		{
+			name:         "returns unsupported media type for plain JSON",
+			contentType:  "application/json",
+			body:         `{"done":true}`,
+			expectedCode: http.StatusUnsupportedMediaType,
+			url:          "/todos/1",
+		},
+		{
+			name:         "returns bad request for invalid ID",
+			contentType:  "application/merge-patch+json",
+			body:         `{"done":true}`,
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/invalid",
+		},
+		{
+			name:         "returns bad request for malformed JSON",
+			contentType:  "application/merge-patch+json",
+			body:         `{"done":`,
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/1",
+		},
+		{
+			name:         "returns bad request for invalid patch",
+			contentType:  "application/merge-patch+json",
+			body:         `{"id":2}`,
+			patchErr:     service.ErrInvalidPatch,
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/1",
+		},
+		{
+			name:         "returns bad request for failed validation",
+			contentType:  "application/merge-patch+json",
+			body:         `{"title":null}`,
+			patchErr:     validator.New(),
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/1",
+		},
+		{
+			name:         "returns not found for missing task",
+			contentType:  "application/merge-patch+json",
+			body:         `{"done":true}`,
+			patchErr:     repository.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			url:          "/todos/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskPatcher(domain.NewTask(1, "Task", "Description"), tt.patchErr)
+			handler := NewPatchTaskHandler(logger, mockService, false)
+
+			req := httptest.NewRequest("PATCH", tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestNewPatchTaskHandlerIfMatch(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	patched := domain.NewTask(1, "Task", "Description")
+	patched.Version = 3
+
+	tests := []struct {
+		name           string
+		ifMatch        string
+		requireIfMatch bool
+		patchErr       error
+		expectedCode   int
+	}{
+		{"matching ETag", `"2"`, true, nil, http.StatusOK},
+		{"any ETag", "*", true, nil, http.StatusOK},
+		{"mismatched ETag", `"1"`, true, service.ErrVersionMismatch, http.StatusPreconditionFailed},
+		{"weak ETag", `W/"2"`, true, nil, http.StatusPreconditionFailed},
+		{"malformed ETag", "2", true, nil, http.StatusPreconditionFailed},
+		{"missing If-Match", "", true, nil, http.StatusPreconditionRequired},
+		{"missing If-Match when not required", "", false, nil, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewPatchTaskHandler(logger, mocks.NewMockTaskPatcher(patched, tt.patchErr), tt.requireIfMatch)
+
+			req := httptest.NewRequest("PATCH", "/todos/1", strings.NewReader(`{"done":true}`))
+			req.Header.Set("Content-Type", "application/merge-patch+json")
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req.SetPathValue("id", "1")
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if tt.expectedCode == http.StatusOK && w.Header().Get("ETag") != `"3"` {
+				t.Errorf("Expected ETag of the patched task, got '%s'", w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestNewMoveTaskHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name         string
+		body         string
+		moveErr      error
+		expectedCode int
+		url          string
+	}{
+		{
+			name:         "moves task successfully",
+			body:         `{"position":2}`,
+			expectedCode: http.StatusOK,
+			url:          "/todos/1/move",
+		},
+		{
+			name:         "returns bad request for invalid ID",
+			body:         `{"position":2}`,
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/invalid/move",
+		},
+		{
+			name:         "returns bad request for invalid body",
+			body:         `{"position":"first"}`,
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/1/move",
+		},
+		{
+			name:         "returns not found for missing task",
+			body:         `{"position":2}`,
+			moveErr:      repository.ErrNotFound,
+			expectedCode: http.StatusNotFound,
+			url:          "/todos/1/move",
+		},
+		{
+			name:         "returns bad request for invalid position",
+			body:         `{"position":0}`,
+			moveErr:      validator.New(),
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/1/move",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := mocks.NewMockTaskMover(domain.NewTask(1, "Task", "Description"), tt.moveErr)
+			handler := NewMoveTaskHandler(logger, mockService)
+
+			req := httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body))
+			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
 			}
 		})
 	}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/vladgrskkh/todo/internal/apierrors"
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/paramutil"
+	"github.com/vladgrskkh/todo/internal/repository"
+	s "github.com/vladgrskkh/todo/internal/service"
+	"github.com/vladgrskkh/todo/pkg/jsonhttp"
+)
+
+type TrashLister interface {
+	GetTrash() ([]*domain.Task, error)
+}
+
+// NewGetTrashHandler returns the deleted tasks kept in the trash sorted by id,
+// with deleted_at set. The trash is empty if it is disabled.
+func NewGetTrashHandler(logger *slog.Logger, service TrashLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tasks, err := service.GetTrash()
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"tasks": tasks}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
+
+type TrashRestorer interface {
+	RestoreTask(id int64) (*domain.Task, error)
+}
+
+// NewRestoreTaskHandler moves the task back from the trash. 404 is returned for tasks
+// not in the trash(e.g. already purged), 409 if a task with the same id was created since.
+func NewRestoreTaskHandler(logger *slog.Logger, service TrashRestorer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := paramutil.ReadIDParam(r)
+		if err != nil {
+			apierrors.BadRequestResponse(logger, w, r, err)
+			return
+		}
+
+		task, err := service.RestoreTask(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, s.ErrInvalidID):
+				apierrors.BadRequestResponse(logger, w, r, err)
+			case errors.Is(err, repository.ErrNotFound):
+				apierrors.NotFoundResponse(logger, w, r)
+			case errors.Is(err, s.ErrTaskExists):
+				apierrors.DuplicateTaskResponse(logger, w, r)
+			default:
+				apierrors.ServerErrorResponse(logger, w, r, err)
+			}
+
+			return
+		}
+
+		err = jsonhttp.WriteJSON(w, http.StatusOK, jsonhttp.Envelope{"task": task}, nil)
+		if err != nil {
+			apierrors.ServerErrorResponse(logger, w, r, err)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/handlers/mocks"
+	s "github.com/vladgrskkh/todo/internal/service"
+)
+
+func TestNewGetTrashHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	deletedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	task := domain.NewTask(1, "Task", "Description")
+	task.DeletedAt = &deletedAt
+
+	handler := NewGetTrashHandler(logger, mocks.NewMockTrashStore([]*domain.Task{task}, nil))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/todos/trash", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"deleted_at": "2025-01-01T12:00:00Z"`) {
+		t.Errorf("Expected deleted_at in response, got %s", w.Body.String())
+	}
+}
+
+func TestNewRestoreTaskHandler(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	deletedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	task := domain.NewTask(1, "Task", "Description")
+	task.DeletedAt = &deletedAt
+
+	tests := []struct {
+		name         string
+		restoreErr   error
+		expectedCode int
+		url          string
+	}{
+		{
+			name:         "restores task successfully",
+			expectedCode: http.StatusOK,
+			url:          "/todos/1/restore",
+		},
+		{
+			name:         "returns bad request for invalid ID",
+			expectedCode: http.StatusBadRequest,
+			url:          "/todos/invalid/restore",
+		},
+		{
+			name:         "returns not found for task not in trash",
+			expectedCode: http.StatusNotFound,
+			url:          "/todos/2/restore",
+		},
+		{
+			name:         "returns conflict for taken id",
+			restoreErr:   s.ErrTaskExists,
+			expectedCode: http.StatusConflict,
+			url:          "/todos/1/restore",
+		},
+		{
+			name:         "returns server error",
+			restoreErr:   errors.New("db error"),
+			expectedCode: http.StatusInternalServerError,
+			url:          "/todos/1/restore",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewRestoreTaskHandler(logger, mocks.NewMockTrashStore([]*domain.Task{task}, tt.restoreErr))
+
+			req := httptest.NewRequest("POST", tt.url, nil)
+			req.SetPathValue("id", strings.Split(tt.url, "/")[2])
+			w := httptest.NewRecorder()
+
+			handler(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+			if w.Code == http.StatusOK && strings.Contains(w.Body.String(), "deleted_at") {
+				t.Errorf("Expected deleted_at to be cleared, got %s", w.Body.String())
+			}
+		})
+	}
+}
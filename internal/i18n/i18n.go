@@ -0,0 +1,117 @@
+// Package i18n translates error messages identified by error codes
+// into the language requested with the Accept-Language header.
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when the request has no supported language.
+// Messages in the default language are produced by the code itself, so the
+// catalog only holds translations.
+const DefaultLanguage = "en"
+
+// catalog maps language to error code to message format.
+var catalog = map[string]map[string]string{
+	"es": {
+		// apierrors
+		"internal_error":        "el servidor encontró un problema y no pudo procesar su solicitud",
+		"data_corrupt":          "los datos almacenados están dañados y no se pudieron leer",
+		"not_found":             "no se pudo encontrar el recurso solicitado",
+		"task_exists":           "ya existe una tarea con este id",
+		"duplicate_title":       "ya existe una tarea abierta con este título",
+		"service_unavailable":   "el servidor está ocupado, vuelva a intentarlo más tarde",
+		"request_timeout":       "la solicitud tardó demasiado en procesarse",
+		"rate_limited":          "límite de solicitudes excedido",
+		"unauthorized":          "token de autenticación inválido o ausente",
+		"empty_body":            "el cuerpo no debe estar vacío",
+		"precondition_failed":   "el recurso fue modificado, If-Match no coincide con su ETag actual",
+		"precondition_required": "la solicitud debe ser condicional, se requiere el encabezado If-Match",
+
+		// validation
+		"required":           "debe ser proporcionado",
+		"positive_integer":   "debe ser un número entero positivo",
+		"too_long":           "no debe tener más de %d símbolos",
+		"too_many_items":     "no debe contener más de %d elementos",
+		"item_required":      "cada elemento debe ser proporcionado",
+		"item_too_long":      "cada elemento no debe tener más de %d símbolos",
+		"item_control_chars": "ningún elemento debe contener caracteres de control",
+		"task_completed":     "no se puede modificar una tarea completada",
+		"control_chars":      "no debe contener caracteres de control",
+		"permitted_value":    "debe ser uno de: %s",
+		"greater_than_zero":  "debe ser mayor que cero",
+		"item_duplicate":     "no debe contener elementos duplicados",
+	},
+}
+
+// Language returns the best supported language from the request Accept-Language
+// header(e.g. "es-ES,es;q=0.9,en;q=0.8"), or DefaultLanguage.
+func Language(r *http.Request) string {
+	header := r.Header.Get("Accept-Language")
+	if header == "" {
+		return DefaultLanguage
+	}
+
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var langs []weighted
+	for part := range strings.SplitSeq(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		// only primary language subtag is used(es-ES -> es)
+		lang, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		langs = append(langs, weighted{lang: lang, q: q})
+	}
+
+	slices.SortStableFunc(langs, func(a, b weighted) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	for _, l := range langs {
+		if l.q <= 0 {
+			continue
+		}
+		if l.lang == DefaultLanguage {
+			return DefaultLanguage
+		}
+		if _, ok := catalog[l.lang]; ok {
+			return l.lang
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// Translate returns the message for the code in the given language formatted with args.
+// Returns fallback if there is no translation.
+func Translate(lang, code, fallback string, args ...any) string {
+	format, ok := catalog[lang][code]
+	if !ok {
+		return fallback
+	}
+
+	return fmt.Sprintf(format, args...)
+}
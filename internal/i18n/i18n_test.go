@@ -0,0 +1,61 @@
+package i18n
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLanguage(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{"no header", "", "en"},
+		{"supported language", "es", "es"},
+		{"language with region", "es-ES", "es"},
+		{"unknown language falls back", "fr", "en"},
+		{"picks highest quality", "fr;q=0.9,es;q=0.8,en;q=0.5", "es"},
+		{"prefers english by quality", "es;q=0.5,en", "en"},
+		{"ignores zero quality", "es;q=0", "en"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Accept-Language", tt.header)
+			}
+
+			if got := Language(req); got != tt.expected {
+				t.Errorf("Expected language '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	t.Run("translates with args", func(t *testing.T) {
+		got := Translate("es", "too_long", "must not be more than 100 symbols long", 100)
+
+		if got != "no debe tener más de 100 símbolos" {
+			t.Errorf("Unexpected translation: '%s'", got)
+		}
+	})
+
+	t.Run("returns fallback for unknown code", func(t *testing.T) {
+		got := Translate("es", "unknown", "fallback")
+
+		if got != "fallback" {
+			t.Errorf("Expected 'fallback', got '%s'", got)
+		}
+	})
+
+	t.Run("returns fallback for default language", func(t *testing.T) {
+		got := Translate("en", "not_found", "requested resource could not be found")
+
+		if got != "requested resource could not be found" {
+			t.Errorf("Unexpected message: '%s'", got)
+		}
+	})
+}
@@ -2,21 +2,28 @@ package integrationtest
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/gob"
 	"encoding/json"
+	"expvar"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
+	"time"
 
+	"github.com/vladgrskkh/todo/config"
 	"github.com/vladgrskkh/todo/internal/domain"
 	"github.com/vladgrskkh/todo/internal/handlers/dto"
 	"github.com/vladgrskkh/todo/internal/handlers/middleware/metrics"
 	"github.com/vladgrskkh/todo/internal/handlers/routes"
 	"github.com/vladgrskkh/todo/internal/repository"
 	"github.com/vladgrskkh/todo/internal/service"
+	"github.com/vladgrskkh/todo/internal/testutil"
 	"github.com/vladgrskkh/todo/pkg/inmemorydb"
 )
 
@@ -54,7 +61,7 @@ func TestIntegrationFullTaskWorkflow(t *testing.T) {
 	defer cleanup()
 
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
-	handler := routes.Routes(logger, s, "test", "1.0.0")
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", Version: "1.0.0"})
 
 	t.Run("complete task lifecycle", func(t *testing.T) {
 		// Create a task
@@ -174,7 +181,7 @@ func TestIntegrationMultipleTasks(t *testing.T) {
 	defer cleanup()
 
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
-	handler := routes.Routes(logger, s, "test", "1.0.0")
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", Version: "1.0.0"})
 
 	t.Run("create and manage multiple tasks", func(t *testing.T) {
 		// Create multiple tasks
@@ -281,7 +288,7 @@ func TestIntegrationErrorScenarios(t *testing.T) {
 	defer cleanup()
 
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
-	handler := routes.Routes(logger, s, "test", "1.0.0")
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", Version: "1.0.0"})
 
 	t.Run("duplicate ID error", func(t *testing.T) {
 		// Create first task
@@ -380,3 +387,624 @@ func TestIntegrationErrorScenarios(t *testing.T) {
 		}
 	})
 }
+
+func TestIntegrationIdempotentCreate(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", Version: "1.0.0"})
+
+	t.Run("same idempotency key yields one task", func(t *testing.T) {
+		body, _ := json.Marshal(dto.CreateTaskInput{ID: 1, Title: "Task", Description: "Description"})
+
+		var responses []string
+		for range 2 {
+			req := httptest.NewRequest("POST", "/todos", bytes.NewReader(body))
+			req.Header.Set("Idempotency-Key", "retry-key")
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+			}
+
+			responses = append(responses, w.Body.String())
+		}
+
+		if responses[0] != responses[1] {
+			t.Errorf("Expected identical responses, got %q and %q", responses[0], responses[1])
+		}
+
+		req := httptest.NewRequest("GET", "/todos", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var response map[string][]domain.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if len(response["tasks"]) != 1 {
+			t.Errorf("Expected 1 task, got %d", len(response["tasks"]))
+		}
+	})
+}
+
+func TestIntegrationCreatedBetween(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", Version: "1.0.0"})
+
+	body, _ := json.Marshal(dto.CreateTaskInput{ID: 1, Title: "Task", Description: "Description"})
+	req := httptest.NewRequest("POST", "/todos", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name          string
+		query         string
+		expectedCode  int
+		expectedCount int
+	}{
+		{
+			name:          "returns tasks created in range",
+			query:         "?created_from=" + past + "&created_to=" + future,
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "returns tasks created after from",
+			query:         "?created_from=" + past,
+			expectedCode:  http.StatusOK,
+			expectedCount: 1,
+		},
+		{
+			name:          "excludes tasks created after to",
+			query:         "?created_to=" + past,
+			expectedCode:  http.StatusOK,
+			expectedCount: 0,
+		},
+		{
+			name:         "returns bad request for inverted range",
+			query:        "?created_from=" + future + "&created_to=" + past,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "returns bad request for invalid timestamp",
+			query:        "?created_from=yesterday",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/todos"+tt.query, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Fatalf("Expected status %d, got %d. Body: %s", tt.expectedCode, w.Code, w.Body.String())
+			}
+
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response map[string][]domain.Task
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Failed to unmarshal: %v", err)
+			}
+			if len(response["tasks"]) != tt.expectedCount {
+				t.Errorf("Expected %d tasks, got %d", tt.expectedCount, len(response["tasks"]))
+			}
+		})
+	}
+}
+
+func TestIntegrationTaskVersion(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", Version: "1.0.0"})
+
+	t.Run("client-sent version is ignored on create", func(t *testing.T) {
+		body := []byte(`{"id":1,"title":"Task","description":"Description","version":42}`)
+		req := httptest.NewRequest("POST", "/todos", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+
+		var response map[string]domain.Task
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if response["task"].Version != 1 {
+			t.Errorf("Expected version 1, got %d", response["task"].Version)
+		}
+	})
+
+	t.Run("GET returns version incremented by update", func(t *testing.T) {
+		updateBody, _ := json.Marshal(dto.UpdateTaskInput{Title: "Updated", Description: "Description"})
+		updateReq := httptest.NewRequest("PUT", "/todos/1", bytes.NewReader(updateBody))
+		updateW := httptest.NewRecorder()
+		handler.ServeHTTP(updateW, updateReq)
+
+		if updateW.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, updateW.Code)
+		}
+
+		req := httptest.NewRequest("GET", "/todos/1", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var response map[string]map[string]any
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if response["task"]["version"] != float64(2) {
+			t.Errorf("Expected version 2, got %v", response["task"]["version"])
+		}
+	})
+}
+
+func TestIntegrationTags(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", Version: "1.0.0"})
+
+	tasks := []dto.CreateTaskInput{
+		{ID: 1, Title: "Task 1", Tags: []string{"work"}},
+		{ID: 2, Title: "Task 2", Tags: []string{"work", "home"}},
+		{ID: 3, Title: "Task 3"},
+	}
+	for _, task := range tasks {
+		body, _ := json.Marshal(task)
+		req := httptest.NewRequest("POST", "/todos", bytes.NewReader(body))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Failed to create task %d: %d", task.ID, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/todos/tags", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response map[string][]domain.TagCount
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+
+	expected := []domain.TagCount{{Name: "work", Count: 2}, {Name: "home", Count: 1}}
+	if len(response["tags"]) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, response["tags"])
+	}
+	for i := range expected {
+		if response["tags"][i] != expected[i] {
+			t.Errorf("Expected %v at position %d, got %v", expected[i], i, response["tags"][i])
+		}
+	}
+}
+
+func TestIntegrationDebugStats(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("requires admin token", func(t *testing.T) {
+		handler := routes.Routes(logger, s, &config.Config{AdminToken: "secret"})
+
+		req := httptest.NewRequest("GET", "/debug/stats", nil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+		}
+
+		req = httptest.NewRequest("GET", "/debug/stats", nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("is disabled without admin token", func(t *testing.T) {
+		handler := routes.Routes(logger, s, &config.Config{})
+
+		req := httptest.NewRequest("GET", "/debug/stats", nil)
+		req.Header.Set("Authorization", "Bearer ")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status %d, got %d", http.StatusNotFound, w.Code)
+		}
+	})
+}
+
+func TestIntegrationBasePath(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	handler := routes.Routes(logger, s, &config.Config{BasePath: "/v1"})
+
+	body, _ := json.Marshal(dto.CreateTaskInput{ID: 1, Title: "Task"})
+	req := httptest.NewRequest("POST", "/v1/todos", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		url          string
+		expectedCode int
+	}{
+		{"/v1/todos", http.StatusOK},
+		{"/v1/todos/1", http.StatusOK},
+		{"/v1/todos/tags", http.StatusOK},
+		{"/v1/todos.csv", http.StatusOK},
+		{"/v1/todos/", http.StatusOK},
+		{"/v1/todos/1/", http.StatusOK},
+		{"/todos", http.StatusNotFound},
+		{"/todos/1", http.StatusNotFound},
+		{"/healthcheck", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != tt.expectedCode {
+				t.Errorf("Expected status %d, got %d", tt.expectedCode, w.Code)
+			}
+		})
+	}
+}
+
+func TestIntegrationValidationErrorMetrics(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", Version: "1.0.0"})
+
+	validationErrors, ok := expvar.Get("validation_errors").(*expvar.Map)
+	if !ok {
+		t.Fatal("Expected validation_errors to be published")
+	}
+
+	count := func(field string) int64 {
+		v, ok := validationErrors.Get(field).(*expvar.Int)
+		if !ok {
+			return 0
+		}
+		return v.Value()
+	}
+
+	idBefore, titleBefore := count("id"), count("title")
+
+	body, _ := json.Marshal(dto.CreateTaskInput{ID: -1, Title: ""})
+	req := httptest.NewRequest("POST", "/todos", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	if got := count("id") - idBefore; got != 1 {
+		t.Errorf("Expected id counter to increase by 1, got %d", got)
+	}
+	if got := count("title") - titleBefore; got != 1 {
+		t.Errorf("Expected title counter to increase by 1, got %d", got)
+	}
+}
+
+// blockingKV blocks reads and deletes until release is closed.
+type blockingKV struct {
+	*testutil.MapKV
+	release chan struct{}
+}
+
+func (kv *blockingKV) GetObject(key string) ([]byte, error) {
+	<-kv.release
+	return kv.MapKV.GetObject(key)
+}
+
+func (kv *blockingKV) ForEach(fn func(key string, value []byte) error) error {
+	<-kv.release
+	return kv.MapKV.ForEach(fn)
+}
+
+func (kv *blockingKV) DeleteObject(key string) error {
+	<-kv.release
+	return kv.MapKV.DeleteObject(key)
+}
+
+func (kv *blockingKV) Has(key string) bool {
+	<-kv.release
+	return kv.MapKV.Has(key)
+}
+
+func TestIntegrationRequestTimeout(t *testing.T) {
+	kv := &blockingKV{MapKV: testutil.NewMapKV(), release: make(chan struct{})}
+	t.Cleanup(func() { close(kv.release) })
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	s := service.NewTodoService(logger, repository.NewTaskRepo(kv))
+	handler := routes.Routes(logger, s, &config.Config{Env: "test", RequestTimeout: 20 * time.Millisecond})
+
+	tests := []struct {
+		method string
+		url    string
+		body   string
+	}{
+		{method: "GET", url: "/todos"},
+		{method: "GET", url: "/todos/1"},
+		{method: "GET", url: "/stats"},
+		{method: "POST", url: "/todos", body: `{"id":1,"title":"Task"}`},
+		{method: "PUT", url: "/todos/1", body: `{"title":"Task"}`},
+		{method: "DELETE", url: "/todos/1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.url, bytes.NewReader([]byte(tt.body)))
+			w := httptest.NewRecorder()
+
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, w.Code)
+			}
+		})
+	}
+
+	t.Run("not applied when disabled", func(t *testing.T) {
+		handler := routes.Routes(logger, s, &config.Config{Env: "test"})
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/healthcheck", nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status %d, got %d", http.StatusOK, w.Code)
+		}
+	})
+}
+
+func TestIntegrationSlowRequestRoute(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	err := s.CreateTask(domain.NewTask(1, "Task", "Description"))
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, nil))
+	// the default timeout passes a copy of the request to the mux
+	handler := routes.Routes(logger, s, &config.Config{
+		Env:                  "test",
+		RequestTimeout:       20 * time.Second,
+		SlowRequestThreshold: time.Nanosecond,
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/todos/1", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	dec := json.NewDecoder(&logs)
+	for dec.More() {
+		var entry map[string]any
+		err := dec.Decode(&entry)
+		if err != nil {
+			t.Fatalf("Failed to decode log entry: %v", err)
+		}
+
+		if entry["msg"] == "slow request" {
+			if entry["route"] != "GET /todos/{id}" {
+				t.Errorf("Expected route 'GET /todos/{id}', got %v", entry["route"])
+			}
+			return
+		}
+	}
+
+	t.Error("Expected slow request to be logged")
+}
+
+func TestIntegrationRawTask(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	handler := routes.Routes(logger, s, &config.Config{AdminToken: "secret"})
+
+	task := domain.NewTask(1, "Task", "Description")
+	task.Tags = []string{"work"}
+	err := s.CreateTask(task)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	req := httptest.NewRequest("GET", "/admin/todos/1/raw", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d without token, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/admin/todos/1/raw", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var response struct {
+		RawTask struct {
+			Raw           string       `json:"raw"`
+			SchemaVersion int          `json:"schema_version"`
+			Task          *domain.Task `json:"task"`
+		} `json:"raw_task"`
+	}
+	err = json.NewDecoder(w.Body).Decode(&response)
+	if err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(response.RawTask.Raw)
+	if err != nil {
+		t.Fatalf("Failed to decode base64: %v", err)
+	}
+
+	// the stored value starts with the schema marker and version
+	if len(raw) < 2 || raw[0] != 0 || int(raw[1]) != response.RawTask.SchemaVersion {
+		t.Fatalf("Expected schema header for version %d, got %v", response.RawTask.SchemaVersion, raw)
+	}
+
+	var decoded domain.Task
+	err = gob.NewDecoder(bytes.NewReader(raw[2:])).Decode(&decoded)
+	if err != nil {
+		t.Fatalf("Failed to gob-decode raw value: %v", err)
+	}
+
+	stored, err := s.GetTask(1)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, stored) {
+		t.Errorf("Expected task %+v, got %+v", stored, decoded)
+	}
+	if response.RawTask.Task == nil || response.RawTask.Task.Title != task.Title {
+		t.Errorf("Expected decoded task %+v, got %+v", task, response.RawTask.Task)
+	}
+
+	req = httptest.NewRequest("GET", "/admin/todos/2/raw", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status %d for missing task, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestIntegrationDBStatusAndCompact(t *testing.T) {
+	s, _, _, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	handler := routes.Routes(logger, s, &config.Config{AdminToken: "secret"})
+
+	// churn: 3 creates(each advancing the id and position counters), 3 updates and
+	// a delete leave 2 tasks, the counters and 9 stale entries, each of the 7 writes
+	// enclosed in batch begin and commit records
+	for i := range 3 {
+		err := s.CreateTask(domain.NewTask(int64(i+1), "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	for range 3 {
+		_, err := s.UpdateTask(1, dto.UpdateTaskInput{Title: "Updated"})
+		if err != nil {
+			t.Fatalf("Failed to update task: %v", err)
+		}
+	}
+	err := s.DeleteTask(2)
+	if err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	type dbStats struct {
+		Keys           int       `json:"keys"`
+		FileSize       int64     `json:"file_size"`
+		LogEntries     int       `json:"log_entries"`
+		StaleRatio     float64   `json:"stale_ratio"`
+		LastCompaction time.Time `json:"last_compaction"`
+	}
+
+	do := func(t *testing.T, method, path string, dst any) {
+		t.Helper()
+
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d. Body: %s", http.StatusOK, w.Code, w.Body.String())
+		}
+
+		err := json.NewDecoder(w.Body).Decode(dst)
+		if err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+	}
+
+	var status struct {
+		DB dbStats `json:"db"`
+	}
+	do(t, "GET", "/admin/db/status", &status)
+
+	if status.DB.Keys != 4 || status.DB.LogEntries != 27 || status.DB.StaleRatio != 23.0/27 {
+		t.Errorf("Expected 4 keys, 27 entries and stale ratio %f, got %+v", 23.0/27, status.DB)
+	}
+
+	var compact struct {
+		ReclaimedBytes int64   `json:"reclaimed_bytes"`
+		DB             dbStats `json:"db"`
+	}
+	do(t, "POST", "/admin/db/compact", &compact)
+
+	if compact.ReclaimedBytes <= 0 || compact.ReclaimedBytes != status.DB.FileSize-compact.DB.FileSize {
+		t.Errorf("Expected reclaimed bytes %d, got %d", status.DB.FileSize-compact.DB.FileSize, compact.ReclaimedBytes)
+	}
+	if compact.DB.Keys != 4 || compact.DB.LogEntries != 4 || compact.DB.StaleRatio != 0 || compact.DB.LastCompaction.IsZero() {
+		t.Errorf("Expected 4 keys without stale entries after compaction, got %+v", compact.DB)
+	}
+
+	do(t, "GET", "/admin/db/status", &status)
+
+	if status.DB != compact.DB {
+		t.Errorf("Expected status %+v after compaction, got %+v", compact.DB, status.DB)
+	}
+}
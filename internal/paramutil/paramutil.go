@@ -2,8 +2,12 @@ package paramutil
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 func ReadIDParam(r *http.Request) (int64, error) {
@@ -16,3 +20,181 @@ func ReadIDParam(r *http.Request) (int64, error) {
 
 	return id, nil
 }
+
+// ReadUUIDParam reads the "id" path value as a UUID in the canonical textual form
+// (8-4-4-4-12 hex digits, e.g. 123e4567-e89b-12d3-a456-426614174000) and returns
+// it in lower case.
+func ReadUUIDParam(r *http.Request) (string, error) {
+	param := r.PathValue("id")
+	if param == "" {
+		return "", errors.New("missing id parameter")
+	}
+
+	if !isUUID(param) {
+		return "", errors.New("invalid id parameter: must be a UUID(e.g. 123e4567-e89b-12d3-a456-426614174000)")
+	}
+
+	return strings.ToLower(param), nil
+}
+
+// isUUID reports whether s is a UUID in the canonical textual form.
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			isHex := (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+			if !isHex {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// ReadDurationQuery reads a query parameter with the given key as a positive Go duration(e.g. 24h).
+func ReadDurationQuery(r *http.Request, key string) (time.Duration, error) {
+	param := r.URL.Query().Get(key)
+
+	d, err := time.ParseDuration(param)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid %s parameter: must be a positive duration(e.g. 24h)", key)
+	}
+
+	return d, nil
+}
+
+// ReadIntQuery reads a query parameter with the given key as an integer within [minValue, maxValue].
+// Returns defaultValue if the parameter is absent.
+func ReadIntQuery(r *http.Request, key string, defaultValue, minValue, maxValue int) (int, error) {
+	query := r.URL.Query()
+	if !query.Has(key) {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(query.Get(key))
+	if err != nil || n < minValue || n > maxValue {
+		return 0, fmt.Errorf("invalid %s parameter: must be an integer between %d and %d", key, minValue, maxValue)
+	}
+
+	return n, nil
+}
+
+// ReadBoolQuery reads a query parameter with the given key as a boolean(true, false, 1, 0, etc.).
+// Returns false if the parameter is absent.
+func ReadBoolQuery(r *http.Request, key string) (bool, error) {
+	query := r.URL.Query()
+	if !query.Has(key) {
+		return false, nil
+	}
+
+	b, err := strconv.ParseBool(query.Get(key))
+	if err != nil {
+		return false, fmt.Errorf("invalid %s parameter: must be a boolean", key)
+	}
+
+	return b, nil
+}
+
+// ReadEnumQuery reads a query parameter with the given key that must be one of values.
+// Returns "" if the parameter is absent or empty.
+func ReadEnumQuery(r *http.Request, key string, values ...string) (string, error) {
+	param := r.URL.Query().Get(key)
+	if param != "" && !slices.Contains(values, param) {
+		return "", fmt.Errorf("invalid %s parameter: must be one of %s", key, strings.Join(values, ", "))
+	}
+
+	return param, nil
+}
+
+// ReadTimeQuery reads a query parameter with the given key as an RFC 3339 timestamp.
+func ReadTimeQuery(r *http.Request, key string) (time.Time, error) {
+	param := r.URL.Query().Get(key)
+
+	t, err := time.Parse(time.RFC3339, param)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s parameter: must be an RFC 3339 timestamp", key)
+	}
+
+	return t, nil
+}
+
+// PageLimits configures page size used by ReadPageQuery.
+// Zero DefaultPageSize returns all items when page_size is absent,
+// zero MaxPageSize disables clamping. Requests without page and page_size
+// are not clamped, so they get all items unless DefaultPageSize is set.
+type PageLimits struct {
+	DefaultPageSize int
+	MaxPageSize     int
+}
+
+// Page is a requested page of a list. Zero Size means all items.
+type Page struct {
+	Number int
+	Size   int
+}
+
+// ReadPageQuery reads page(default 1) and page_size query parameters.
+// page_size is clamped to limits.MaxPageSize, limits.DefaultPageSize is used
+// when it is absent or 0. Without both parameters the page is not clamped.
+func ReadPageQuery(r *http.Request, limits PageLimits) (Page, error) {
+	query := r.URL.Query()
+
+	page := Page{Number: 1, Size: limits.DefaultPageSize}
+
+	if query.Has("page") {
+		n, err := strconv.Atoi(query.Get("page"))
+		if err != nil || n < 1 {
+			return Page{}, errors.New("invalid page parameter: must be a positive integer")
+		}
+		page.Number = n
+	}
+
+	if query.Has("page_size") {
+		n, err := strconv.Atoi(query.Get("page_size"))
+		if err != nil || n < 0 {
+			return Page{}, errors.New("invalid page_size parameter: must be a non-negative integer")
+		}
+		if n > 0 {
+			page.Size = n
+		}
+	}
+
+	// paging is opt-in: unpaged requests get the default page, all items by default
+	if !query.Has("page") && !query.Has("page_size") {
+		return page, nil
+	}
+
+	if limits.MaxPageSize > 0 && (page.Size == 0 || page.Size > limits.MaxPageSize) {
+		page.Size = limits.MaxPageSize
+	}
+
+	return page, nil
+}
+
+// Paginate returns the items of the page. Pages past the end are empty.
+func Paginate[T any](items []T, page Page) []T {
+	if page.Size == 0 {
+		return items
+	}
+
+	// compared before multiplying, as huge page numbers overflow the offset
+	if page.Number-1 > (len(items)-1)/page.Size {
+		return items[:0]
+	}
+
+	start := (page.Number - 1) * page.Size
+	if start >= len(items) {
+		return items[:0]
+	}
+
+	return items[start : start+min(page.Size, len(items)-start)]
+}
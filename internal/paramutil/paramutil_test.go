@@ -1,8 +1,11 @@
 package paramutil
 
 import (
+	"math"
 	"net/http/httptest"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestReadIDParam(t *testing.T) {
@@ -83,3 +86,305 @@ func TestReadIDParam(t *testing.T) {
 		})
 	}
 }
+
+func TestReadUUIDParam(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "reads valid UUID",
+			id:       "123e4567-e89b-12d3-a456-426614174000",
+			expected: "123e4567-e89b-12d3-a456-426614174000",
+		},
+		{
+			name:     "lowercases upper case UUID",
+			id:       "123E4567-E89B-12D3-A456-426614174000",
+			expected: "123e4567-e89b-12d3-a456-426614174000",
+		},
+		{
+			name:      "empty UUID",
+			id:        "",
+			expectErr: true,
+		},
+		{
+			name:      "integer id",
+			id:        "123",
+			expectErr: true,
+		},
+		{
+			name:      "UUID without hyphens",
+			id:        "123e4567e89b12d3a456426614174000",
+			expectErr: true,
+		},
+		{
+			name:      "misplaced hyphen",
+			id:        "123e4567e-89b-12d3-a456-426614174000",
+			expectErr: true,
+		},
+		{
+			name:      "non-hex character",
+			id:        "123e4567-e89b-12d3-a456-42661417400g",
+			expectErr: true,
+		},
+		{
+			name:      "UUID in braces",
+			id:        "{123e4567-e89b-12d3-a456-426614174000}",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/todos/"+tt.id, nil)
+			req.SetPathValue("id", tt.id)
+
+			id, err := ReadUUIDParam(req)
+			if err != nil && !tt.expectErr {
+				t.Errorf("Expected no error, got %v", err)
+			} else if err == nil && tt.expectErr {
+				t.Errorf("Expected error, got nil")
+			}
+			if id != tt.expected {
+				t.Errorf("Expected id '%s', got '%s'", tt.expected, id)
+			}
+		})
+	}
+}
+
+func TestReadBoolQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		expected  bool
+		expectErr bool
+	}{
+		{name: "absent", url: "/todos/1"},
+		{name: "true", url: "/todos/1?upsert=true", expected: true},
+		{name: "one", url: "/todos/1?upsert=1", expected: true},
+		{name: "false", url: "/todos/1?upsert=false"},
+		{name: "invalid", url: "/todos/1?upsert=yes", expectErr: true},
+		{name: "empty", url: "/todos/1?upsert=", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("PUT", tt.url, nil)
+
+			b, err := ReadBoolQuery(req, "upsert")
+			if err != nil && !tt.expectErr {
+				t.Errorf("Expected no error, got %v", err)
+			} else if err == nil && tt.expectErr {
+				t.Errorf("Expected error, got nil")
+			}
+			if b != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, b)
+			}
+		})
+	}
+}
+
+func TestReadEnumQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		expected  string
+		expectErr bool
+	}{
+		{name: "absent", url: "/todos"},
+		{name: "empty", url: "/todos?priority="},
+		{name: "valid", url: "/todos?priority=high", expected: "high"},
+		{name: "invalid", url: "/todos?priority=urgent", expectErr: true},
+		{name: "case-sensitive", url: "/todos?priority=HIGH", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+
+			v, err := ReadEnumQuery(req, "priority", "low", "medium", "high")
+			if err != nil && !tt.expectErr {
+				t.Errorf("Expected no error, got %v", err)
+			} else if err == nil && tt.expectErr {
+				t.Errorf("Expected error, got nil")
+			}
+			if v != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, v)
+			}
+		})
+	}
+}
+
+func TestReadDurationQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		expected  time.Duration
+		expectErr bool
+	}{
+		{
+			name:     "reads hours",
+			url:      "/todos?due_within=24h",
+			expected: 24 * time.Hour,
+		},
+		{
+			name:     "reads combined duration",
+			url:      "/todos?due_within=1h30m",
+			expected: 90 * time.Minute,
+		},
+		{
+			name:      "invalid duration",
+			url:       "/todos?due_within=tomorrow",
+			expectErr: true,
+		},
+		{
+			name:      "negative duration",
+			url:       "/todos?due_within=-1h",
+			expectErr: true,
+		},
+		{
+			name:      "empty duration",
+			url:       "/todos?due_within=",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+
+			d, err := ReadDurationQuery(req, "due_within")
+			if err != nil && !tt.expectErr {
+				t.Errorf("Expected no error, got %v", err)
+			} else if err == nil && tt.expectErr {
+				t.Errorf("Expected error, got nil")
+			}
+			if d != tt.expected {
+				t.Errorf("Expected duration %s, got %s", tt.expected, d)
+			}
+		})
+	}
+}
+
+func TestReadPageQuery(t *testing.T) {
+	limits := PageLimits{DefaultPageSize: 20, MaxPageSize: 100}
+
+	tests := []struct {
+		name      string
+		url       string
+		limits    PageLimits
+		expected  Page
+		expectErr bool
+	}{
+		{
+			name:     "applies default page size",
+			url:      "/todos",
+			limits:   limits,
+			expected: Page{Number: 1, Size: 20},
+		},
+		{
+			name:     "reads page and page size",
+			url:      "/todos?page=3&page_size=50",
+			limits:   limits,
+			expected: Page{Number: 3, Size: 50},
+		},
+		{
+			name:     "clamps page size to max",
+			url:      "/todos?page_size=100000",
+			limits:   limits,
+			expected: Page{Number: 1, Size: 100},
+		},
+		{
+			name:     "zero page size uses default",
+			url:      "/todos?page_size=0",
+			limits:   limits,
+			expected: Page{Number: 1, Size: 20},
+		},
+		{
+			name:     "no limits returns all",
+			url:      "/todos",
+			expected: Page{Number: 1, Size: 0},
+		},
+		{
+			name:     "unpaged request is not clamped",
+			url:      "/todos",
+			limits:   PageLimits{MaxPageSize: 100},
+			expected: Page{Number: 1, Size: 0},
+		},
+		{
+			name:     "paged request is clamped",
+			url:      "/todos?page=2",
+			limits:   PageLimits{MaxPageSize: 100},
+			expected: Page{Number: 2, Size: 100},
+		},
+		{
+			name:      "negative page size",
+			url:       "/todos?page_size=-1",
+			limits:    limits,
+			expectErr: true,
+		},
+		{
+			name:      "zero page",
+			url:       "/todos?page=0",
+			limits:    limits,
+			expectErr: true,
+		},
+		{
+			name:      "non-numeric page size",
+			url:       "/todos?page_size=ten",
+			limits:    limits,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+
+			page, err := ReadPageQuery(req, tt.limits)
+
+			if tt.expectErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if page != tt.expected {
+				t.Errorf("Expected page %+v, got %+v", tt.expected, page)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name     string
+		page     Page
+		expected []int
+	}{
+		{"first page", Page{Number: 1, Size: 2}, []int{1, 2}},
+		{"last partial page", Page{Number: 3, Size: 2}, []int{5}},
+		{"page past the end", Page{Number: 4, Size: 2}, []int{}},
+		{"huge page number", Page{Number: math.MaxInt, Size: 100}, []int{}},
+		{"huge page size", Page{Number: 2, Size: math.MaxInt}, []int{}},
+		{"zero size returns all", Page{Number: 1, Size: 0}, items},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Paginate(items, tt.page)
+
+			if !slices.Equal(got, tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+)
+
+// ErrNotSupported is returned for operations the underlying store doesn't support.
+var ErrNotSupported = errors.New("operation is not supported by the store")
+
+// KV is the key-value store the repository keeps tasks in. *inmemorydb.DB satisfies it.
+// Implementations must be safe for concurrent use and return inmemorydb.ErrNotFound
+// for missing keys.
+type KV interface {
+	GetObject(key string) ([]byte, error)
+	PutObject(key string, value []byte) error
+	Update(key string, fn func(value []byte, exists bool) ([]byte, error)) error
+	DeleteObject(key string) error
+	ForEach(fn func(key string, value []byte) error) error
+	Has(key string) bool
+}
+
+var (
+	_ KV = (*inmemorydb.DB)(nil)
+
+	_ txStore = (*inmemorydb.Batch)(nil)
+	_ txStore = (*stagedTx)(nil)
+)
+
+// Optional store capabilities, implemented by *inmemorydb.DB.
+type (
+	// batcher applies a group of writes atomically.
+	batcher interface {
+		Batch(fn func(b *inmemorydb.Batch) error) error
+	}
+
+	// historian returns previous values of a key.
+	historian interface {
+		History(key string) ([][]byte, error)
+	}
+
+	// compactor reports storage statistics and compacts the storage.
+	compactor interface {
+		Stats() (inmemorydb.Stats, error)
+		Shrink() error
+	}
+)
+
+// txStore is the store view used by Tx. *inmemorydb.Batch satisfies it.
+type txStore interface {
+	GetObject(key string) ([]byte, error)
+	PutObject(key string, value []byte)
+	DeleteObject(key string) error
+	ForEach(fn func(key string, value []byte) error) error
+	Has(key string) bool
+}
+
+// stagedValue is a pending write of stagedTx. deleted marks a pending delete.
+type stagedValue struct {
+	value   []byte
+	deleted bool
+}
+
+// stagedTx collects writes for stores without batch support and applies them
+// one by one on commit. Reads see the pending writes.
+type stagedTx struct {
+	kv      KV
+	pending map[string]stagedValue
+}
+
+func newStagedTx(kv KV) *stagedTx {
+	return &stagedTx{
+		kv:      kv,
+		pending: make(map[string]stagedValue),
+	}
+}
+
+func (s *stagedTx) GetObject(key string) ([]byte, error) {
+	if v, ok := s.pending[key]; ok {
+		if v.deleted {
+			return nil, inmemorydb.ErrNotFound
+		}
+
+		return append([]byte(nil), v.value...), nil
+	}
+
+	return s.kv.GetObject(key)
+}
+
+func (s *stagedTx) PutObject(key string, value []byte) {
+	s.pending[key] = stagedValue{value: append([]byte(nil), value...)}
+}
+
+func (s *stagedTx) DeleteObject(key string) error {
+	if !s.Has(key) {
+		return inmemorydb.ErrNotFound
+	}
+
+	s.pending[key] = stagedValue{deleted: true}
+	return nil
+}
+
+func (s *stagedTx) ForEach(fn func(key string, value []byte) error) error {
+	err := s.kv.ForEach(func(key string, value []byte) error {
+		if _, ok := s.pending[key]; ok {
+			return nil
+		}
+
+		return fn(key, value)
+	})
+	if err != nil {
+		return err
+	}
+
+	for key, v := range s.pending {
+		if v.deleted {
+			continue
+		}
+
+		err = fn(key, append([]byte(nil), v.value...))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *stagedTx) Has(key string) bool {
+	if v, ok := s.pending[key]; ok {
+		return !v.deleted
+	}
+
+	return s.kv.Has(key)
+}
+
+func (s *stagedTx) commit() error {
+	for key, v := range s.pending {
+		var err error
+		if v.deleted {
+			err = s.kv.DeleteObject(key)
+		} else {
+			err = s.kv.PutObject(key, v.value)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
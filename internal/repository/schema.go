@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+)
+
+// Stored tasks start with taskSchemaMarker followed by the schema version, then
+// the gob encoded task. Tasks stored before versioning are plain gob(schema 0),
+// which never starts with a zero byte, since gob messages start with their length.
+const (
+	taskSchemaMarker  byte = 0
+	taskSchemaVersion byte = 1
+)
+
+// taskMigrations[v] upgrades a task decoded from schema v to schema v+1, filling
+// defaults for the fields schema v didn't have. Changing domain.Task in a way that
+// needs defaults requires bumping taskSchemaVersion and adding a migration.
+var taskMigrations = []func(task *domain.Task){
+	0: func(task *domain.Task) {
+		// tasks created before versioning could be stored without version and update time
+		if task.Version < 1 {
+			task.Version = 1
+		}
+		if task.UpdatedAt.IsZero() {
+			task.UpdatedAt = task.CreatedAt
+		}
+	},
+}
+
+// encodeTask encodes the task with the current schema version. Returns ErrTooLarge
+// if the encoded task exceeds MaxTaskSize.
+func encodeTask(task *domain.Task) ([]byte, error) {
+	buf := bytes.NewBuffer([]byte{taskSchemaMarker, taskSchemaVersion})
+	enc := gob.NewEncoder(buf)
+	err := enc.Encode(task)
+	if err != nil {
+		return nil, err
+	}
+
+	if buf.Len() > MaxTaskSize {
+		return nil, ErrTooLarge
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decodeTask decodes the task stored with any known schema version and upgrades it
+// to the current one. Upgraded tasks are rewritten in the current schema on next save.
+// Undecodable values return ErrCorrupt.
+func decodeTask(obj []byte) (*domain.Task, error) {
+	version, payload, err := splitTaskSchema(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := gob.NewDecoder(bytes.NewReader(payload))
+
+	var task domain.Task
+	err = dec.Decode(&task)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorrupt, err)
+	}
+
+	for v := version; v < taskSchemaVersion; v++ {
+		taskMigrations[v](&task)
+	}
+
+	return &task, nil
+}
+
+// splitTaskSchema returns the schema version of the stored task and its gob payload.
+func splitTaskSchema(obj []byte) (byte, []byte, error) {
+	if len(obj) == 0 || obj[0] != taskSchemaMarker {
+		return 0, obj, nil
+	}
+
+	if len(obj) < 2 {
+		return 0, nil, fmt.Errorf("%w: missing schema version", ErrCorrupt)
+	}
+
+	version := obj[1]
+	if version > taskSchemaVersion {
+		return 0, nil, fmt.Errorf("unsupported task schema version %d", version)
+	}
+
+	return version, obj[2:], nil
+}
+
+// MigrateAll rewrites tasks stored with an older schema in the current one, in a
+// single transaction, and returns the number of rewritten tasks. Reads upgrade old
+// tasks anyway, MigrateAll upgrades the stored data in place(e.g. before dropping
+// a migration).
+func (r *TaskRepo) MigrateAll() (int, error) {
+	migrated := 0
+
+	err := r.WithTx(func(tx *Tx) error {
+		var outdated []*domain.Task
+		err := tx.batch.ForEach(func(key string, value []byte) error {
+			if !isTaskKey(key) {
+				return nil
+			}
+
+			version, _, err := splitTaskSchema(value)
+			if err != nil || version == taskSchemaVersion {
+				return err
+			}
+
+			task, err := decodeTask(value)
+			if err != nil {
+				return err
+			}
+
+			outdated = append(outdated, task)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, task := range outdated {
+			err := tx.Update(task)
+			if err != nil {
+				return err
+			}
+		}
+
+		migrated = len(outdated)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return migrated, nil
+}
@@ -2,9 +2,15 @@ package repository
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/gob"
 	"errors"
+	"fmt"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/vladgrskkh/todo/internal/domain"
 	"github.com/vladgrskkh/todo/pkg/inmemorydb"
@@ -14,13 +20,41 @@ var (
 	ErrNotFound      = errors.New("resource not found")
 	ErrEditConflict  = errors.New("edit conflict")
 	ErrAlreadyExists = errors.New("resource already exists")
+	// ErrCorrupt is returned when a stored value can't be decoded, so it can be
+	// told apart from a missing one.
+	ErrCorrupt = errors.New("stored resource is corrupt")
+	// ErrTooLarge is returned for a task which encoded size exceeds MaxTaskSize.
+	ErrTooLarge = errors.New("resource is too large to be stored")
+	// ErrProtectedKey is returned by DeleteKey for the keys which can't be deleted raw.
+	ErrProtectedKey = errors.New("key can't be deleted")
 )
 
+// MaxTaskSize is the largest encoded task, so every stored task fits into a database
+// record and can be read back.
+const MaxTaskSize = inmemorydb.MaxValueSize
+
+// idempotencyKeyPrefix namespaces idempotency records, so they don't clash with task keys.
+const idempotencyKeyPrefix = "idempotency:"
+
+// counterKeyPrefix namespaces counters, so they don't clash with task keys.
+const counterKeyPrefix = "counter:"
+
+// idempotencyRecord stores the result of a create request made with an Idempotency-Key.
+type idempotencyRecord struct {
+	Task      domain.Task
+	CreatedAt time.Time
+}
+
 type TaskRepo struct {
-	db *inmemorydb.DB
+	db KV
+
+	// txMutex serializes transactions on stores without batch support.
+	txMutex sync.Mutex
 }
 
-func NewTaskRepo(db *inmemorydb.DB) *TaskRepo {
+// NewTaskRepo returns a repository keeping tasks in db. Transactions are atomic,
+// history and compaction are available only if db supports them(as *inmemorydb.DB does).
+func NewTaskRepo(db KV) *TaskRepo {
 	return &TaskRepo{
 		db: db,
 	}
@@ -38,74 +72,529 @@ func (r *TaskRepo) Get(id int64) (*domain.Task, error) {
 		}
 	}
 
-	dec := gob.NewDecoder(bytes.NewReader(obj))
+	return decodeTask(obj)
+}
+
+// GetAll returns all tasks sorted by id, so the order is the same across calls.
+func (r *TaskRepo) GetAll() ([]*domain.Task, error) {
+	tasks := make([]*domain.Task, 0)
+
+	err := r.db.ForEach(func(key string, value []byte) error {
+		// skipping non-task keys(idempotency records, etc.)
+		if !isTaskKey(key) {
+			return nil
+		}
+
+		task, err := decodeTask(value)
+		if err != nil {
+			return err
+		}
 
-	var task domain.Task
-	err = dec.Decode(&task)
+		tasks = append(tasks, task)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &task, nil
+	sortByID(tasks)
+	return tasks, nil
 }
 
-func (r *TaskRepo) GetAll() ([]*domain.Task, error) {
-	tasks := make([]*domain.Task, 0, r.db.Size())
-	data := r.db.GetAllObjects()
+// Iterate calls fn for every task in id order(like GetAll), reading one task at a
+// time instead of building the whole slice. Iteration stops at the first error
+// returned by fn and the error is returned.
+//
+// Only task ids are collected upfront and each task is read when visited, so fn
+// doesn't run under the store lock and may be slow(e.g. write to a client).
+// Tasks deleted during the iteration are skipped, tasks created during it may be missed.
+func (r *TaskRepo) Iterate(fn func(task *domain.Task) error) error {
+	var ids []int64
+	err := r.db.ForEach(func(key string, _ []byte) error {
+		// skipping non-task keys(idempotency records, etc.)
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err == nil {
+			ids = append(ids, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-	for _, v := range data {
-		dec := gob.NewDecoder(bytes.NewReader(v))
+	slices.Sort(ids)
 
-		var task domain.Task
-		err := dec.Decode(&task)
+	for _, id := range ids {
+		task, err := r.Get(id)
+		if errors.Is(err, ErrNotFound) {
+			continue
+		}
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		tasks = append(tasks, &task)
+		err = fn(task)
+		if err != nil {
+			return err
+		}
 	}
 
-	return tasks, nil
+	return nil
+}
+
+// sortByID sorts tasks by id ascending. Stores iterate maps, so without
+// sorting the order would change between calls.
+func sortByID(tasks []*domain.Task) {
+	slices.SortFunc(tasks, func(a, b *domain.Task) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+}
+
+// GetDueBetween returns tasks with due date within [from, to], sorted by due date ascending.
+// Tasks without due date are excluded.
+func (r *TaskRepo) GetDueBetween(from, to time.Time) ([]*domain.Task, error) {
+	tasks, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	due := make([]*domain.Task, 0)
+	for _, task := range tasks {
+		if task.DueDate == nil || task.DueDate.Before(from) || task.DueDate.After(to) {
+			continue
+		}
+
+		due = append(due, task)
+	}
+
+	slices.SortFunc(due, func(a, b *domain.Task) int {
+		return a.DueDate.Compare(*b.DueDate)
+	})
+
+	return due, nil
+}
+
+// CountOpen returns the number of open tasks. Only the done field is decoded,
+// so it's cheaper than GetAll.
+func (r *TaskRepo) CountOpen() (int, error) {
+	open := 0
+
+	err := r.db.ForEach(func(key string, value []byte) error {
+		if !isTaskKey(key) {
+			return nil
+		}
+
+		_, payload, err := splitTaskSchema(value)
+		if err != nil {
+			return err
+		}
+
+		// gob skips fields missing in the destination
+		var task struct{ Done bool }
+		err = gob.NewDecoder(bytes.NewReader(payload)).Decode(&task)
+		if err != nil {
+			return err
+		}
+
+		if !task.Done {
+			open++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return open, nil
+}
+
+// NextOpen returns the oldest open task(by CreatedAt, ties broken by the lowest id).
+// Returns ErrNotFound if there are no open tasks.
+func (r *TaskRepo) NextOpen() (*domain.Task, error) {
+	tasks, err := r.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var next *domain.Task
+	for _, task := range tasks {
+		if task.Done {
+			continue
+		}
+
+		if next == nil || task.CreatedAt.Before(next.CreatedAt) ||
+			(task.CreatedAt.Equal(next.CreatedAt) && task.ID < next.ID) {
+			next = task
+		}
+	}
+
+	if next == nil {
+		return nil, ErrNotFound
+	}
+
+	return next, nil
 }
 
+// Insert stores the task as is, overriding a task with the same id. The version is
+// not changed, so imported tasks keep theirs: bumping it is up to the caller
+// (see domain.Task.Update).
+//
+// Like the other writes, it runs in a transaction to keep the title index.
 func (r *TaskRepo) Insert(task *domain.Task) error {
-	key := strconv.FormatInt(task.ID, 10)
+	return r.WithTx(func(tx *Tx) error {
+		return tx.put(task)
+	})
+}
 
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	err := enc.Encode(task)
+// Update stores the task as is, like Insert. The caller is expected to have
+// incremented the version.
+func (r *TaskRepo) Update(task *domain.Task) error {
+	return r.WithTx(func(tx *Tx) error {
+		return tx.put(task)
+	})
+}
+
+func (r *TaskRepo) Delete(id int64) error {
+	return r.WithTx(func(tx *Tx) error {
+		return tx.Delete(id)
+	})
+}
+
+// History returns the stored snapshots of the task, oldest first.
+func (r *TaskRepo) History(id int64) ([]*domain.Task, error) {
+	h, ok := r.db.(historian)
+	if !ok {
+		return nil, ErrNotSupported
+	}
+
+	objs, err := h.History(strconv.FormatInt(id, 10))
+	if err != nil {
+		switch {
+		case errors.Is(err, inmemorydb.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	history := make([]*domain.Task, 0, len(objs))
+	for _, obj := range objs {
+		task, err := decodeTask(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, task)
+	}
+
+	return history, nil
+}
+
+// GetIdempotencyRecord returns the task created with the given idempotency key
+// and the time the record was created.
+func (r *TaskRepo) GetIdempotencyRecord(key string) (*domain.Task, time.Time, error) {
+	obj, err := r.db.GetObject(idempotencyKeyPrefix + key)
+	if err != nil {
+		switch {
+		case errors.Is(err, inmemorydb.ErrNotFound):
+			return nil, time.Time{}, ErrNotFound
+		default:
+			return nil, time.Time{}, err
+		}
+	}
+
+	dec := gob.NewDecoder(bytes.NewReader(obj))
+
+	var record idempotencyRecord
+	err = dec.Decode(&record)
 	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return &record.Task, record.CreatedAt, nil
+}
+
+// InsertIdempotencyRecord stores the task created with the given idempotency key.
+// Existing record with the same key is overridden.
+func (r *TaskRepo) InsertIdempotencyRecord(key string, task *domain.Task, createdAt time.Time) error {
+	obj, err := encodeIdempotencyRecord(task, createdAt)
+	if err != nil {
+		return err
+	}
+
+	return r.db.PutObject(idempotencyKeyPrefix+key, obj)
+}
+
+// DeleteIdempotencyRecord removes the record of the idempotency key, missing records are ignored.
+func (r *TaskRepo) DeleteIdempotencyRecord(key string) error {
+	err := r.db.DeleteObject(idempotencyKeyPrefix + key)
+	if err != nil && !errors.Is(err, inmemorydb.ErrNotFound) {
 		return err
 	}
 
-	err = r.db.PutObject(key, buf.Bytes())
+	return nil
+}
+
+// InsertIdempotencyRecord stores the task created with the given idempotency key in the
+// transaction, so the record is committed together with the task.
+func (tx *Tx) InsertIdempotencyRecord(key string, task *domain.Task, createdAt time.Time) error {
+	obj, err := encodeIdempotencyRecord(task, createdAt)
 	if err != nil {
 		return err
 	}
 
+	tx.batch.PutObject(idempotencyKeyPrefix+key, obj)
 	return nil
 }
 
-func (r *TaskRepo) Update(task *domain.Task) error {
-	key := strconv.FormatInt(task.ID, 10)
+// PurgeIdempotencyRecords removes the idempotency records created before the given time
+// and returns the number of removed records.
+func (tx *Tx) PurgeIdempotencyRecords(createdBefore time.Time) (int, error) {
+	var expired []string
+	err := tx.batch.ForEach(func(key string, value []byte) error {
+		if !strings.HasPrefix(key, idempotencyKeyPrefix) {
+			return nil
+		}
+
+		var record idempotencyRecord
+		err := gob.NewDecoder(bytes.NewReader(value)).Decode(&record)
+		if err != nil {
+			return err
+		}
+
+		if record.CreatedAt.Before(createdBefore) {
+			expired = append(expired, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
 
+	// keys are deleted after the iteration, as the store must not be modified during it
+	for _, key := range expired {
+		err = tx.batch.DeleteObject(key)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(expired), nil
+}
+
+func encodeIdempotencyRecord(task *domain.Task, createdAt time.Time) ([]byte, error) {
 	var buf bytes.Buffer
 	enc := gob.NewEncoder(&buf)
-	err := enc.Encode(task)
+	err := enc.Encode(idempotencyRecord{Task: *task, CreatedAt: createdAt})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = r.db.PutObject(key, buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+// IncrCounter atomically adds delta to the counter with the given name and returns
+// the new value. Missing counters start at 0.
+func (r *TaskRepo) IncrCounter(name string, delta int64) (int64, error) {
+	var n int64
+	err := r.db.Update(counterKeyPrefix+name, func(value []byte, exists bool) ([]byte, error) {
+		if exists {
+			current, err := strconv.ParseInt(string(value), 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			n = current
+		}
+
+		n += delta
+		return []byte(strconv.FormatInt(n, 10)), nil
+	})
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	return nil
+	return n, nil
 }
 
-func (r *TaskRepo) Delete(id int64) error {
-	key := strconv.FormatInt(id, 10)
+// isTaskKey reports whether the key belongs to a task(task keys are plain ids).
+func isTaskKey(key string) bool {
+	_, err := strconv.ParseInt(key, 10, 64)
+	return err == nil
+}
+
+// Stats returns the underlying database statistics.
+func (r *TaskRepo) Stats() (inmemorydb.Stats, error) {
+	c, ok := r.db.(compactor)
+	if !ok {
+		return inmemorydb.Stats{}, ErrNotSupported
+	}
+
+	return c.Stats()
+}
+
+// Vacuum compacts the database file and returns the number of bytes reclaimed.
+func (r *TaskRepo) Vacuum() (int64, error) {
+	reclaimed, _, err := r.Compact()
+	return reclaimed, err
+}
+
+// Compact compacts the database file like Vacuum and returns the number of bytes
+// reclaimed with the database statistics after the compaction.
+func (r *TaskRepo) Compact() (int64, inmemorydb.Stats, error) {
+	c, ok := r.db.(compactor)
+	if !ok {
+		return 0, inmemorydb.Stats{}, ErrNotSupported
+	}
+
+	before, err := c.Stats()
+	if err != nil {
+		return 0, inmemorydb.Stats{}, err
+	}
+
+	err = c.Shrink()
+	if err != nil {
+		return 0, inmemorydb.Stats{}, err
+	}
+
+	after, err := c.Stats()
+	if err != nil {
+		return 0, inmemorydb.Stats{}, err
+	}
+
+	return before.FileSize - after.FileSize, after, nil
+}
+
+// IntegrityReport is the result of CheckIntegrity.
+type IntegrityReport struct {
+	// Tasks is the number of stored tasks(including the trash), Corrupt the number
+	// of them that can't be decoded.
+	Tasks   int `json:"tasks"`
+	Corrupt int `json:"corrupt"`
+	// CorruptKeys holds the sorted keys of the corrupt tasks.
+	CorruptKeys []string `json:"corrupt_keys,omitempty"`
+}
+
+// CorruptRatio returns the share of corrupt tasks, 0 if there are no tasks.
+func (r IntegrityReport) CorruptRatio() float64 {
+	if r.Tasks == 0 {
+		return 0
+	}
+
+	return float64(r.Corrupt) / float64(r.Tasks)
+}
+
+// CheckIntegrity decodes every stored task(including the trash) and reports the corrupt
+// ones(see ErrCorrupt), instead of failing on the first one like GetAll does. Other
+// decoding errors(e.g. an unsupported schema version) are returned.
+func (r *TaskRepo) CheckIntegrity() (IntegrityReport, error) {
+	var report IntegrityReport
+
+	err := r.db.ForEach(func(key string, value []byte) error {
+		if _, ok := trashID(key); !ok && !isTaskKey(key) {
+			return nil
+		}
+
+		report.Tasks++
+
+		_, err := decodeTask(value)
+		switch {
+		case errors.Is(err, ErrCorrupt):
+			report.Corrupt++
+			report.CorruptKeys = append(report.CorruptKeys, key)
+		case err != nil:
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return IntegrityReport{}, err
+	}
+
+	slices.Sort(report.CorruptKeys)
+	return report, nil
+}
+
+// RawTask is the value stored for a task with its decoded form, for debugging encoding issues.
+type RawTask struct {
+	// Value is the stored value: the schema header(see encodeTask) followed by the gob
+	// encoded task. Tasks stored with schema 0 have no header.
+	Value         []byte       `json:"raw"`
+	SchemaVersion int          `json:"schema_version"`
+	Task          *domain.Task `json:"task"`            // nil if the value can't be decoded
+	Error         string       `json:"error,omitempty"` // decoding error
+}
+
+// GetRaw returns the stored value of the task. Values that can't be decoded are
+// returned too, with the decoding error. Returns ErrNotFound if the task does not exist.
+func (r *TaskRepo) GetRaw(id int64) (*RawTask, error) {
+	obj, err := r.db.GetObject(strconv.FormatInt(id, 10))
+	if err != nil {
+		switch {
+		case errors.Is(err, inmemorydb.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	raw := &RawTask{Value: obj}
+
+	version, _, err := splitTaskSchema(obj)
+	if err == nil {
+		raw.SchemaVersion = int(version)
+		raw.Task, err = decodeTask(obj)
+	}
+	if err != nil {
+		raw.Error = err.Error()
+	}
+
+	return raw, nil
+}
+
+// KeyInfo describes a raw key of the store.
+type KeyInfo struct {
+	Key  string `json:"key"`
+	Size int    `json:"size"` // value size in bytes
+	Task bool   `json:"task"` // the key holds a task
+}
+
+// Keys returns the raw keys starting with prefix(all keys for empty prefix) sorted,
+// including non-task keys(idempotency records, counters, etc.).
+func (r *TaskRepo) Keys(prefix string) ([]KeyInfo, error) {
+	keys := make([]KeyInfo, 0)
+
+	err := r.db.ForEach(func(key string, value []byte) error {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, KeyInfo{Key: key, Size: len(value), Task: isTaskKey(key)})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(keys, func(a, b KeyInfo) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+
+	return keys, nil
+}
+
+// deletableKeyPrefixes are the prefixes of the keys DeleteKey removes. Deleting the other
+// keys would break the store: e.g. a deleted counter lets new tasks reuse ids, a deleted
+// task key leaves it in the title index.
+var deletableKeyPrefixes = []string{idempotencyKeyPrefix, trashKeyPrefix}
+
+// DeleteKey removes the raw idempotency record or trashed task key. Returns ErrProtectedKey
+// for other keys(tasks, counters, etc.) and ErrNotFound if the key does not exist.
+func (r *TaskRepo) DeleteKey(key string) error {
+	deletable := slices.ContainsFunc(deletableKeyPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(key, prefix)
+	})
+	if !deletable {
+		return ErrProtectedKey
+	}
+
 	err := r.db.DeleteObject(key)
 	if err != nil {
 		switch {
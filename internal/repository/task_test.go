@@ -1,11 +1,21 @@
 package repository
 
 import (
+	"bytes"
+	"encoding/gob"
 	"errors"
+	"math"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/testutil"
 	"github.com/vladgrskkh/todo/pkg/inmemorydb"
 )
 
@@ -49,6 +59,39 @@ func TestTaskRepoInsertAndGet(t *testing.T) {
 		}
 	})
 
+	t.Run("keeps the version", func(t *testing.T) {
+		task := domain.NewTask(2, "Imported", "")
+		task.Version = 5
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+
+		retrieved, err := repo.Get(2)
+		if err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+		if retrieved.Version != 5 {
+			t.Errorf("Expected version 5, got %d", retrieved.Version)
+		}
+	})
+
+	t.Run("rejects too large task", func(t *testing.T) {
+		task := domain.NewTask(3, "Large", strings.Repeat("a", MaxTaskSize))
+
+		err := repo.WithTx(func(tx *Tx) error {
+			return tx.Insert(task)
+		})
+		if !errors.Is(err, ErrTooLarge) {
+			t.Errorf("Expected ErrTooLarge, got %v", err)
+		}
+
+		_, err = repo.Get(3)
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected task not to be stored, got %v", err)
+		}
+	})
+
 	t.Run("returns error for non-existent task", func(t *testing.T) {
 		_, err := repo.Get(999)
 		if err == nil {
@@ -60,6 +103,178 @@ func TestTaskRepoInsertAndGet(t *testing.T) {
 	})
 }
 
+func TestTaskRepoSchema(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	createdAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	putLegacyTask(t, db, 1, "Old", createdAt)
+
+	t.Run("decodes legacy task with defaults", func(t *testing.T) {
+		task, err := repo.Get(1)
+		if err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+
+		if task.Title != "Old" || task.Description != "Legacy" || task.Done {
+			t.Errorf("Expected legacy fields to be decoded, got %+v", task)
+		}
+		if task.Version != 1 {
+			t.Errorf("Expected version 1, got %d", task.Version)
+		}
+		if !task.UpdatedAt.Equal(createdAt) {
+			t.Errorf("Expected updated_at %v, got %v", createdAt, task.UpdatedAt)
+		}
+		if task.Priority != "" || task.Tags != nil || task.DueDate != nil {
+			t.Errorf("Expected new fields to be empty, got %+v", task)
+		}
+
+		open, err := repo.CountOpen()
+		if err != nil || open != 1 {
+			t.Errorf("Expected 1 open task, got %d(%v)", open, err)
+		}
+	})
+
+	t.Run("rewrites legacy task in current schema on save", func(t *testing.T) {
+		task, err := repo.Get(1)
+		if err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+
+		err = repo.Update(task)
+		if err != nil {
+			t.Fatalf("Failed to update: %v", err)
+		}
+
+		obj, err := db.GetObject("1")
+		if err != nil {
+			t.Fatalf("Failed to get object: %v", err)
+		}
+		if len(obj) < 2 || obj[0] != taskSchemaMarker || obj[1] != taskSchemaVersion {
+			t.Errorf("Expected task to be stored with schema version %d", taskSchemaVersion)
+		}
+
+		task, err = repo.Get(1)
+		if err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+		if task.Title != "Old" || task.Version != 1 {
+			t.Errorf("Expected rewritten task to round-trip, got %+v", task)
+		}
+	})
+
+	t.Run("rejects unknown schema version", func(t *testing.T) {
+		err := db.PutObject("2", []byte{taskSchemaMarker, taskSchemaVersion + 1})
+		if err != nil {
+			t.Fatalf("Failed to put task: %v", err)
+		}
+
+		_, err = repo.Get(2)
+		if err == nil {
+			t.Error("Expected error for unknown schema version")
+		}
+		if errors.Is(err, ErrCorrupt) {
+			t.Error("Expected unknown schema version not to be reported as corrupt")
+		}
+	})
+
+	t.Run("reports undecodable task as corrupt", func(t *testing.T) {
+		for key, obj := range map[string][]byte{
+			"3": {taskSchemaMarker, taskSchemaVersion, 0xff, 0x01},
+			"4": {taskSchemaMarker},
+			"5": []byte("not gob"),
+		} {
+			err := db.PutObject(key, obj)
+			if err != nil {
+				t.Fatalf("Failed to put task: %v", err)
+			}
+
+			id, _ := strconv.ParseInt(key, 10, 64)
+			_, err = repo.Get(id)
+			if !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Expected ErrCorrupt for task %s, got %v", key, err)
+			}
+		}
+	})
+}
+
+func TestTaskRepoMigrateAll(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	createdAt := time.Date(2024, time.June, 1, 12, 0, 0, 0, time.UTC)
+	putLegacyTask(t, db, 1, "Old 1", createdAt)
+	putLegacyTask(t, db, 2, "Old 2", createdAt)
+
+	err := repo.Insert(domain.NewTask(3, "Current", ""))
+	if err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	migrated, err := repo.MigrateAll()
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if migrated != 2 {
+		t.Errorf("Expected 2 migrated tasks, got %d", migrated)
+	}
+
+	for _, key := range []string{"1", "2", "3"} {
+		obj, err := db.GetObject(key)
+		if err != nil {
+			t.Fatalf("Failed to get object: %v", err)
+		}
+		if obj[0] != taskSchemaMarker || obj[1] != taskSchemaVersion {
+			t.Errorf("Expected task %s to be stored with schema version %d", key, taskSchemaVersion)
+		}
+	}
+
+	task, err := repo.Get(1)
+	if err != nil {
+		t.Fatalf("Failed to get: %v", err)
+	}
+	if task.Title != "Old 1" || task.Version != 1 || !task.UpdatedAt.Equal(createdAt) {
+		t.Errorf("Expected migrated task with defaults, got %+v", task)
+	}
+
+	migrated, err = repo.MigrateAll()
+	if err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if migrated != 0 {
+		t.Errorf("Expected nothing to migrate, got %d", migrated)
+	}
+}
+
+// putLegacyTask stores a task the way it was stored before schema versioning,
+// without version, update time and the fields added later.
+func putLegacyTask(t *testing.T, db *inmemorydb.DB, id int64, title string, createdAt time.Time) {
+	t.Helper()
+
+	type legacyTask struct {
+		ID          int64
+		Title       string
+		Description string
+		Done        bool
+		CreatedAt   time.Time
+	}
+
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(legacyTask{ID: id, Title: title, Description: "Legacy", CreatedAt: createdAt})
+	if err != nil {
+		t.Fatalf("Failed to encode legacy task: %v", err)
+	}
+
+	err = db.PutObject(strconv.FormatInt(id, 10), buf.Bytes())
+	if err != nil {
+		t.Fatalf("Failed to put legacy task: %v", err)
+	}
+}
+
 func TestTaskRepoGetAll(t *testing.T) {
 	db, cleanup := setupTestEnvironment(t)
 	defer cleanup()
@@ -92,6 +307,94 @@ func TestTaskRepoGetAll(t *testing.T) {
 			t.Errorf("Expected 3 tasks, got %d", len(tasks))
 		}
 	})
+
+	t.Run("returns tasks sorted by id", func(t *testing.T) {
+		for _, id := range []int64{42, 7, 100, 15, 4, 23} {
+			err := repo.Insert(domain.NewTask(id, "Test", "Test"))
+			if err != nil {
+				t.Fatalf("Failed to insert task: %v", err)
+			}
+		}
+
+		ids := func() []int64 {
+			tasks, err := repo.GetAll()
+			if err != nil {
+				t.Fatalf("Failed to get all: %v", err)
+			}
+
+			ids := make([]int64, len(tasks))
+			for i, task := range tasks {
+				ids[i] = task.ID
+			}
+			return ids
+		}
+
+		first := ids()
+		if !slices.IsSorted(first) {
+			t.Errorf("Expected tasks sorted by id, got %v", first)
+		}
+
+		for range 10 {
+			if got := ids(); !slices.Equal(got, first) {
+				t.Fatalf("Expected the same order %v, got %v", first, got)
+			}
+		}
+	})
+}
+
+func TestTaskRepoIterate(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	for _, id := range []int64{42, 7, 100, 15} {
+		err := repo.Insert(domain.NewTask(id, "Test", "Test"))
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	// non-task keys are skipped
+	err := repo.InsertIdempotencyRecord("key", domain.NewTask(1, "Test", "Test"), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to insert idempotency record: %v", err)
+	}
+
+	t.Run("visits every task once in id order", func(t *testing.T) {
+		var ids []int64
+		err := repo.Iterate(func(task *domain.Task) error {
+			ids = append(ids, task.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed to iterate: %v", err)
+		}
+
+		expected := []int64{7, 15, 42, 100}
+		if !slices.Equal(ids, expected) {
+			t.Errorf("Expected tasks %v, got %v", expected, ids)
+		}
+	})
+
+	t.Run("stops at the first error", func(t *testing.T) {
+		stopErr := errors.New("stop")
+
+		visited := 0
+		err := repo.Iterate(func(task *domain.Task) error {
+			visited++
+			if visited == 2 {
+				return stopErr
+			}
+			return nil
+		})
+		if !errors.Is(err, stopErr) {
+			t.Errorf("Expected fn error, got %v", err)
+		}
+		if visited != 2 {
+			t.Errorf("Expected iteration to stop after 2 tasks, visited %d", visited)
+		}
+	})
 }
 
 func TestTaskRepoDelete(t *testing.T) {
@@ -127,3 +430,908 @@ func TestTaskRepoDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestTaskRepoIdempotencyRecord(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	t.Run("returns not found for unknown key", func(t *testing.T) {
+		_, _, err := repo.GetIdempotencyRecord("unknown")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("stores record and keeps it out of GetAll", func(t *testing.T) {
+		task := domain.NewTask(1, "Task", "Description")
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert: %v", err)
+		}
+
+		createdAt := time.Now()
+		err = repo.InsertIdempotencyRecord("key", task, createdAt)
+		if err != nil {
+			t.Fatalf("Failed to insert idempotency record: %v", err)
+		}
+
+		stored, storedAt, err := repo.GetIdempotencyRecord("key")
+		if err != nil {
+			t.Fatalf("Failed to get idempotency record: %v", err)
+		}
+		if stored.ID != task.ID || !storedAt.Equal(createdAt) {
+			t.Errorf("Unexpected record: %v at %s", stored, storedAt)
+		}
+
+		tasks, err := repo.GetAll()
+		if err != nil {
+			t.Fatalf("Failed to get all: %v", err)
+		}
+		if len(tasks) != 1 {
+			t.Errorf("Expected 1 task, got %d", len(tasks))
+		}
+	})
+}
+
+func TestTaskRepoGetDueBetween(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(24 * time.Hour)
+
+	before := from.Add(-time.Second)
+	inside := from.Add(12 * time.Hour)
+	after := to.Add(time.Second)
+
+	dueDates := map[int64]*time.Time{
+		1: &to,     // upper boundary, included
+		2: &from,   // lower boundary, included
+		3: &inside, // inside the window
+		4: &before, // before the window
+		5: &after,  // after the window
+		6: nil,     // without due date
+	}
+
+	for id, dueDate := range dueDates {
+		task := domain.NewTask(id, "Task", "Description")
+		task.DueDate = dueDate
+
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	tasks, err := repo.GetDueBetween(from, to)
+	if err != nil {
+		t.Fatalf("Failed to get due tasks: %v", err)
+	}
+
+	expected := []int64{2, 3, 1}
+	if len(tasks) != len(expected) {
+		t.Fatalf("Expected %d tasks, got %d", len(expected), len(tasks))
+	}
+	for i, id := range expected {
+		if tasks[i].ID != id {
+			t.Errorf("Expected task %d at position %d, got %d", id, i, tasks[i].ID)
+		}
+	}
+}
+
+func TestTaskRepoCountOpen(t *testing.T) {
+	tests := []struct {
+		name     string
+		done     []bool
+		expected int
+	}{
+		{name: "no tasks", expected: 0},
+		{name: "all open", done: []bool{false, false, false}, expected: 3},
+		{name: "all done", done: []bool{true, true}, expected: 0},
+		{name: "mixed", done: []bool{true, false, true, false, false}, expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewTaskRepo(testutil.NewMapKV())
+
+			for i, done := range tt.done {
+				task := domain.NewTask(int64(i+1), "Task", "Description")
+				task.Done = done
+				task.Tags = []string{"tag"}
+
+				err := repo.Insert(task)
+				if err != nil {
+					t.Fatalf("Failed to insert task: %v", err)
+				}
+			}
+
+			// non-task keys must not be counted
+			_, err := repo.IncrCounter("task_id", 1)
+			if err != nil {
+				t.Fatalf("Failed to increment counter: %v", err)
+			}
+
+			open, err := repo.CountOpen()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if open != tt.expected {
+				t.Errorf("Expected %d open tasks, got %d", tt.expected, open)
+			}
+		})
+	}
+}
+
+func TestTaskRepoNextOpen(t *testing.T) {
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type taskSpec struct {
+		id        int64
+		done      bool
+		createdAt time.Time
+	}
+
+	tests := []struct {
+		name       string
+		tasks      []taskSpec
+		expectedID int64
+		expectErr  error
+	}{
+		{
+			name:      "no tasks",
+			expectErr: ErrNotFound,
+		},
+		{
+			name: "all done",
+			tasks: []taskSpec{
+				{id: 1, done: true, createdAt: base},
+				{id: 2, done: true, createdAt: base.Add(time.Hour)},
+			},
+			expectErr: ErrNotFound,
+		},
+		{
+			name: "oldest open task",
+			tasks: []taskSpec{
+				{id: 1, done: true, createdAt: base},
+				{id: 2, createdAt: base.Add(2 * time.Hour)},
+				{id: 3, createdAt: base.Add(time.Hour)},
+			},
+			expectedID: 3,
+		},
+		{
+			name: "same created at, lowest id",
+			tasks: []taskSpec{
+				{id: 5, createdAt: base},
+				{id: 4, createdAt: base},
+				{id: 6, createdAt: base},
+			},
+			expectedID: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewTaskRepo(testutil.NewMapKV())
+
+			for _, spec := range tt.tasks {
+				task := domain.NewTask(spec.id, "Task", "Description")
+				task.Done = spec.done
+				task.CreatedAt = spec.createdAt
+
+				err := repo.Insert(task)
+				if err != nil {
+					t.Fatalf("Failed to insert task: %v", err)
+				}
+			}
+
+			task, err := repo.NextOpen()
+			if tt.expectErr != nil {
+				if !errors.Is(err, tt.expectErr) {
+					t.Errorf("Expected error %v, got %v", tt.expectErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if task.ID != tt.expectedID {
+				t.Errorf("Expected task %d, got %d", tt.expectedID, task.ID)
+			}
+		})
+	}
+}
+
+func TestTaskRepoVacuum(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	for i := range 50 {
+		task := domain.NewTask(int64(i+1), "Task", "Description")
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+
+		err = repo.Delete(task.ID)
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+	}
+
+	before, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+
+	reclaimed, err := repo.Vacuum()
+	if err != nil {
+		t.Fatalf("Vacuum failed: %v", err)
+	}
+
+	if reclaimed <= 0 {
+		t.Errorf("Expected positive reclaimed bytes, got %d", reclaimed)
+	}
+
+	after, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Failed to get stats: %v", err)
+	}
+
+	if after.FileSize >= before.FileSize {
+		t.Errorf("Expected file size to shrink, got %d before and %d after", before.FileSize, after.FileSize)
+	}
+	if before.FileSize-after.FileSize != reclaimed {
+		t.Errorf("Expected reclaimed %d, got %d", before.FileSize-after.FileSize, reclaimed)
+	}
+}
+
+func TestTaskRepoWithTx(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	err := repo.Insert(domain.NewTask(1, "Task 1", "Description"))
+	if err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+
+	t.Run("error leaves store unchanged", func(t *testing.T) {
+		txErr := errors.New("tx error")
+
+		err := repo.WithTx(func(tx *Tx) error {
+			err := tx.Insert(domain.NewTask(2, "Task 2", "Description"))
+			if err != nil {
+				return err
+			}
+
+			err = tx.Delete(1)
+			if err != nil {
+				return err
+			}
+
+			return txErr
+		})
+		if !errors.Is(err, txErr) {
+			t.Errorf("Expected tx error, got %v", err)
+		}
+
+		tasks, err := repo.GetAll()
+		if err != nil {
+			t.Fatalf("Failed to get tasks: %v", err)
+		}
+		if len(tasks) != 1 || tasks[0].ID != 1 {
+			t.Errorf("Expected only task 1 to exist, got %v", tasks)
+		}
+	})
+
+	t.Run("success applies all writes", func(t *testing.T) {
+		err := repo.WithTx(func(tx *Tx) error {
+			err := tx.Insert(domain.NewTask(2, "Task 2", "Description"))
+			if err != nil {
+				return err
+			}
+
+			task, err := tx.Get(1)
+			if err != nil {
+				return err
+			}
+
+			task.Title = "Updated"
+			return tx.Update(task)
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		task, err := repo.Get(1)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if task.Title != "Updated" {
+			t.Errorf("Expected title 'Updated', got '%s'", task.Title)
+		}
+
+		_, err = repo.Get(2)
+		if err != nil {
+			t.Errorf("Expected task 2 to exist, got %v", err)
+		}
+	})
+
+	t.Run("insert of existing task fails", func(t *testing.T) {
+		err := repo.WithTx(func(tx *Tx) error {
+			return tx.Insert(domain.NewTask(1, "Task", "Description"))
+		})
+		if !errors.Is(err, ErrAlreadyExists) {
+			t.Errorf("Expected ErrAlreadyExists, got %v", err)
+		}
+	})
+}
+
+func TestTaskRepoUpdateFields(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	err := repo.Insert(domain.NewTask(1, "Task", ""))
+	if err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+
+	t.Run("concurrent updates are serialized", func(t *testing.T) {
+		const workers, updates = 10, 20
+
+		var wg sync.WaitGroup
+		for w := range workers {
+			wg.Go(func() {
+				for range updates {
+					err := repo.UpdateFields(1, func(task *domain.Task) error {
+						task.Position++
+						if w%2 == 0 {
+							task.Tags = append(task.Tags, "even")
+						}
+						return nil
+					})
+					if err != nil {
+						t.Errorf("UpdateFields failed: %v", err)
+					}
+				}
+			})
+		}
+		wg.Wait()
+
+		task, err := repo.Get(1)
+		if err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+
+		if task.Position != workers*updates {
+			t.Errorf("Expected position %d, got %d", workers*updates, task.Position)
+		}
+		if len(task.Tags) != workers/2*updates {
+			t.Errorf("Expected %d tags, got %d", workers/2*updates, len(task.Tags))
+		}
+	})
+
+	t.Run("mutate error discards the update", func(t *testing.T) {
+		mutateErr := errors.New("invalid task")
+		err := repo.UpdateFields(1, func(task *domain.Task) error {
+			task.Title = "Changed"
+			return mutateErr
+		})
+		if !errors.Is(err, mutateErr) {
+			t.Errorf("Expected mutate error, got %v", err)
+		}
+
+		task, err := repo.Get(1)
+		if err != nil {
+			t.Fatalf("Failed to get: %v", err)
+		}
+		if task.Title != "Task" {
+			t.Errorf("Expected title to stay 'Task', got %q", task.Title)
+		}
+	})
+
+	t.Run("returns ErrNotFound for missing task", func(t *testing.T) {
+		err := repo.UpdateFields(999, func(task *domain.Task) error { return nil })
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestTaskRepoIncrCounter(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	t.Run("starts at zero", func(t *testing.T) {
+		n, err := repo.IncrCounter("first", 5)
+		if err != nil {
+			t.Fatalf("IncrCounter failed: %v", err)
+		}
+
+		if n != 5 {
+			t.Errorf("Expected 5, got %d", n)
+		}
+	})
+
+	t.Run("concurrent increments sum correctly", func(t *testing.T) {
+		const workers, increments = 10, 100
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Go(func() {
+				for range increments {
+					_, err := repo.IncrCounter("concurrent", 1)
+					if err != nil {
+						t.Errorf("IncrCounter failed: %v", err)
+					}
+				}
+			})
+		}
+		wg.Wait()
+
+		n, err := repo.IncrCounter("concurrent", 0)
+		if err != nil {
+			t.Fatalf("IncrCounter failed: %v", err)
+		}
+
+		if n != workers*increments {
+			t.Errorf("Expected %d, got %d", workers*increments, n)
+		}
+	})
+
+	t.Run("counters are not tasks", func(t *testing.T) {
+		tasks, err := repo.GetAll()
+		if err != nil {
+			t.Fatalf("GetAll failed: %v", err)
+		}
+
+		if len(tasks) != 0 {
+			t.Errorf("Expected no tasks, got %d", len(tasks))
+		}
+	})
+}
+
+func TestTaskRepoNextID(t *testing.T) {
+	insert := func(t *testing.T, repo *TaskRepo, id int64) {
+		t.Helper()
+
+		err := repo.WithTx(func(tx *Tx) error {
+			return tx.Insert(domain.NewTask(id, "Task", "Description"))
+		})
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	t.Run("continues after ids stored without the counter", func(t *testing.T) {
+		db, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		repo := NewTaskRepo(db)
+
+		err := repo.Insert(domain.NewTask(5, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+
+		id, err := repo.NextID()
+		if err != nil {
+			t.Fatalf("NextID failed: %v", err)
+		}
+		if id != 6 {
+			t.Errorf("Expected id 6, got %d", id)
+		}
+	})
+
+	t.Run("inserted ids advance the counter", func(t *testing.T) {
+		db, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		repo := NewTaskRepo(db)
+
+		first, err := repo.NextID()
+		if err != nil {
+			t.Fatalf("NextID failed: %v", err)
+		}
+
+		insert(t, repo, 100)
+		insert(t, repo, 50)
+
+		next, err := repo.NextID()
+		if err != nil {
+			t.Fatalf("NextID failed: %v", err)
+		}
+		if first != 1 || next != 101 {
+			t.Errorf("Expected ids 1 and 101, got %d and %d", first, next)
+		}
+	})
+
+	t.Run("returns error on overflow", func(t *testing.T) {
+		db, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		repo := NewTaskRepo(db)
+
+		insert(t, repo, domain.MaxTaskID)
+
+		_, err := repo.NextID()
+		if !errors.Is(err, ErrIDOverflow) {
+			t.Errorf("Expected ErrIDOverflow, got %v", err)
+		}
+	})
+
+	t.Run("rejects ids above the largest one", func(t *testing.T) {
+		db, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		repo := NewTaskRepo(db)
+
+		err := repo.WithTx(func(tx *Tx) error {
+			return tx.Insert(domain.NewTask(math.MaxInt64, "Task", "Description"))
+		})
+		if !errors.Is(err, ErrIDTooLarge) {
+			t.Errorf("Expected ErrIDTooLarge, got %v", err)
+		}
+
+		id, err := repo.NextID()
+		if err != nil || id != 1 {
+			t.Errorf("Expected id 1, got %d(err: %v)", id, err)
+		}
+	})
+}
+
+func TestTaskRepoNextPosition(t *testing.T) {
+	nextPosition := func(t *testing.T, repo *TaskRepo) int {
+		t.Helper()
+
+		var position int
+		err := repo.WithTx(func(tx *Tx) error {
+			var err error
+			position, err = tx.NextPosition()
+			return err
+		})
+		if err != nil {
+			t.Fatalf("NextPosition failed: %v", err)
+		}
+
+		return position
+	}
+
+	t.Run("continues after positions stored without the counter", func(t *testing.T) {
+		db, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		repo := NewTaskRepo(db)
+
+		task := domain.NewTask(1, "Task", "Description")
+		task.Position = 7
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+
+		if position := nextPosition(t, repo); position != 8 {
+			t.Errorf("Expected position 8, got %d", position)
+		}
+	})
+
+	t.Run("inserted positions advance the counter", func(t *testing.T) {
+		db, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		repo := NewTaskRepo(db)
+
+		err := repo.WithTx(func(tx *Tx) error {
+			for id, position := range []int{3, 2} {
+				task := domain.NewTask(int64(id+1), "Task", "Description")
+				task.Position = position
+
+				err := tx.Insert(task)
+				if err != nil {
+					return err
+				}
+			}
+
+			return tx.Delete(1)
+		})
+		if err != nil {
+			t.Fatalf("Failed to insert tasks: %v", err)
+		}
+
+		// the counter is kept after the last task is deleted
+		if position := nextPosition(t, repo); position != 4 {
+			t.Errorf("Expected position 4, got %d", position)
+		}
+	})
+}
+
+func TestTaskRepoTitleIndex(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	hasOpenTitle := func(t *testing.T, title string) bool {
+		t.Helper()
+
+		var exists bool
+		err := repo.WithTx(func(tx *Tx) error {
+			var err error
+			exists, err = tx.HasOpenTitle(title)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("HasOpenTitle failed: %v", err)
+		}
+
+		return exists
+	}
+
+	// stored before the index is kept
+	err := repo.Insert(domain.NewTask(1, "Task A", "Description"))
+	if err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+
+	if !hasOpenTitle(t, " task a ") {
+		t.Error("Expected title of stored task to be indexed")
+	}
+	if !db.Has(titleKey("Task A")) {
+		t.Error("Expected title index key to be stored")
+	}
+
+	task := domain.NewTask(1, "Task B", "Description")
+	err = repo.Update(task)
+	if err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	if hasOpenTitle(t, "Task A") || !hasOpenTitle(t, "Task B") {
+		t.Error("Expected index to follow the title change")
+	}
+	if db.Has(titleKey("Task A")) {
+		t.Error("Expected empty title index key to be deleted")
+	}
+
+	task.Done = true
+	err = repo.Update(task)
+	if err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	if hasOpenTitle(t, "Task B") {
+		t.Error("Expected done task not to be indexed")
+	}
+
+	err = repo.Insert(domain.NewTask(2, "Task B", "Description"))
+	if err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+	err = repo.Delete(2)
+	if err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	if hasOpenTitle(t, "Task B") {
+		t.Error("Expected deleted task not to be indexed")
+	}
+}
+
+func TestTaskRepoKeys(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	err := repo.Insert(domain.NewTask(1, "Test", "Test"))
+	if err != nil {
+		t.Fatalf("Failed to insert: %v", err)
+	}
+	err = repo.InsertIdempotencyRecord("abc", domain.NewTask(1, "Test", "Test"), time.Now())
+	if err != nil {
+		t.Fatalf("Failed to insert idempotency record: %v", err)
+	}
+	_, err = repo.IncrCounter("task_id", 1)
+	if err != nil {
+		t.Fatalf("Failed to increment counter: %v", err)
+	}
+
+	keys, err := repo.Keys("")
+	if err != nil {
+		t.Fatalf("Failed to list keys: %v", err)
+	}
+
+	expected := []string{"1", counterKeyPrefix + "task_id", idempotencyKeyPrefix + "abc"}
+	if len(keys) != len(expected) {
+		t.Fatalf("Expected keys %v, got %+v", expected, keys)
+	}
+	for i, key := range keys {
+		if key.Key != expected[i] || key.Size == 0 || key.Task != (i == 0) {
+			t.Errorf("Unexpected key %+v, expected %s", key, expected[i])
+		}
+	}
+
+	keys, err = repo.Keys(idempotencyKeyPrefix)
+	if err != nil || len(keys) != 1 {
+		t.Errorf("Expected 1 idempotency key, got %+v(%v)", keys, err)
+	}
+
+	err = repo.DeleteKey(idempotencyKeyPrefix + "abc")
+	if err != nil {
+		t.Fatalf("Failed to delete key: %v", err)
+	}
+	if db.Has(idempotencyKeyPrefix + "abc") {
+		t.Error("Expected key to be deleted")
+	}
+
+	err = repo.DeleteKey(idempotencyKeyPrefix + "abc")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	for _, key := range []string{counterKeyPrefix + taskIDCounter, "1", titleIndexKey, "unknown"} {
+		err = repo.DeleteKey(key)
+		if !errors.Is(err, ErrProtectedKey) {
+			t.Errorf("Expected ErrProtectedKey for %s, got %v", key, err)
+		}
+	}
+}
+
+func TestTaskRepoStores(t *testing.T) {
+	stores := []struct {
+		name  string
+		setup func(t *testing.T) KV
+	}{
+		{
+			name: "inmemorydb",
+			setup: func(t *testing.T) KV {
+				db, cleanup := setupTestEnvironment(t)
+				t.Cleanup(cleanup)
+				return db
+			},
+		},
+		{
+			name: "map",
+			setup: func(t *testing.T) KV {
+				return testutil.NewMapKV()
+			},
+		},
+	}
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			repo := NewTaskRepo(store.setup(t))
+
+			err := repo.Insert(domain.NewTask(1, "First", "Description"))
+			if err != nil {
+				t.Fatalf("Insert failed: %v", err)
+			}
+
+			task, err := repo.Get(1)
+			if err != nil || task.Title != "First" {
+				t.Fatalf("Expected task 'First', got %v(%v)", task, err)
+			}
+
+			_, err = repo.IncrCounter("tasks", 1)
+			if err != nil {
+				t.Fatalf("IncrCounter failed: %v", err)
+			}
+
+			errTx := errors.New("tx error")
+			err = repo.WithTx(func(tx *Tx) error {
+				err := tx.Insert(domain.NewTask(2, "Second", "Description"))
+				if err != nil {
+					return err
+				}
+				return errTx
+			})
+			if !errors.Is(err, errTx) {
+				t.Fatalf("Expected tx error, got %v", err)
+			}
+
+			err = repo.WithTx(func(tx *Tx) error {
+				err := tx.Insert(domain.NewTask(3, "Third", "Description"))
+				if err != nil {
+					return err
+				}
+
+				if err := tx.Insert(domain.NewTask(1, "Duplicate", "Description")); !errors.Is(err, ErrAlreadyExists) {
+					t.Errorf("Expected ErrAlreadyExists, got %v", err)
+				}
+
+				return tx.Delete(1)
+			})
+			if err != nil {
+				t.Fatalf("WithTx failed: %v", err)
+			}
+
+			tasks, err := repo.GetAll()
+			if err != nil {
+				t.Fatalf("GetAll failed: %v", err)
+			}
+
+			if len(tasks) != 1 || tasks[0].ID != 3 {
+				t.Errorf("Expected only task 3, got %v", tasks)
+			}
+
+			err = repo.Delete(1)
+			if !errors.Is(err, ErrNotFound) {
+				t.Errorf("Expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+
+	t.Run("map store doesn't support compaction", func(t *testing.T) {
+		repo := NewTaskRepo(testutil.NewMapKV())
+
+		_, err := repo.Vacuum()
+		if !errors.Is(err, ErrNotSupported) {
+			t.Errorf("Expected ErrNotSupported, got %v", err)
+		}
+	})
+}
+
+func TestTaskRepoCheckIntegrity(t *testing.T) {
+	db, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	repo := NewTaskRepo(db)
+
+	for _, id := range []int64{1, 2, 3} {
+		err := repo.Insert(domain.NewTask(id, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+	err := db.PutObject(counterKeyPrefix+"task_id", []byte("3"))
+	if err != nil {
+		t.Fatalf("Failed to put counter: %v", err)
+	}
+
+	t.Run("clean store", func(t *testing.T) {
+		report, err := repo.CheckIntegrity()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := IntegrityReport{Tasks: 3}
+		if !reflect.DeepEqual(report, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, report)
+		}
+	})
+
+	t.Run("corrupt tasks are flagged", func(t *testing.T) {
+		for _, key := range []string{"2", trashKey(4)} {
+			err := db.PutObject(key, []byte{taskSchemaMarker, taskSchemaVersion, 0xff, 0x01})
+			if err != nil {
+				t.Fatalf("Failed to put task: %v", err)
+			}
+		}
+
+		report, err := repo.CheckIntegrity()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		expected := IntegrityReport{Tasks: 4, Corrupt: 2, CorruptKeys: []string{"2", "trash:4"}}
+		if !reflect.DeepEqual(report, expected) {
+			t.Errorf("Expected %+v, got %+v", expected, report)
+		}
+		if report.CorruptRatio() != 0.5 {
+			t.Errorf("Expected corrupt ratio 0.5, got %v", report.CorruptRatio())
+		}
+	})
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+)
+
+// titleKeyPrefix namespaces the title index: the key of a title holds the ids of the
+// open tasks with it, so HasOpenTitle doesn't scan all tasks.
+const titleKeyPrefix = "title:"
+
+// titleIndexKey marks that the title index is kept. Stores written before it was kept
+// don't have it, then the index is built by the first HasOpenTitle.
+const titleIndexKey = titleKeyPrefix + "index"
+
+// titleKey returns the index key of the title. Titles are compared ignoring surrounding
+// whitespace and case, and hashed, so long titles fit into a key.
+func titleKey(title string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(title))))
+	return titleKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// HasOpenTitle reports whether an open task has the title, ignoring surrounding
+// whitespace and case.
+func (tx *Tx) HasOpenTitle(title string) (bool, error) {
+	err := tx.buildTitleIndex()
+	if err != nil {
+		return false, err
+	}
+
+	ids, err := tx.titleIDs(titleKey(title))
+	if err != nil {
+		return false, err
+	}
+
+	// the ids are checked, so a stale index entry(e.g. of a deleted corrupt task)
+	// is not reported, as well as a different title with the same hash
+	for _, id := range ids {
+		task, err := tx.Get(id)
+		switch {
+		case errors.Is(err, ErrNotFound), errors.Is(err, ErrCorrupt):
+			continue
+		case err != nil:
+			return false, err
+		}
+
+		if !task.Done && strings.EqualFold(strings.TrimSpace(task.Title), strings.TrimSpace(title)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// buildTitleIndex indexes the titles of all open tasks, unless the index is kept already.
+func (tx *Tx) buildTitleIndex() error {
+	if tx.batch.Has(titleIndexKey) {
+		return nil
+	}
+
+	index := make(map[string][]int64)
+	err := tx.batch.ForEach(func(key string, value []byte) error {
+		if !isTaskKey(key) {
+			return nil
+		}
+
+		task, err := decodeTask(value)
+		if err != nil {
+			// corrupt tasks can't be indexed, they are reported by CheckIntegrity
+			if errors.Is(err, ErrCorrupt) {
+				return nil
+			}
+			return err
+		}
+
+		if !task.Done {
+			key := titleKey(task.Title)
+			index[key] = append(index[key], task.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// written after the iteration, as the store must not be modified during it
+	for key, ids := range index {
+		tx.putTitleIDs(key, ids)
+	}
+	tx.batch.PutObject(titleIndexKey, nil)
+
+	return nil
+}
+
+// reindexTitle updates the title index for the task changed from old to task(nil
+// for an inserted or deleted one). Only open tasks are indexed.
+func (tx *Tx) reindexTitle(old, task *domain.Task) error {
+	if !tx.batch.Has(titleIndexKey) {
+		return nil
+	}
+
+	var oldKey, newKey string
+	if old != nil && !old.Done {
+		oldKey = titleKey(old.Title)
+	}
+	if task != nil && !task.Done {
+		newKey = titleKey(task.Title)
+	}
+	if oldKey == newKey {
+		return nil
+	}
+
+	if oldKey != "" {
+		ids, err := tx.titleIDs(oldKey)
+		if err != nil {
+			return err
+		}
+
+		tx.putTitleIDs(oldKey, slices.DeleteFunc(ids, func(id int64) bool {
+			return id == old.ID
+		}))
+	}
+
+	if newKey != "" {
+		ids, err := tx.titleIDs(newKey)
+		if err != nil {
+			return err
+		}
+
+		if !slices.Contains(ids, task.ID) {
+			tx.putTitleIDs(newKey, append(ids, task.ID))
+		}
+	}
+
+	return nil
+}
+
+// titleIDs returns the ids stored at the title index key.
+func (tx *Tx) titleIDs(key string) ([]int64, error) {
+	obj, err := tx.batch.GetObject(key)
+	switch {
+	case errors.Is(err, inmemorydb.ErrNotFound):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var ids []int64
+	for s := range strings.SplitSeq(string(obj), ",") {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: title index %s: %w", ErrCorrupt, key, err)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// putTitleIDs stores the ids at the title index key, deleting the key if there are none.
+func (tx *Tx) putTitleIDs(key string, ids []int64) {
+	if len(ids) == 0 {
+		// the key may be missing, if the ids are already empty
+		_ = tx.batch.DeleteObject(key)
+		return
+	}
+
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.FormatInt(id, 10)
+	}
+
+	tx.batch.PutObject(key, []byte(strings.Join(s, ",")))
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+)
+
+// trashKeyPrefix namespaces deleted tasks kept in the trash, so they are skipped
+// by task reads and the task key can't clash with them.
+const trashKeyPrefix = "trash:"
+
+// trashKey returns the key of the task with the given id in the trash.
+func trashKey(id int64) string {
+	return trashKeyPrefix + strconv.FormatInt(id, 10)
+}
+
+// trashID returns the task id of the trash key, ok is false for other keys.
+func trashID(key string) (id int64, ok bool) {
+	idStr, found := strings.CutPrefix(key, trashKeyPrefix)
+	if !found {
+		return 0, false
+	}
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	return id, err == nil
+}
+
+// GetTrash returns the tasks in the trash sorted by id.
+func (r *TaskRepo) GetTrash() ([]*domain.Task, error) {
+	tasks := make([]*domain.Task, 0)
+
+	err := r.db.ForEach(func(key string, value []byte) error {
+		if _, ok := trashID(key); !ok {
+			return nil
+		}
+
+		task, err := decodeTask(value)
+		if err != nil {
+			return err
+		}
+
+		tasks = append(tasks, task)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortByID(tasks)
+	return tasks, nil
+}
+
+// Trash moves the task to the trash, setting its DeletedAt. A task with the same
+// id already in the trash is replaced. Returns ErrNotFound if the task does not exist.
+func (tx *Tx) Trash(id int64, deletedAt time.Time) error {
+	task, err := tx.Get(id)
+	if err != nil {
+		return err
+	}
+
+	task.DeletedAt = &deletedAt
+
+	obj, err := encodeTask(task)
+	if err != nil {
+		return err
+	}
+
+	tx.batch.PutObject(trashKey(id), obj)
+	return tx.Delete(id)
+}
+
+// RestoreFromTrash moves the task back from the trash, clearing its DeletedAt.
+// Returns ErrNotFound if the task is not in the trash and ErrAlreadyExists if
+// a task with the same id was created since.
+func (tx *Tx) RestoreFromTrash(id int64) (*domain.Task, error) {
+	obj, err := tx.batch.GetObject(trashKey(id))
+	if err != nil {
+		switch {
+		case errors.Is(err, inmemorydb.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	task, err := decodeTask(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	task.DeletedAt = nil
+
+	err = tx.Insert(task)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.batch.DeleteObject(trashKey(id))
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// PurgeTrash permanently removes the tasks deleted before the given time from the
+// trash and returns the number of removed tasks.
+func (tx *Tx) PurgeTrash(deletedBefore time.Time) (int, error) {
+	var expired []string
+	err := tx.batch.ForEach(func(key string, value []byte) error {
+		if _, ok := trashID(key); !ok {
+			return nil
+		}
+
+		task, err := decodeTask(value)
+		if err != nil {
+			return err
+		}
+
+		if task.DeletedAt == nil || task.DeletedAt.Before(deletedBefore) {
+			expired = append(expired, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// keys are deleted after the iteration, as the store must not be modified during it
+	for _, key := range expired {
+		err = tx.batch.DeleteObject(key)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(expired), nil
+}
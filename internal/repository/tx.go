@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+)
+
+// Tx groups several task writes, so they are applied all together or not at all.
+// Reads made through Tx see its own pending writes.
+type Tx struct {
+	batch txStore
+}
+
+// WithTx runs fn in a transaction. Writes made through tx are committed atomically
+// if fn returns nil and discarded otherwise.
+//
+// The store is locked while fn runs, so fn must use only tx to access tasks.
+// On stores without batch support transactions are serialized with each other and
+// the writes are applied one by one, so they are not atomic against other writes.
+func (r *TaskRepo) WithTx(fn func(tx *Tx) error) error {
+	if b, ok := r.db.(batcher); ok {
+		return b.Batch(func(b *inmemorydb.Batch) error {
+			return fn(&Tx{batch: b})
+		})
+	}
+
+	r.txMutex.Lock()
+	defer r.txMutex.Unlock()
+
+	staged := newStagedTx(r.db)
+
+	err := fn(&Tx{batch: staged})
+	if err != nil {
+		return err
+	}
+
+	return staged.commit()
+}
+
+// UpdateFields loads the task, calls mutate on it and stores the result in one
+// transaction, so concurrent updates of the task don't overwrite each other.
+// If mutate returns an error(e.g. the mutated task is invalid), nothing is
+// stored and the error is returned. Returns ErrNotFound if the task does not exist.
+//
+// mutate runs with the store locked, so it must not access the repository.
+func (r *TaskRepo) UpdateFields(id int64, mutate func(task *domain.Task) error) error {
+	return r.WithTx(func(tx *Tx) error {
+		task, err := tx.Get(id)
+		if err != nil {
+			return err
+		}
+
+		err = mutate(task)
+		if err != nil {
+			return err
+		}
+
+		return tx.Update(task)
+	})
+}
+
+func (tx *Tx) Get(id int64) (*domain.Task, error) {
+	obj, err := tx.batch.GetObject(strconv.FormatInt(id, 10))
+	if err != nil {
+		switch {
+		case errors.Is(err, inmemorydb.ErrNotFound):
+			return nil, ErrNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return decodeTask(obj)
+}
+
+// GetAll returns all tasks sorted by id, including the ones changed in the transaction.
+func (tx *Tx) GetAll() ([]*domain.Task, error) {
+	tasks := make([]*domain.Task, 0)
+
+	err := tx.batch.ForEach(func(key string, value []byte) error {
+		if !isTaskKey(key) {
+			return nil
+		}
+
+		task, err := decodeTask(value)
+		if err != nil {
+			return err
+		}
+
+		tasks = append(tasks, task)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortByID(tasks)
+	return tasks, nil
+}
+
+var (
+	// ErrIDOverflow is returned by NextID when the largest task id(domain.MaxTaskID)
+	// is already taken.
+	ErrIDOverflow = errors.New("task id overflow")
+	// ErrIDTooLarge is returned by Insert for ids greater than domain.MaxTaskID.
+	ErrIDTooLarge = errors.New("task id is too large")
+)
+
+// taskIDCounter is the counter holding the largest task id, generated or inserted.
+const taskIDCounter = "task_id"
+
+// NextID returns a new task id, greater than ids of the existing tasks and of
+// the previously generated ones, so ids of deleted tasks are not reused.
+// Returns ErrIDOverflow if there is no greater id.
+func (tx *Tx) NextID() (int64, error) {
+	last, err := tx.lastID()
+	if err != nil {
+		return 0, err
+	}
+	if last >= domain.MaxTaskID {
+		return 0, ErrIDOverflow
+	}
+
+	id := last + 1
+	tx.batch.PutObject(counterKeyPrefix+taskIDCounter, []byte(strconv.FormatInt(id, 10)))
+
+	return id, nil
+}
+
+// lastID returns the largest task id kept by the counter. Stores written before the
+// counter was kept don't have it, then the id is found among the stored tasks.
+func (tx *Tx) lastID() (int64, error) {
+	obj, err := tx.batch.GetObject(counterKeyPrefix + taskIDCounter)
+	switch {
+	case err == nil:
+		return strconv.ParseInt(string(obj), 10, 64)
+	case !errors.Is(err, inmemorydb.ErrNotFound):
+		return 0, err
+	}
+
+	// trashed tasks keep their ids to be restored
+	var last int64
+	err = tx.batch.ForEach(func(key string, _ []byte) error {
+		id, err := strconv.ParseInt(key, 10, 64)
+		if err == nil {
+			last = max(last, id)
+		}
+		if id, ok := trashID(key); ok {
+			last = max(last, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return last, nil
+}
+
+// NextID returns a new task id in its own transaction(see Tx.NextID).
+func (r *TaskRepo) NextID() (int64, error) {
+	var id int64
+	err := r.WithTx(func(tx *Tx) error {
+		var err error
+		id, err = tx.NextID()
+		return err
+	})
+
+	return id, err
+}
+
+// taskPositionCounter is the counter holding the largest position of an inserted task.
+const taskPositionCounter = "task_position"
+
+// NextPosition returns the position after the positions of the existing tasks,
+// so a task inserted with it is placed last.
+func (tx *Tx) NextPosition() (int, error) {
+	last, err := tx.lastPosition()
+	if err != nil {
+		return 0, err
+	}
+
+	return last + 1, nil
+}
+
+// lastPosition returns the largest task position kept by the counter. Like lastID,
+// it is found among the stored tasks if the store doesn't have the counter yet.
+func (tx *Tx) lastPosition() (int, error) {
+	obj, err := tx.batch.GetObject(counterKeyPrefix + taskPositionCounter)
+	switch {
+	case err == nil:
+		return strconv.Atoi(string(obj))
+	case !errors.Is(err, inmemorydb.ErrNotFound):
+		return 0, err
+	}
+
+	tasks, err := tx.GetAll()
+	if err != nil {
+		return 0, err
+	}
+
+	var last int
+	for _, t := range tasks {
+		last = max(last, t.Position)
+	}
+
+	return last, nil
+}
+
+// Insert stores a new task as is(keeping its version). Returns ErrAlreadyExists if a
+// task with the same id exists. Ids greater than the last one(e.g. sent by clients)
+// advance the NextID counter, positions greater than the last one advance the
+// NextPosition counter. Returns ErrIDTooLarge for ids greater than domain.MaxTaskID,
+// so they can't exhaust the counter.
+func (tx *Tx) Insert(task *domain.Task) error {
+	if task.ID > domain.MaxTaskID {
+		return ErrIDTooLarge
+	}
+
+	key := strconv.FormatInt(task.ID, 10)
+	if tx.batch.Has(key) {
+		return ErrAlreadyExists
+	}
+
+	last, err := tx.lastID()
+	if err != nil {
+		return err
+	}
+	if task.ID > last {
+		tx.batch.PutObject(counterKeyPrefix+taskIDCounter, []byte(key))
+	}
+
+	lastPosition, err := tx.lastPosition()
+	if err != nil {
+		return err
+	}
+	if task.Position > lastPosition {
+		tx.batch.PutObject(counterKeyPrefix+taskPositionCounter, []byte(strconv.Itoa(task.Position)))
+	}
+
+	return tx.put(task)
+}
+
+// Update stores an existing task as is, the caller increments the version.
+// Returns ErrNotFound if the task does not exist.
+func (tx *Tx) Update(task *domain.Task) error {
+	key := strconv.FormatInt(task.ID, 10)
+	if !tx.batch.Has(key) {
+		return ErrNotFound
+	}
+
+	return tx.put(task)
+}
+
+func (tx *Tx) Delete(id int64) error {
+	old, err := tx.stored(id)
+	if err != nil {
+		return err
+	}
+
+	err = tx.batch.DeleteObject(strconv.FormatInt(id, 10))
+	if err != nil {
+		switch {
+		case errors.Is(err, inmemorydb.ErrNotFound):
+			return ErrNotFound
+		default:
+			return err
+		}
+	}
+
+	return tx.reindexTitle(old, nil)
+}
+
+func (tx *Tx) put(task *domain.Task) error {
+	obj, err := encodeTask(task)
+	if err != nil {
+		return err
+	}
+
+	old, err := tx.stored(task.ID)
+	if err != nil {
+		return err
+	}
+
+	tx.batch.PutObject(strconv.FormatInt(task.ID, 10), obj)
+	return tx.reindexTitle(old, task)
+}
+
+// stored returns the stored task with the id to be reindexed, nil if it's missing
+// or corrupt(then it's not indexed).
+func (tx *Tx) stored(id int64) (*domain.Task, error) {
+	task, err := tx.Get(id)
+	switch {
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrCorrupt):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	return task, nil
+}
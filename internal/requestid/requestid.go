@@ -0,0 +1,51 @@
+// Package requestid generates request ids and passes them in the request context,
+// so logs and responses of the same request can be correlated.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the request and response header carrying the request id.
+const Header = "X-Request-ID"
+
+// maxLength limits the length of the request id accepted from clients.
+const maxLength = 64
+
+type contextKey struct{}
+
+// New returns a random request id(32 hex symbols).
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether id can be used as a request id: 1 to 64 letters, digits, '-', '_' or '.'.
+// Ids from clients are checked, so they are safe to log and send back.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewContext returns a copy of ctx with the request id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request id from ctx, or an empty string if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
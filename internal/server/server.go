@@ -5,64 +5,94 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
-	"os"
 	"os/signal"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"github.com/vladgrskkh/todo/config"
 )
 
+// ErrShutdownTimeout is returned by Serve when requests didn't finish within the
+// shutdown timeout, so graceful shutdown was aborted.
+var ErrShutdownTimeout = errors.New("graceful shutdown timed out")
+
 type Server struct {
 	logger *slog.Logger
 	srv    *http.Server
+
+	shutdownTimeout time.Duration
+
+	// inFlight is the number of requests being processed.
+	inFlight atomic.Int64
 }
 
 func New(logger *slog.Logger, cfg *config.Config, routes http.Handler) *Server {
-	srv := &http.Server{
-		Addr:         fmt.Sprintf(":%d", cfg.Port),
-		Handler:      routes,
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+	s := &Server{
+		logger:          logger,
+		shutdownTimeout: cfg.ShutdownTimeout,
 	}
-	return &Server{
-		logger: logger,
-		srv:    srv,
+
+	s.srv = &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           s.trackInFlight(routes),
+		IdleTimeout:       time.Minute,
+		ReadTimeout:       10 * time.Second,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      30 * time.Second,
 	}
+
+	return s
 }
 
+// Serve listens on the configured address and serves requests until SIGTERM or SIGINT,
+// then shuts the server down gracefully. Returns an error wrapping ErrShutdownTimeout
+// if requests didn't finish within the shutdown timeout.
 func (s *Server) Serve() error {
-	shutdownError := make(chan error)
+	// catch signals
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
 
-	go func() {
-		quit := make(chan os.Signal, 1)
-		// catch signals
-		signal.Notify(quit, syscall.SIGTERM, syscall.SIGINT)
+	ln, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return fmt.Errorf("error while starting server: %w", err)
+	}
 
-		sig := <-quit
+	return s.serve(ctx, ln)
+}
 
-		s.logger.Info("shutting down server", slog.String("signal", sig.String()))
+// serve serves requests on ln until ctx is done, then shuts the server down.
+func (s *Server) serve(ctx context.Context, ln net.Listener) error {
+	shutdownError := make(chan error, 1)
 
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
+	go func() {
+		<-ctx.Done()
 
-		err := s.srv.Shutdown(ctx)
-		if err != nil {
-			shutdownError <- err
-		}
+		s.logger.Info("shutting down server", slog.String("cause", context.Cause(ctx).Error()))
 
-		shutdownError <- nil
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+		defer cancel()
 
+		shutdownError <- s.srv.Shutdown(shutdownCtx)
 	}()
 
-	err := s.srv.ListenAndServe()
+	err := s.srv.Serve(ln)
 	if !errors.Is(err, http.ErrServerClosed) {
 		return fmt.Errorf("error while starting server: %w", err)
 	}
 
 	err = <-shutdownError
+	if errors.Is(err, context.DeadlineExceeded) {
+		unfinished := s.inFlight.Load()
+		s.logger.Error("graceful shutdown timed out", slog.Int64("unfinished_requests", unfinished))
+
+		// dropping connections of the unfinished requests
+		_ = s.srv.Close()
+
+		return fmt.Errorf("%w: %d requests unfinished", ErrShutdownTimeout, unfinished)
+	}
 	if err != nil {
 		return fmt.Errorf("error while shutting down server: %w", err)
 	}
@@ -70,3 +100,14 @@ func (s *Server) Serve() error {
 	s.logger.Info("server stopped", slog.String("addr", s.srv.Addr))
 	return nil
 }
+
+// trackInFlight counts requests being processed, so the number of unfinished
+// requests can be reported when shutdown times out.
+func (s *Server) trackInFlight(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/vladgrskkh/todo/config"
+)
+
+func TestNew(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("applies read header timeout from config", func(t *testing.T) {
+		cfg := &config.Config{Port: 8080, ReadHeaderTimeout: 3 * time.Second}
+
+		s := New(logger, cfg, http.NewServeMux())
+
+		if s.srv.ReadHeaderTimeout != 3*time.Second {
+			t.Errorf("Expected read header timeout %s, got %s", 3*time.Second, s.srv.ReadHeaderTimeout)
+		}
+		if s.srv.Addr != ":8080" {
+			t.Errorf("Expected addr ':8080', got '%s'", s.srv.Addr)
+		}
+	})
+}
+
+func TestServe(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	// start serves handler and returns the server address, cancel function
+	// triggering shutdown and the channel with the serve result.
+	start := func(t *testing.T, shutdownTimeout time.Duration, handler http.Handler) (string, context.CancelFunc, <-chan error) {
+		t.Helper()
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+
+		s := New(logger, &config.Config{ShutdownTimeout: shutdownTimeout}, handler)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		result := make(chan error, 1)
+		go func() {
+			result <- s.serve(ctx, ln)
+		}()
+
+		return "http://" + ln.Addr().String(), cancel, result
+	}
+
+	t.Run("clean shutdown returns nil", func(t *testing.T) {
+		_, shutdown, result := start(t, time.Second, http.NewServeMux())
+
+		shutdown()
+
+		select {
+		case err := <-result:
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Serve didn't return after shutdown")
+		}
+	})
+
+	t.Run("returns timeout error for unfinished requests", func(t *testing.T) {
+		entered := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(entered)
+			<-release
+		})
+
+		addr, shutdown, result := start(t, 50*time.Millisecond, handler)
+
+		go func() {
+			resp, err := http.Get(addr)
+			if err == nil {
+				resp.Body.Close()
+			}
+		}()
+
+		<-entered
+		shutdown()
+
+		select {
+		case err := <-result:
+			if !errors.Is(err, ErrShutdownTimeout) {
+				t.Errorf("Expected ErrShutdownTimeout, got %v", err)
+			}
+			if err != nil && err.Error() != "graceful shutdown timed out: 1 requests unfinished" {
+				t.Errorf("Unexpected error message: %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Serve didn't return after shutdown timeout")
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"log/slog"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+)
+
+// EventType is the kind of task change reported to observers.
+type EventType string
+
+const (
+	EventCreated EventType = "created"
+	EventUpdated EventType = "updated"
+	EventDeleted EventType = "deleted"
+)
+
+// TaskEvent describes a stored task change. Task is the task after the change,
+// for EventDeleted it is the task as it was before the deletion.
+type TaskEvent struct {
+	Type EventType
+	Task *domain.Task
+}
+
+// Observer is notified about task changes, e.g. to send webhooks or invalidate caches.
+// OnTaskEvent is called synchronously after the change is stored, so it should be fast
+// (slow work belongs in a goroutine) and must not modify the task. Errors are logged
+// and don't fail the operation.
+type Observer interface {
+	OnTaskEvent(event TaskEvent) error
+}
+
+// ObserverFunc adapts a function to the Observer interface.
+type ObserverFunc func(event TaskEvent) error
+
+func (f ObserverFunc) OnTaskEvent(event TaskEvent) error {
+	return f(event)
+}
+
+// WithObservers registers observers notified about task changes in the given order.
+func WithObservers(observers ...Observer) Option {
+	return func(s *TodoService) {
+		s.observers = append(s.observers, observers...)
+	}
+}
+
+// notify reports the change of the tasks to all observers, logging their errors.
+func (s *TodoService) notify(eventType EventType, tasks ...*domain.Task) {
+	for _, task := range tasks {
+		event := TaskEvent{Type: eventType, Task: task}
+
+		for _, observer := range s.observers {
+			err := observer.OnTaskEvent(event)
+			if err != nil {
+				s.logger.Error("task observer failed",
+					slog.String("event", string(eventType)),
+					slog.Int64("id", task.ID),
+					slog.String("error", err.Error()))
+			}
+		}
+	}
+}
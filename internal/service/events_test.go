@@ -0,0 +1,121 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/handlers/dto"
+)
+
+func TestTodoServiceObservers(t *testing.T) {
+	t.Run("observer receives task events", func(t *testing.T) {
+		logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		var events []TaskEvent
+		observer := ObserverFunc(func(event TaskEvent) error {
+			events = append(events, event)
+			return nil
+		})
+		service := NewTodoService(logger, repo, WithObservers(observer))
+
+		err := service.CreateTask(domain.NewTask(1, "Task", ""))
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+
+		_, err = service.UpdateTask(1, dto.UpdateTaskInput{Title: "Updated"})
+		if err != nil {
+			t.Fatalf("Failed to update task: %v", err)
+		}
+
+		err = service.DeleteTask(1)
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+
+		expected := []struct {
+			eventType EventType
+			title     string
+			version   int
+		}{
+			{EventCreated, "Task", 1},
+			{EventUpdated, "Updated", 2},
+			{EventDeleted, "Updated", 2},
+		}
+
+		if len(events) != len(expected) {
+			t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+		}
+		for i, e := range expected {
+			got := events[i]
+			if got.Type != e.eventType || got.Task.ID != 1 || got.Task.Title != e.title || got.Task.Version != e.version {
+				t.Errorf("Expected event %d to be %s of task 1 %q v%d, got %s of task %d %q v%d",
+					i, e.eventType, e.title, e.version, got.Type, got.Task.ID, got.Task.Title, got.Task.Version)
+			}
+		}
+	})
+
+	t.Run("failed operation sends no event", func(t *testing.T) {
+		logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		called := false
+		service := NewTodoService(logger, repo, WithObservers(ObserverFunc(func(TaskEvent) error {
+			called = true
+			return nil
+		})))
+
+		err := service.CreateTask(domain.NewTask(1, "", ""))
+		if err == nil {
+			t.Fatal("Expected validation error")
+		}
+
+		if called {
+			t.Error("Expected observer not to be called")
+		}
+	})
+
+	t.Run("observer error doesn't fail the operation", func(t *testing.T) {
+		var logs bytes.Buffer
+		logger := slog.New(slog.NewJSONHandler(&logs, nil))
+
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		failing := ObserverFunc(func(TaskEvent) error {
+			return errors.New("webhook unavailable")
+		})
+		var notified int
+		counting := ObserverFunc(func(TaskEvent) error {
+			notified++
+			return nil
+		})
+		service := NewTodoService(logger, repo, WithObservers(failing, counting))
+
+		err := service.CreateTask(domain.NewTask(1, "Task", ""))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		_, err = service.GetTask(1)
+		if err != nil {
+			t.Errorf("Expected task to be stored, got %v", err)
+		}
+		if notified != 1 {
+			t.Errorf("Expected the next observer to be notified once, got %d", notified)
+		}
+		if !strings.Contains(logs.String(), "webhook unavailable") {
+			t.Errorf("Expected observer error to be logged, got %s", logs.String())
+		}
+	})
+}
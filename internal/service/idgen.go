@@ -0,0 +1,78 @@
+package service
+
+import (
+	"sync"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/repository"
+)
+
+// IDGenerator assigns ids to tasks created by the server(e.g. DuplicateTask).
+// Next is called concurrently and must return ids greater than zero.
+type IDGenerator interface {
+	Next() (int64, error)
+}
+
+// WithIDGenerator sets the generator of server-assigned task ids.
+// The default is MonotonicIDGenerator over the service repository.
+func WithIDGenerator(ids IDGenerator) Option {
+	return func(s *TodoService) {
+		s.ids = ids
+	}
+}
+
+// MonotonicIDGenerator generates sequential ids from the counter persisted in the
+// repository(see repository.TaskRepo.NextID), so it continues where it stopped after
+// a restart and never reuses ids of deleted tasks.
+type MonotonicIDGenerator struct {
+	repo *repository.TaskRepo
+}
+
+func NewMonotonicIDGenerator(repo *repository.TaskRepo) *MonotonicIDGenerator {
+	return &MonotonicIDGenerator{repo: repo}
+}
+
+func (g *MonotonicIDGenerator) Next() (int64, error) {
+	return g.repo.NextID()
+}
+
+// idEpoch is the start of timestamp ids. With a recent epoch ids stay below 2^53,
+// so they are exact in JavaScript numbers, for about 70 years.
+var idEpoch = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// idSequenceBits is the number of low bits of timestamp ids holding a sequence
+// number for ids generated in the same millisecond.
+const idSequenceBits = 12
+
+// TimestampIDGenerator generates time-sortable ids(like Snowflake ids): milliseconds
+// since 2025-01-01 shifted left by idSequenceBits, the low bits counting ids generated
+// in the same millisecond. Ids are strictly increasing within the process, when the
+// sequence overflows the next millisecond is borrowed.
+//
+// Nothing is persisted, so after a restart with the clock moved back ids can repeat
+// (creating the task fails with ErrTaskExists).
+type TimestampIDGenerator struct {
+	mu    sync.Mutex
+	last  int64
+	clock Clock
+}
+
+// NewTimestampIDGenerator returns a TimestampIDGenerator taking the time from clock,
+// nil uses time.Now.
+func NewTimestampIDGenerator(clock Clock) *TimestampIDGenerator {
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	return &TimestampIDGenerator{clock: clock}
+}
+
+func (g *TimestampIDGenerator) Next() (int64, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := max(g.clock.Now().Sub(idEpoch).Milliseconds(), 0)
+	g.last = max(g.last+1, ms<<idSequenceBits)
+
+	return g.last, nil
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/repository"
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+)
+
+func TestMonotonicIDGenerator(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	next := func(t *testing.T, n int) []int64 {
+		t.Helper()
+
+		db, err := inmemorydb.Open(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to open db: %v", err)
+		}
+		defer func() {
+			err := db.Close()
+			if err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+		}()
+
+		gen := NewMonotonicIDGenerator(repository.NewTaskRepo(db))
+
+		ids := make([]int64, n)
+		for i := range ids {
+			ids[i], err = gen.Next()
+			if err != nil {
+				t.Fatalf("Next failed: %v", err)
+			}
+		}
+
+		return ids
+	}
+
+	ids := next(t, 3)
+	ids = append(ids, next(t, 3)...)
+
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("Expected strictly increasing ids across restart, got %v", ids)
+		}
+	}
+	if ids[0] != 1 {
+		t.Errorf("Expected first id 1, got %d", ids[0])
+	}
+}
+
+func TestTimestampIDGenerator(t *testing.T) {
+	clock := &fakeClock{now: idEpoch.Add(time.Hour)}
+	gen := NewTimestampIDGenerator(clock)
+
+	t.Run("ids in the same millisecond are increasing", func(t *testing.T) {
+		first, _ := gen.Next()
+		second, _ := gen.Next()
+
+		if first != time.Hour.Milliseconds()<<idSequenceBits {
+			t.Errorf("Expected id %d, got %d", time.Hour.Milliseconds()<<idSequenceBits, first)
+		}
+		if second != first+1 {
+			t.Errorf("Expected id %d, got %d", first+1, second)
+		}
+	})
+
+	t.Run("ids are sorted by time", func(t *testing.T) {
+		before, _ := gen.Next()
+		clock.Advance(time.Millisecond)
+		after, _ := gen.Next()
+
+		if after>>idSequenceBits != before>>idSequenceBits+1 {
+			t.Errorf("Expected id of the next millisecond, got %d after %d", after, before)
+		}
+	})
+
+	t.Run("clock moved back keeps ids increasing", func(t *testing.T) {
+		before, _ := gen.Next()
+		clock.Advance(-time.Second)
+		after, _ := gen.Next()
+
+		if after <= before {
+			t.Errorf("Expected id greater than %d, got %d", before, after)
+		}
+	})
+}
+
+// fixedIDs is an IDGenerator returning the given ids in order.
+type fixedIDs []int64
+
+func (ids *fixedIDs) Next() (int64, error) {
+	id := (*ids)[0]
+	*ids = (*ids)[1:]
+	return id, nil
+}
+
+func TestTodoServiceIDGenerator(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	ids := fixedIDs{42}
+	service := NewTodoService(logger, repo, WithIDGenerator(&ids))
+
+	err := service.CreateTask(domain.NewTask(1, "Task", ""))
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	task, err := service.DuplicateTask(1)
+	if err != nil {
+		t.Fatalf("Failed to duplicate task: %v", err)
+	}
+
+	if task.ID != 42 {
+		t.Errorf("Expected generated id 42, got %d", task.ID)
+	}
+}
+
+func TestTodoServiceIDGeneratorAfterValidation(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	ids := fixedIDs{42}
+	service := NewTodoService(logger, repo, WithIDGenerator(&ids),
+		WithValidationLimits(domain.ValidationLimits{MaxTitleLength: 10, MaxDescriptionLength: 100}))
+
+	err := service.CreateTask(domain.NewTask(1, "Long title", ""))
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	// the copy title is too long
+	_, err = service.DuplicateTask(1)
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+
+	if len(ids) != 1 {
+		t.Errorf("Expected no id to be generated for invalid task, %d left", len(ids))
+	}
+}
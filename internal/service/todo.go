@@ -1,30 +1,138 @@
 package service
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/vladgrskkh/todo/internal/domain"
 	"github.com/vladgrskkh/todo/internal/handlers/dto"
 	"github.com/vladgrskkh/todo/internal/repository"
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+	"github.com/vladgrskkh/todo/pkg/mergepatch"
 	"github.com/vladgrskkh/todo/pkg/validator"
 )
 
 var (
-	ErrInvalidID  = fmt.Errorf("invalid id param")
-	ErrTaskExists = fmt.Errorf("task with this id already exists")
+	ErrInvalidID        = fmt.Errorf("invalid id param")
+	ErrTaskExists       = fmt.Errorf("task with this id already exists")
+	ErrDuplicateTitle   = fmt.Errorf("open task with this title already exists")
+	ErrInvalidTimeRange = fmt.Errorf("invalid time range: start must not be after end")
+	ErrInvalidPatch     = fmt.Errorf("invalid merge patch")
+	ErrVersionMismatch  = fmt.Errorf("task version does not match")
 )
 
+// idempotencyTTL is how long the result of a create request with Idempotency-Key is remembered.
+const idempotencyTTL = 24 * time.Hour
+
+// Clock is a source of the current time, used wherever timestamps are set.
+type Clock interface {
+	Now() time.Time
+}
+
+// realClock is the default Clock using time.Now.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// allTasksCall is a GetAllTasks repository read shared by concurrent callers.
+type allTasksCall struct {
+	done  chan struct{}
+	tasks []*domain.Task
+	err   error
+}
+
 type TodoService struct {
 	logger   *slog.Logger
 	taskRepo *repository.TaskRepo
+
+	// idempotencyMu serializes idempotent creates, so concurrent retries
+	// with the same key don't create the task twice.
+	idempotencyMu sync.Mutex
+
+	// allTasksCall is the in-flight GetAllTasks call shared by concurrent callers.
+	// Writes reset it, so reads started after a write don't get stale tasks.
+	allTasksMu   sync.Mutex
+	allTasksCall *allTasksCall
+
+	limits domain.ValidationLimits
+	clock  Clock
+
+	// uniqueTitles makes creating a task fail if an open task has the same title.
+	uniqueTitles bool
+
+	// trashTTL is how long deleted tasks are kept in the trash, 0 deletes them permanently.
+	trashTTL time.Duration
+
+	// ids generates ids of tasks created by the server.
+	ids IDGenerator
+
+	// observers are notified about task changes, see WithObservers.
+	observers []Observer
+
+	// integrity is the result of the last CheckIntegrity call.
+	integrity atomic.Pointer[repository.IntegrityReport]
+}
+
+// Option configures optional TodoService settings.
+type Option func(*TodoService)
+
+// WithValidationLimits sets limits used to validate tasks on create and update.
+func WithValidationLimits(limits domain.ValidationLimits) Option {
+	return func(s *TodoService) {
+		s.limits = limits
+	}
+}
+
+// WithClock sets the time source used by the service.
+func WithClock(clock Clock) Option {
+	return func(s *TodoService) {
+		s.clock = clock
+	}
+}
+
+// WithUniqueTitles makes creating a task fail with ErrDuplicateTitle if an open task
+// has the same title, ignoring surrounding whitespace and case.
+func WithUniqueTitles(enabled bool) Option {
+	return func(s *TodoService) {
+		s.uniqueTitles = enabled
+	}
 }
 
-func NewTodoService(logger *slog.Logger, taskRepo *repository.TaskRepo) *TodoService {
-	return &TodoService{
+// WithTrashTTL makes deleted tasks move to the trash, where they can be restored
+// until PurgeTrash removes them ttl after the deletion. 0(default) deletes tasks permanently.
+func WithTrashTTL(ttl time.Duration) Option {
+	return func(s *TodoService) {
+		s.trashTTL = ttl
+	}
+}
+
+func NewTodoService(logger *slog.Logger, taskRepo *repository.TaskRepo, opts ...Option) *TodoService {
+	s := &TodoService{
 		logger:   logger,
 		taskRepo: taskRepo,
+		limits:   domain.DefaultValidationLimits(),
+		clock:    realClock{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.ids == nil {
+		s.ids = NewMonotonicIDGenerator(taskRepo)
 	}
+
+	return s
 }
 
 func (s *TodoService) GetTask(id int64) (*domain.Task, error) {
@@ -40,66 +148,1031 @@ func (s *TodoService) GetTask(id int64) (*domain.Task, error) {
 	return task, nil
 }
 
+// CountOpenTasks returns the number of open tasks.
+func (s *TodoService) CountOpenTasks() (int, error) {
+	return s.taskRepo.CountOpen()
+}
+
+// GetNextTask returns the oldest open task.
+func (s *TodoService) GetNextTask() (*domain.Task, error) {
+	task, err := s.taskRepo.NextOpen()
+	if err != nil {
+		return nil, fmt.Errorf("error getting next task: %w", err)
+	}
+
+	return task, nil
+}
+
+// GetAllTasks returns all tasks. Concurrent calls share a single repository read.
+// Callers get their own slice, but the tasks are shared and must not be modified.
 func (s *TodoService) GetAllTasks() ([]*domain.Task, error) {
-	return s.taskRepo.GetAll()
+	s.allTasksMu.Lock()
+	call := s.allTasksCall
+	if call != nil {
+		s.allTasksMu.Unlock()
+		<-call.done
+	} else {
+		call = &allTasksCall{done: make(chan struct{})}
+		s.allTasksCall = call
+		s.allTasksMu.Unlock()
+
+		s.readAllTasks(call)
+	}
+
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	return slices.Clone(call.tasks), nil
+}
+
+func (s *TodoService) readAllTasks(call *allTasksCall) {
+	defer close(call.done)
+
+	call.tasks, call.err = s.taskRepo.GetAll()
+
+	s.allTasksMu.Lock()
+	if s.allTasksCall == call {
+		s.allTasksCall = nil
+	}
+	s.allTasksMu.Unlock()
+}
+
+// invalidateAllTasks detaches the in-flight GetAllTasks call after a write,
+// so later calls read the tasks again.
+func (s *TodoService) invalidateAllTasks() {
+	s.allTasksMu.Lock()
+	s.allTasksCall = nil
+	s.allTasksMu.Unlock()
+}
+
+// SearchTasks returns the tasks matching all of the filter fields in one pass.
+// Zero filter returns all tasks. DueWithin is counted from the clock time.
+func (s *TodoService) SearchTasks(filter domain.TaskFilter) ([]*domain.Task, error) {
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && filter.CreatedFrom.After(filter.CreatedTo) {
+		return nil, ErrInvalidTimeRange
+	}
+
+	if filter.DueWithin > 0 {
+		now := s.clock.Now()
+		filter.DueFrom, filter.DueTo = now, now.Add(filter.DueWithin)
+	}
+
+	tasks, err := s.GetAllTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	return filter.Apply(tasks), nil
+}
+
+// GetTaskBoard returns the tasks matching the filter grouped by status.
+func (s *TodoService) GetTaskBoard(filter domain.TaskFilter) (domain.TaskBoard, error) {
+	tasks, err := s.SearchTasks(filter)
+	if err != nil {
+		return domain.TaskBoard{}, err
+	}
+
+	return domain.GroupByStatus(tasks), nil
+}
+
+// GetTaskHistory returns the snapshots of the task since the last storage compaction,
+// oldest first.
+func (s *TodoService) GetTaskHistory(id int64) ([]*domain.Task, error) {
+	if id < 1 {
+		return nil, ErrInvalidID
+	}
+
+	history, err := s.taskRepo.History(id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting history of task with %d id: %w", id, err)
+	}
+
+	return history, nil
+}
+
+// GetTasksDueToday returns tasks due on the current local day(in the clock location),
+// from midnight to midnight, sorted by due date.
+func (s *TodoService) GetTasksDueToday() ([]*domain.Task, error) {
+	startOfDay, startOfNextDay := dayBounds(s.clock.Now())
+
+	// GetDueBetween includes the upper bound, the next midnight belongs to the next day
+	return s.taskRepo.GetDueBetween(startOfDay, startOfNextDay.Add(-time.Nanosecond))
+}
+
+// dayBounds returns the start of the day t falls on and the start of the next day,
+// in t's location.
+func dayBounds(t time.Time) (time.Time, time.Time) {
+	year, month, day := t.Date()
+	startOfDay := time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+
+	return startOfDay, startOfDay.AddDate(0, 0, 1)
+}
+
+// GetDailyStats returns the numbers of tasks created and completed on each of the last
+// days local days(in the clock location), including today, oldest first. Tasks are
+// counted by CreatedAt, done tasks as completed by UpdatedAt.
+func (s *TodoService) GetDailyStats(days int) ([]domain.DailyStats, error) {
+	tasks, err := s.taskRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.clock.Now()
+	startOfDay, _ := dayBounds(now)
+
+	// days are matched by date, as with DST not every day is 24h long
+	stats := make([]domain.DailyStats, days)
+	index := make(map[string]int, days)
+	for i := range stats {
+		date := startOfDay.AddDate(0, 0, i-days+1).Format(time.DateOnly)
+		stats[i].Date = date
+		index[date] = i
+	}
+
+	for _, task := range tasks {
+		if i, ok := index[task.CreatedAt.In(now.Location()).Format(time.DateOnly)]; ok {
+			stats[i].Created++
+		}
+
+		if !task.Done {
+			continue
+		}
+		if i, ok := index[task.UpdatedAt.In(now.Location()).Format(time.DateOnly)]; ok {
+			stats[i].Completed++
+		}
+	}
+
+	return stats, nil
+}
+
+// GetTaskStats returns task counts. Done tasks updated since the start of the current
+// local day(in the clock location) are counted as completed today.
+func (s *TodoService) GetTaskStats() (domain.TaskStats, error) {
+	tasks, err := s.taskRepo.GetAll()
+	if err != nil {
+		return domain.TaskStats{}, err
+	}
+
+	startOfDay, startOfNextDay := dayBounds(s.clock.Now())
+
+	stats := domain.TaskStats{Total: len(tasks)}
+	for _, task := range tasks {
+		if !task.Done {
+			stats.Open++
+			continue
+		}
+
+		stats.Done++
+		if !task.UpdatedAt.Before(startOfDay) && task.UpdatedAt.Before(startOfNextDay) {
+			stats.CompletedToday++
+		}
+	}
+
+	return stats, nil
+}
+
+// GetTagCounts returns all tags with the number of tasks using them,
+// sorted by count descending, then by name.
+func (s *TodoService) GetTagCounts() ([]domain.TagCount, error) {
+	tasks, err := s.taskRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, task := range tasks {
+		// counting each tag once per task
+		seen := make(map[string]struct{}, len(task.Tags))
+		for _, tag := range task.Tags {
+			if _, ok := seen[tag]; ok {
+				continue
+			}
+			seen[tag] = struct{}{}
+			counts[tag]++
+		}
+	}
+
+	tags := make([]domain.TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, domain.TagCount{Name: name, Count: count})
+	}
+
+	slices.SortFunc(tags, func(a, b domain.TagCount) int {
+		if a.Count != b.Count {
+			return b.Count - a.Count
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return tags, nil
 }
 
 func (s *TodoService) CreateTask(task *domain.Task) error {
-	validator := validator.New()
+	return s.createTask(task, false, nil)
+}
+
+// DuplicateTask creates a copy of the task with a new server-generated id,
+// " (copy)" appended to the title and done reset to false.
+func (s *TodoService) DuplicateTask(id int64) (*domain.Task, error) {
+	if id < 1 {
+		return nil, ErrInvalidID
+	}
+
+	original, err := s.taskRepo.Get(id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting task with %d id: %w", id, err)
+	}
 
-	domain.ValidateTask(validator, task)
+	task := domain.NewTask(0, original.Title+" (copy)", original.Description)
+	task.Subtasks = slices.Clone(original.Subtasks)
+	task.Tags = slices.Clone(original.Tags)
+	task.DueDate = original.DueDate
+	task.Priority = original.Priority
+
+	err = s.createTask(task, true, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// createTask validates and stores a new task. If generateID is true, the task id
+// is assigned by the service IDGenerator. If record is not nil, it is called in the
+// transaction after the task is inserted, to store related data with the task.
+func (s *TodoService) createTask(task *domain.Task, generateID bool, record func(tx *repository.Tx) error) error {
+	now := s.clock.Now()
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	// new tasks always start with version 1, only RestoreTasks keeps the given one
+	task.Version = 1
+
+	// generated ids are allocated after validation, so invalid tasks don't use them up
+	validator := validator.New()
+	if generateID {
+		domain.ValidateTaskFields(validator, task, s.limits)
+	} else {
+		domain.ValidateTaskWithLimits(validator, task, s.limits)
+	}
 
 	if !validator.Valid() {
 		return validator
 	}
 
-	// checking if task with this id already exists
-	_, err := s.taskRepo.Get(task.ID)
-	if err == nil {
-		return ErrTaskExists
+	if generateID {
+		id, err := s.ids.Next()
+		if err != nil {
+			return fmt.Errorf("error generating task id: %w", err)
+		}
+		task.ID = id
 	}
 
-	err = s.taskRepo.Insert(task)
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		if s.uniqueTitles {
+			exists, err := tx.HasOpenTitle(task.Title)
+			if err != nil {
+				return err
+			}
+
+			if exists {
+				return ErrDuplicateTitle
+			}
+		}
+
+		// new tasks are placed after all existing ones
+		position, err := tx.NextPosition()
+		if err != nil {
+			return err
+		}
+		task.Position = position
+
+		err = tx.Insert(task)
+		if err != nil || record == nil {
+			return err
+		}
+
+		return record(tx)
+	})
+	s.invalidateAllTasks()
 	if err != nil {
-		return err
+		switch {
+		case errors.Is(err, repository.ErrAlreadyExists):
+			return ErrTaskExists
+		default:
+			return err
+		}
 	}
 
+	s.notify(EventCreated, task)
 	return nil
 }
 
+// CreateTaskIdempotent creates a task like CreateTask, but remembers the result under the
+// provided idempotency key. Repeated calls with the same key within idempotencyTTL return
+// the originally created task instead of creating a new one.
+// The returned bool reports whether the result was replayed.
+func (s *TodoService) CreateTaskIdempotent(key string, task *domain.Task) (*domain.Task, bool, error) {
+	s.idempotencyMu.Lock()
+	defer s.idempotencyMu.Unlock()
+
+	stored, createdAt, err := s.taskRepo.GetIdempotencyRecord(key)
+	switch {
+	case err == nil && s.clock.Now().Sub(createdAt) < idempotencyTTL:
+		return stored, true, nil
+	case err == nil:
+		// the expired record is removed even if the create below fails
+		err = s.taskRepo.DeleteIdempotencyRecord(key)
+		if err != nil {
+			return nil, false, fmt.Errorf("error deleting idempotency record: %w", err)
+		}
+	case !errors.Is(err, repository.ErrNotFound):
+		return nil, false, fmt.Errorf("error getting idempotency record: %w", err)
+	}
+
+	// the record is stored with the task, so a task is never created without it
+	err = s.createTask(task, false, func(tx *repository.Tx) error {
+		return tx.InsertIdempotencyRecord(key, task, task.CreatedAt)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return task, false, nil
+}
+
 func (s *TodoService) UpdateTask(id int64, input dto.UpdateTaskInput) (*domain.Task, error) {
-	// checking if task with this id already exists
-	task, err := s.taskRepo.Get(id)
+	return s.updateTask(id, input)
+}
+
+// UpsertTask creates the task with the given id from input if it doesn't exist,
+// otherwise updates it like UpdateTask. Reports whether the task was created.
+func (s *TodoService) UpsertTask(id int64, input dto.UpdateTaskInput) (*domain.Task, bool, error) {
+	if id < 1 {
+		return nil, false, ErrInvalidID
+	}
+
+	task, err := s.updateTask(id, input)
+	if !errors.Is(err, repository.ErrNotFound) {
+		return task, false, err
+	}
+
+	task = domain.NewTask(id, input.Title, input.Description)
+	task.Done = input.Done
+	task.Subtasks = input.Subtasks
+	task.Tags = input.Tags
+	task.DueDate = input.DueDate
+	task.Priority = input.Priority
+
+	err = s.createTask(task, false, nil)
+	if errors.Is(err, ErrTaskExists) {
+		// created concurrently, so it's replaced instead
+		return s.UpsertTask(id, input)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return task, true, nil
+}
+
+// PatchTask applies JSON Merge Patch(RFC 7386) to the updatable fields of the task
+// (the UpdateTaskInput representation) and updates the task with the merged result.
+// null resets the field to its zero value, absent fields are left untouched.
+// Patches with unknown or read-only fields return ErrInvalidPatch.
+// If version is not 0, the patch is only applied to the task with this version,
+// otherwise ErrVersionMismatch is returned.
+func (s *TodoService) PatchTask(id int64, patch []byte, version int) (*domain.Task, error) {
+	if id < 1 {
+		return nil, ErrInvalidID
+	}
+
+	// the task is read, patched and stored under the store lock, so concurrent
+	// patches are applied one after another
+	var patched *domain.Task
+	err := s.taskRepo.UpdateFields(id, func(task *domain.Task) error {
+		if version != 0 && task.Version != version {
+			return ErrVersionMismatch
+		}
+
+		current, err := json.Marshal(dto.UpdateTaskInput{
+			Title:       task.Title,
+			Description: task.Description,
+			Done:        task.Done,
+			Subtasks:    task.Subtasks,
+			Tags:        task.Tags,
+			DueDate:     task.DueDate,
+			Priority:    task.Priority,
+		})
+		if err != nil {
+			return err
+		}
+
+		merged, err := mergepatch.Apply(current, patch)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		var input dto.UpdateTaskInput
+
+		dec := json.NewDecoder(bytes.NewReader(merged))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&input)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		patched = task
+		return s.applyUpdate(task, input)
+	})
+	s.invalidateAllTasks()
 	if err != nil {
 		return nil, err
 	}
 
+	s.notify(EventUpdated, patched)
+	return patched, nil
+}
+
+// applyUpdate replaces the task fields with the input and validates the task.
+func (s *TodoService) applyUpdate(task *domain.Task, input dto.UpdateTaskInput) error {
 	validator := validator.New()
 
 	task.Update(validator, input.Title, input.Description, input.Done)
-	domain.ValidateTask(validator, task)
+	task.Subtasks = input.Subtasks
+	task.Tags = input.Tags
+	task.DueDate = input.DueDate
+	task.Priority = input.Priority
+	domain.ValidateTaskWithLimits(validator, task, s.limits)
+
+	if !validator.Valid() {
+		return validator
+	}
+
+	task.UpdatedAt = s.clock.Now()
+	return nil
+}
+
+// updateTask replaces the task fields with the input, validates and stores the task.
+// The task is read and stored in one transaction, so a task deleted or moved
+// concurrently is not brought back or overwritten with stale fields.
+// Returns repository.ErrNotFound if the task does not exist.
+func (s *TodoService) updateTask(id int64, input dto.UpdateTaskInput) (*domain.Task, error) {
+	var updated *domain.Task
+	err := s.taskRepo.UpdateFields(id, func(task *domain.Task) error {
+		updated = task
+		return s.applyUpdate(task, input)
+	})
+	s.invalidateAllTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(EventUpdated, updated)
+	return updated, nil
+}
+
+// MoveTask moves the task to the given position(starting from 1) and renumbers
+// the other tasks, so positions stay unique and contiguous. Position greater than
+// the number of tasks moves the task to the end. Every task with a changed position
+// gets a new version and is reported to observers. UpdatedAt of done tasks is kept,
+// as the stats count it as the completion time.
+func (s *TodoService) MoveTask(id int64, position int) (*domain.Task, error) {
+	if id < 1 {
+		return nil, ErrInvalidID
+	}
+
+	validator := validator.New()
+	validator.CheckCode(position >= 1, "position", "greater_than_zero", "must be greater than zero")
 
 	if !validator.Valid() {
 		return nil, validator
 	}
 
-	err = s.taskRepo.Insert(task)
+	var moved *domain.Task
+	var changed []*domain.Task
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		tasks, err := tx.GetAll()
+		if err != nil {
+			return err
+		}
+
+		domain.SortByPosition(tasks)
+
+		i := slices.IndexFunc(tasks, func(t *domain.Task) bool {
+			return t.ID == id
+		})
+		if i == -1 {
+			return repository.ErrNotFound
+		}
+
+		moved = tasks[i]
+		tasks = slices.Delete(tasks, i, i+1)
+		tasks = slices.Insert(tasks, min(position, len(tasks)+1)-1, moved)
+
+		for i, t := range tasks {
+			if t.Position == i+1 && t != moved {
+				continue
+			}
+
+			t.Position = i + 1
+			t.Version++
+			if !t.Done {
+				t.UpdatedAt = s.clock.Now()
+			}
+
+			err = tx.Update(t)
+			if err != nil {
+				return err
+			}
+
+			changed = append(changed, t)
+		}
+
+		return nil
+	})
+	s.invalidateAllTasks()
+	if err != nil {
+		return nil, fmt.Errorf("error moving task with %d id: %w", id, err)
+	}
+
+	s.notify(EventUpdated, changed...)
+	return moved, nil
+}
+
+// CompleteTasks marks all open tasks with the tag as done in one transaction and
+// returns the number of completed tasks. Empty tag matches all tasks.
+// Already done tasks are skipped, as completed tasks can't be modified.
+func (s *TodoService) CompleteTasks(tag string) (int, error) {
+	var completed []*domain.Task
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		tasks, err := tx.GetAll()
+		if err != nil {
+			return err
+		}
+
+		now := s.clock.Now()
+		for _, task := range tasks {
+			if task.Done || (tag != "" && !slices.Contains(task.Tags, tag)) {
+				continue
+			}
+
+			task.Done = true
+			task.Version++
+			task.UpdatedAt = now
+
+			err = tx.Update(task)
+			if err != nil {
+				return err
+			}
+
+			completed = append(completed, task)
+		}
+
+		return nil
+	})
+	s.invalidateAllTasks()
 	if err != nil {
-		return nil, fmt.Errorf("error updating task with %d id: %w", task.ID, err)
+		return 0, fmt.Errorf("error completing tasks: %w", err)
 	}
 
-	return task, nil
+	s.notify(EventUpdated, completed...)
+	return len(completed), nil
 }
 
+// maxBulkIDs limits the number of tasks changed by one bulk request.
+const maxBulkIDs = 1000
+
+// UpdateTasksStatus sets the done status of the tasks with the given ids in one
+// transaction and returns a result per id, in the order of ids.
+//
+// The update is best-effort: missing tasks are reported as notfound and tasks the
+// update can't be applied to(completed tasks can't be modified, including completing
+// them again) as conflict, the other tasks are still updated. Each task is updated
+// like by UpdateTask with only the done status changed.
+func (s *TodoService) UpdateTasksStatus(input dto.BulkStatusInput) ([]domain.BulkResult, error) {
+	v := validator.New()
+	v.CheckCode(len(input.IDs) > 0, "ids", "required", "must be provided")
+	v.CheckCode(len(input.IDs) <= maxBulkIDs, "ids", "too_many_items", "must not contain more than %d items", maxBulkIDs)
+	v.CheckCode(!slices.ContainsFunc(input.IDs, func(id domain.JSONID) bool { return id < 1 }),
+		"ids", "positive_integer", "must be a positive integer")
+	v.CheckCode(!hasDuplicates(input.IDs), "ids", "item_duplicate", "must not contain duplicates")
+	v.CheckCode(input.Done != nil, "done", "required", "must be provided")
+
+	if !v.Valid() {
+		return nil, v
+	}
+
+	results := make([]domain.BulkResult, 0, len(input.IDs))
+	var updated []*domain.Task
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		for _, id := range input.IDs {
+			task, err := tx.Get(int64(id))
+			if errors.Is(err, repository.ErrNotFound) {
+				results = append(results, domain.BulkResult{ID: id, Status: domain.BulkNotFound})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			err = s.applyUpdate(task, dto.UpdateTaskInput{
+				Title:       task.Title,
+				Description: task.Description,
+				Done:        *input.Done,
+				Subtasks:    task.Subtasks,
+				Tags:        task.Tags,
+				DueDate:     task.DueDate,
+				Priority:    task.Priority,
+			})
+			var validationErr *validator.Validator
+			if errors.As(err, &validationErr) {
+				results = append(results, domain.BulkResult{ID: id, Status: domain.BulkConflict, Errors: validationErr.Errors})
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			err = tx.Update(task)
+			if err != nil {
+				return err
+			}
+
+			updated = append(updated, task)
+			results = append(results, domain.BulkResult{ID: id, Status: domain.BulkUpdated})
+		}
+
+		return nil
+	})
+	s.invalidateAllTasks()
+	if err != nil {
+		return nil, fmt.Errorf("error updating tasks status: %w", err)
+	}
+
+	s.notify(EventUpdated, updated...)
+	return results, nil
+}
+
+// hasDuplicates reports whether ids contains an id more than once.
+func hasDuplicates(ids []domain.JSONID) bool {
+	seen := make(map[domain.JSONID]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			return true
+		}
+		seen[id] = struct{}{}
+	}
+
+	return false
+}
+
+// IterateTasks calls fn for every task in id order, reading one task at a time.
+// Iteration stops at the first error returned by fn.
+func (s *TodoService) IterateTasks(fn func(task *domain.Task) error) error {
+	return s.taskRepo.Iterate(fn)
+}
+
+// RestoreTasks stores the tasks as they are(with their ids, timestamps, versions and
+// positions) in one transaction, replacing existing tasks with the same ids, and
+// returns the number of restored tasks. Used to import a backup made by IterateTasks.
+// If any task is invalid, nothing is stored and the validation errors of all tasks
+// are returned keyed by "tasks[i].field".
+func (s *TodoService) RestoreTasks(tasks []*domain.Task) (int, error) {
+	v := validator.New()
+	for i, task := range tasks {
+		taskV := validator.New()
+		domain.ValidateTaskWithLimits(taskV, task, s.limits)
+		v.Merge(fmt.Sprintf("tasks[%d]", i), taskV)
+	}
+
+	if !v.Valid() {
+		return 0, v
+	}
+
+	var created, updated []*domain.Task
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		for _, task := range tasks {
+			err := tx.Insert(task)
+			if errors.Is(err, repository.ErrAlreadyExists) {
+				err = tx.Update(task)
+				if err != nil {
+					return err
+				}
+
+				updated = append(updated, task)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			created = append(created, task)
+		}
+
+		return nil
+	})
+	s.invalidateAllTasks()
+	if err != nil {
+		return 0, fmt.Errorf("error restoring tasks: %w", err)
+	}
+
+	s.notify(EventCreated, created...)
+	s.notify(EventUpdated, updated...)
+	return len(tasks), nil
+}
+
+// DeleteCompletedTasks deletes all done tasks in one transaction and returns
+// the number of deleted tasks. Open tasks are left untouched.
+func (s *TodoService) DeleteCompletedTasks() (int, error) {
+	var deleted []*domain.Task
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		tasks, err := tx.GetAll()
+		if err != nil {
+			return err
+		}
+
+		for _, task := range tasks {
+			if !task.Done {
+				continue
+			}
+
+			err = s.deleteTask(tx, task.ID)
+			if err != nil {
+				return err
+			}
+
+			deleted = append(deleted, task)
+		}
+
+		return nil
+	})
+	s.invalidateAllTasks()
+	if err != nil {
+		return 0, fmt.Errorf("error deleting completed tasks: %w", err)
+	}
+
+	s.notify(EventDeleted, deleted...)
+	return len(deleted), nil
+}
+
+// DeleteTask deletes the task, moving it to the trash if WithTrashTTL is set.
 func (s *TodoService) DeleteTask(id int64) error {
 	if id < 1 {
 		return ErrInvalidID
 	}
 
-	err := s.taskRepo.Delete(id)
+	var deleted *domain.Task
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		var err error
+		deleted, err = tx.Get(id)
+		if errors.Is(err, repository.ErrCorrupt) {
+			// corrupt tasks can't be moved to the trash, so they are deleted
+			// permanently, observers only get the id
+			deleted = &domain.Task{ID: id}
+			return tx.Delete(id)
+		}
+		if err != nil {
+			return err
+		}
+
+		return s.deleteTask(tx, id)
+	})
+	s.invalidateAllTasks()
 	if err != nil {
 		return fmt.Errorf("error deleting task with %d id: %w", id, err)
 	}
 
+	s.notify(EventDeleted, deleted)
+	return nil
+}
+
+// deleteTask moves the task to the trash, or deletes it permanently if the trash is disabled.
+func (s *TodoService) deleteTask(tx *repository.Tx, id int64) error {
+	if s.trashTTL > 0 {
+		return tx.Trash(id, s.clock.Now())
+	}
+
+	return tx.Delete(id)
+}
+
+// GetTrash returns the deleted tasks kept in the trash sorted by id.
+func (s *TodoService) GetTrash() ([]*domain.Task, error) {
+	tasks, err := s.taskRepo.GetTrash()
+	if err != nil {
+		return nil, fmt.Errorf("error getting trash: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// RestoreTask moves the task back from the trash. Returns repository.ErrNotFound if the
+// task is not in the trash(e.g. it was purged) and ErrTaskExists if a task with the same
+// id was created after the deletion.
+func (s *TodoService) RestoreTask(id int64) (*domain.Task, error) {
+	if id < 1 {
+		return nil, ErrInvalidID
+	}
+
+	var task *domain.Task
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		var err error
+		task, err = tx.RestoreFromTrash(id)
+		return err
+	})
+	s.invalidateAllTasks()
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrAlreadyExists):
+			return nil, ErrTaskExists
+		default:
+			return nil, fmt.Errorf("error restoring task with %d id: %w", id, err)
+		}
+	}
+
+	s.notify(EventCreated, task)
+	return task, nil
+}
+
+// PurgeTrash permanently removes the tasks kept in the trash longer than the trash TTL
+// and returns the number of removed tasks.
+func (s *TodoService) PurgeTrash() (int, error) {
+	var purged int
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		var err error
+		purged, err = tx.PurgeTrash(s.clock.Now().Add(-s.trashTTL))
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error purging trash: %w", err)
+	}
+
+	if purged > 0 {
+		s.logger.Info("trash purged", slog.Int("purged", purged))
+	}
+
+	return purged, nil
+}
+
+// PurgeIdempotencyRecords removes the idempotency records older than idempotencyTTL
+// and returns the number of removed records.
+func (s *TodoService) PurgeIdempotencyRecords() (int, error) {
+	var purged int
+	err := s.taskRepo.WithTx(func(tx *repository.Tx) error {
+		var err error
+		purged, err = tx.PurgeIdempotencyRecords(s.clock.Now().Add(-idempotencyTTL))
+		return err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error purging idempotency records: %w", err)
+	}
+
+	if purged > 0 {
+		s.logger.Info("idempotency records purged", slog.Int("purged", purged))
+	}
+
+	return purged, nil
+}
+
+// DBStats returns the task storage statistics.
+func (s *TodoService) DBStats() (inmemorydb.Stats, error) {
+	return s.taskRepo.Stats()
+}
+
+// Vacuum compacts the task storage and returns the number of bytes reclaimed.
+func (s *TodoService) Vacuum() (int64, error) {
+	reclaimed, _, err := s.CompactDB()
+	return reclaimed, err
+}
+
+// CompactDB compacts the task storage and returns the number of bytes reclaimed
+// with the storage statistics after the compaction.
+func (s *TodoService) CompactDB() (int64, inmemorydb.Stats, error) {
+	reclaimed, stats, err := s.taskRepo.Compact()
+	if err != nil {
+		return 0, inmemorydb.Stats{}, fmt.Errorf("error vacuuming database: %w", err)
+	}
+
+	s.logger.Info("database vacuumed", slog.Int64("reclaimed_bytes", reclaimed))
+
+	return reclaimed, stats, nil
+}
+
+// MigrateTasks rewrites tasks stored with an older schema in the current one and
+// returns the number of rewritten tasks.
+func (s *TodoService) MigrateTasks() (int, error) {
+	migrated, err := s.taskRepo.MigrateAll()
+	if err != nil {
+		return 0, fmt.Errorf("error migrating tasks: %w", err)
+	}
+
+	s.logger.Info("tasks migrated", slog.Int("migrated", migrated))
+
+	return migrated, nil
+}
+
+// CheckIntegrity decodes every stored task and reports the corrupt ones. The report
+// is kept and returned by IntegrityReport(e.g. for the healthcheck).
+func (s *TodoService) CheckIntegrity() (repository.IntegrityReport, error) {
+	report, err := s.taskRepo.CheckIntegrity()
+	if err != nil {
+		return repository.IntegrityReport{}, fmt.Errorf("error checking integrity: %w", err)
+	}
+
+	s.integrity.Store(&report)
+
+	return report, nil
+}
+
+// IntegrityReport returns the result of the last CheckIntegrity call, nil if there was none.
+func (s *TodoService) IntegrityReport() *repository.IntegrityReport {
+	return s.integrity.Load()
+}
+
+// GetRawTask returns the stored value of the task with its decoded form.
+func (s *TodoService) GetRawTask(id int64) (*repository.RawTask, error) {
+	if id < 1 {
+		return nil, ErrInvalidID
+	}
+
+	raw, err := s.taskRepo.GetRaw(id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting raw task with %d id: %w", id, err)
+	}
+
+	return raw, nil
+}
+
+// ListKeys returns the raw storage keys starting with prefix, including non-task ones.
+func (s *TodoService) ListKeys(prefix string) ([]repository.KeyInfo, error) {
+	keys, err := s.taskRepo.Keys(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("error listing keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// DeleteKey removes the raw storage key of an idempotency record or a trashed task.
+// Other keys(tasks, counters, etc.) are refused with repository.ErrProtectedKey.
+func (s *TodoService) DeleteKey(key string) error {
+	err := s.taskRepo.DeleteKey(key)
+	s.invalidateAllTasks()
+	if err != nil {
+		return fmt.Errorf("error deleting key %q: %w", key, err)
+	}
+
+	s.logger.Info("key deleted", slog.String("key", key))
+
 	return nil
 }
+
+// StartVacuum runs Vacuum every interval in background until the returned
+// stop function is called.
+func (s *TodoService) StartVacuum(interval time.Duration) (stop func()) {
+	return s.runEvery(interval, func() error {
+		_, err := s.Vacuum()
+		return err
+	})
+}
+
+// StartTrashSweeper runs PurgeTrash and PurgeIdempotencyRecords every interval in
+// background until the returned stop function is called.
+func (s *TodoService) StartTrashSweeper(interval time.Duration) (stop func()) {
+	return s.runEvery(interval, func() error {
+		_, trashErr := s.PurgeTrash()
+		_, recordsErr := s.PurgeIdempotencyRecords()
+		return errors.Join(trashErr, recordsErr)
+	})
+}
+
+// runEvery calls fn every interval in background, logging its errors, until the
+// returned stop function is called.
+func (s *TodoService) runEvery(interval time.Duration, fn func() error) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				err := fn()
+				if err != nil {
+					s.logger.Error(err.Error())
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
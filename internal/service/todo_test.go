@@ -5,11 +5,16 @@ import (
 	"io"
 	"log/slog"
 	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/vladgrskkh/todo/internal/domain"
 	"github.com/vladgrskkh/todo/internal/handlers/dto"
 	"github.com/vladgrskkh/todo/internal/repository"
+	"github.com/vladgrskkh/todo/internal/testutil"
 	"github.com/vladgrskkh/todo/pkg/inmemorydb"
 	"github.com/vladgrskkh/todo/pkg/validator"
 )
@@ -34,6 +39,38 @@ func setupTestEnvironment(t *testing.T) (*repository.TaskRepo, func()) {
 	}
 }
 
+// fakeClock is a Clock returning fixed time, that can be moved manually.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// pausingClock blocks the first Now call until release is closed or a short
+// timeout passes(the caller may hold the store lock the releaser waits for).
+type pausingClock struct {
+	once    sync.Once
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (c *pausingClock) Now() time.Time {
+	c.once.Do(func() {
+		close(c.entered)
+		select {
+		case <-c.release:
+		case <-time.After(50 * time.Millisecond):
+		}
+	})
+	return time.Now()
+}
+
 func TestTodoServiceGetTask(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
@@ -107,6 +144,161 @@ func TestTodoServiceGetTask(t *testing.T) {
 	})
 }
 
+// slowKV is a KV counting ForEach calls, which block until release is closed.
+type slowKV struct {
+	*testutil.MapKV
+
+	forEachCalls atomic.Int32
+	release      chan struct{}
+}
+
+func (kv *slowKV) ForEach(fn func(key string, value []byte) error) error {
+	kv.forEachCalls.Add(1)
+	<-kv.release
+
+	return kv.MapKV.ForEach(fn)
+}
+
+func TestTodoServiceGetAllTasksCoalescing(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	setup := func(t *testing.T) (*TodoService, *slowKV) {
+		t.Helper()
+
+		kv := &slowKV{MapKV: testutil.NewMapKV(), release: make(chan struct{})}
+		repo := repository.NewTaskRepo(kv)
+
+		for id := int64(1); id <= 3; id++ {
+			err := repo.Insert(domain.NewTask(id, "Task", "Description"))
+			if err != nil {
+				t.Fatalf("Failed to insert task: %v", err)
+			}
+		}
+
+		return NewTodoService(logger, repo), kv
+	}
+
+	// waitForCalls waits until ForEach was called n times.
+	waitForCalls := func(t *testing.T, kv *slowKV, n int32) {
+		t.Helper()
+
+		deadline := time.Now().Add(time.Second)
+		for kv.forEachCalls.Load() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("Expected %d ForEach calls, got %d", n, kv.forEachCalls.Load())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	t.Run("concurrent reads share one repository read", func(t *testing.T) {
+		service, kv := setup(t)
+
+		const readers = 10
+
+		var wg sync.WaitGroup
+		results := make([][]*domain.Task, readers)
+
+		wg.Go(func() {
+			results[0], _ = service.GetAllTasks()
+		})
+		waitForCalls(t, kv, 1)
+
+		for i := 1; i < readers; i++ {
+			wg.Go(func() {
+				results[i], _ = service.GetAllTasks()
+			})
+		}
+
+		// giving the readers time to join the in-flight read
+		time.Sleep(20 * time.Millisecond)
+		close(kv.release)
+		wg.Wait()
+
+		if calls := kv.forEachCalls.Load(); calls != 1 {
+			t.Errorf("Expected 1 repository read, got %d", calls)
+		}
+		for i, tasks := range results {
+			if len(tasks) != 3 {
+				t.Errorf("Expected reader %d to get 3 tasks, got %d", i, len(tasks))
+			}
+		}
+	})
+
+	t.Run("reads after a write don't join the in-flight read", func(t *testing.T) {
+		service, kv := setup(t)
+
+		var wg sync.WaitGroup
+		var before, after []*domain.Task
+
+		wg.Go(func() {
+			before, _ = service.GetAllTasks()
+		})
+		waitForCalls(t, kv, 1)
+
+		err := service.DeleteTask(1)
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+
+		wg.Go(func() {
+			after, _ = service.GetAllTasks()
+		})
+		waitForCalls(t, kv, 2)
+
+		close(kv.release)
+		wg.Wait()
+
+		if len(before) != 2 || len(after) != 2 {
+			t.Errorf("Expected both reads to see the delete, got %d and %d tasks", len(before), len(after))
+		}
+	})
+}
+
+func TestTodoServiceGetTaskHistory(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := NewTodoService(logger, repo)
+
+	err := service.CreateTask(domain.NewTask(1, "Task", "Description"))
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	_, err = service.UpdateTask(1, dto.UpdateTaskInput{Title: "Updated", Description: "Description"})
+	if err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+
+	history, err := service.GetTaskHistory(1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 history entries, got %d", len(history))
+	}
+	if history[0].Title != "Task" || history[0].Version != 1 {
+		t.Errorf("Expected first entry to be the created task, got %+v", history[0])
+	}
+	if history[1].Title != "Updated" || history[1].Version != 2 {
+		t.Errorf("Expected second entry to be the updated task, got %+v", history[1])
+	}
+
+	_, err = service.GetTaskHistory(42)
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	_, err = service.GetTaskHistory(0)
+	if !errors.Is(err, ErrInvalidID) {
+		t.Errorf("Expected ErrInvalidID, got %v", err)
+	}
+}
+
 func TestTodoServiceGetAllTasks(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
@@ -146,6 +338,333 @@ func TestTodoServiceGetAllTasks(t *testing.T) {
 			t.Errorf("Expected 2 tasks, got %d", len(tasks))
 		}
 	})
+
+	t.Run("returns tasks due within duration", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		soon := time.Now().Add(time.Hour)
+		later := time.Now().Add(48 * time.Hour)
+
+		dueDates := map[int64]*time.Time{1: &later, 2: &soon, 3: nil}
+		for id, dueDate := range dueDates {
+			task := domain.NewTask(id, "Test", "Test")
+			task.DueDate = dueDate
+
+			err := repo.Insert(task)
+			if err != nil {
+				t.Fatalf("Failed to insert task: %v", err)
+			}
+		}
+
+		tasks, err := service.SearchTasks(domain.TaskFilter{DueWithin: 24 * time.Hour})
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if len(tasks) != 1 || tasks[0].ID != 2 {
+			t.Errorf("Expected only task 2 to be due, got %v", tasks)
+		}
+	})
+}
+
+func TestTodoServiceGetTasksDueToday(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	clock := &fakeClock{now: time.Date(2025, time.March, 10, 15, 0, 0, 0, time.UTC)}
+	service := NewTodoService(logger, repo, WithClock(clock))
+
+	startOfDay := time.Date(2025, time.March, 10, 0, 0, 0, 0, time.UTC)
+	endOfDay := startOfDay.Add(24*time.Hour - time.Nanosecond)
+	nextMidnight := startOfDay.Add(24 * time.Hour)
+	beforeMidnight := startOfDay.Add(-time.Nanosecond)
+	noon := startOfDay.Add(12 * time.Hour)
+
+	dueDates := map[int64]*time.Time{
+		1: &endOfDay,       // last instant of the day, included
+		2: &startOfDay,     // midnight, included
+		3: &nextMidnight,   // next day, excluded
+		4: &beforeMidnight, // previous day, excluded
+		5: &noon,
+		6: nil, // no due date, excluded
+	}
+	for id, dueDate := range dueDates {
+		task := domain.NewTask(id, "Test", "Test")
+		task.DueDate = dueDate
+
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	tasks, err := service.GetTasksDueToday()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ids := make([]int64, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+
+	expected := []int64{2, 5, 1}
+	if !slices.Equal(ids, expected) {
+		t.Errorf("Expected tasks %v, got %v", expected, ids)
+	}
+}
+
+func TestTodoServiceSearchTasks(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := NewTodoService(logger, repo)
+
+	priorities := map[int64]string{1: domain.PriorityHigh, 2: domain.PriorityLow, 3: ""}
+	for id, priority := range priorities {
+		task := domain.NewTask(id, "Task", "Description")
+		task.Priority = priority
+		task.Done = id == 2
+
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	tasks, err := service.SearchTasks(domain.TaskFilter{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tasks) != 3 {
+		t.Errorf("Expected all 3 tasks for empty filter, got %d", len(tasks))
+	}
+
+	notDone := false
+	tasks, err = service.SearchTasks(domain.TaskFilter{Done: &notDone, Priority: domain.PriorityHigh})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != 1 {
+		t.Errorf("Expected only task 1, got %v", tasks)
+	}
+}
+
+func TestTodoServiceGetTaskBoard(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := NewTodoService(logger, repo)
+
+	for id := int64(1); id <= 5; id++ {
+		task := domain.NewTask(id, "Task", "Description")
+		task.Done = id%2 == 0
+		if id <= 3 {
+			task.Tags = []string{"work"}
+		}
+
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	filters := []domain.TaskFilter{{}, {Tag: "work"}, {Tag: "home"}}
+	for _, filter := range filters {
+		board, err := service.GetTaskBoard(filter)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for _, task := range board.Open {
+			if task.Done {
+				t.Errorf("Expected only open tasks in open group, got task %d", task.ID)
+			}
+		}
+		for _, task := range board.Done {
+			if !task.Done {
+				t.Errorf("Expected only done tasks in done group, got task %d", task.ID)
+			}
+		}
+
+		tasks, err := service.SearchTasks(filter)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(board.Open)+len(board.Done) != len(tasks) {
+			t.Errorf("Expected %d tasks on board for filter %+v, got %d", len(tasks), filter, len(board.Open)+len(board.Done))
+		}
+	}
+
+	board, err := service.GetTaskBoard(domain.TaskFilter{Tag: "work"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(board.Open) != 2 || len(board.Done) != 1 {
+		t.Errorf("Expected 2 open and 1 done work tasks, got %d and %d", len(board.Open), len(board.Done))
+	}
+}
+
+func TestTodoServiceGetTagCounts(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := NewTodoService(logger, repo)
+
+	tags := map[int64][]string{
+		1: {"work", "urgent"},
+		2: {"work", "home"},
+		3: {"home", "work", "work"},
+		4: nil,
+	}
+	for id, taskTags := range tags {
+		task := domain.NewTask(id, "Task", "Description")
+		task.Tags = taskTags
+
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	counts, err := service.GetTagCounts()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []domain.TagCount{
+		{Name: "work", Count: 3},
+		{Name: "home", Count: 2},
+		{Name: "urgent", Count: 1},
+	}
+	if len(counts) != len(expected) {
+		t.Fatalf("Expected %d tags, got %d: %v", len(expected), len(counts), counts)
+	}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("Expected %v at position %d, got %v", expected[i], i, counts[i])
+		}
+	}
+}
+
+func TestTodoServiceGetTaskStats(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	clock := &fakeClock{now: time.Date(2025, time.March, 10, 23, 30, 0, 0, time.UTC)}
+	service := NewTodoService(logger, repo, WithClock(clock))
+
+	for id := int64(1); id <= 3; id++ {
+		err := service.CreateTask(domain.NewTask(id, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+
+	complete := func(id int64) {
+		t.Helper()
+
+		_, err := service.UpdateTask(id, dto.UpdateTaskInput{Title: "Task", Description: "Description", Done: true})
+		if err != nil {
+			t.Fatalf("Failed to complete task: %v", err)
+		}
+	}
+
+	check := func(expected domain.TaskStats) {
+		t.Helper()
+
+		stats, err := service.GetTaskStats()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if stats != expected {
+			t.Errorf("Expected %+v, got %+v", expected, stats)
+		}
+	}
+
+	complete(1)
+	check(domain.TaskStats{Total: 3, Done: 1, Open: 2, CompletedToday: 1})
+
+	// next day
+	clock.Advance(time.Hour)
+	check(domain.TaskStats{Total: 3, Done: 1, Open: 2, CompletedToday: 0})
+
+	complete(2)
+	check(domain.TaskStats{Total: 3, Done: 2, Open: 1, CompletedToday: 1})
+}
+
+func TestTodoServiceGetDailyStats(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	// 23:30 local time, one hour ahead of UTC
+	loc := time.FixedZone("UTC+1", 60*60)
+	clock := &fakeClock{now: time.Date(2025, time.March, 8, 23, 30, 0, 0, loc)}
+	service := NewTodoService(logger, repo, WithClock(clock))
+
+	create := func(id int64) {
+		t.Helper()
+
+		err := service.CreateTask(domain.NewTask(id, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+	}
+	complete := func(id int64) {
+		t.Helper()
+
+		_, err := service.UpdateTask(id, dto.UpdateTaskInput{Title: "Task", Description: "Description", Done: true})
+		if err != nil {
+			t.Fatalf("Failed to complete task: %v", err)
+		}
+	}
+
+	// March 8, out of the 2 day window
+	create(1)
+	// March 9, 00:29 local time(23:29 UTC of March 8)
+	clock.Advance(59 * time.Minute)
+	create(2)
+	complete(1)
+	// March 10
+	clock.Advance(24 * time.Hour)
+	create(3)
+	complete(3)
+
+	stats, err := service.GetDailyStats(2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []domain.DailyStats{
+		{Date: "2025-03-09", Created: 1, Completed: 1},
+		{Date: "2025-03-10", Created: 1, Completed: 1},
+	}
+	if !slices.Equal(stats, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+
+	stats, err = service.GetDailyStats(3)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if stats[0].Date != "2025-03-08" || stats[0].Created != 1 || stats[0].Completed != 0 {
+		t.Errorf("Expected 1 task created on 2025-03-08, got %+v", stats[0])
+	}
 }
 
 func TestTodoServiceCreateTask(t *testing.T) {
@@ -211,24 +730,331 @@ func TestTodoServiceCreateTask(t *testing.T) {
 			t.Errorf("Expected ErrTaskExists, got %v", err)
 		}
 	})
-}
 
-func TestTodoServiceUpdateTask(t *testing.T) {
-	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	t.Run("enforces configured validation limits", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
 
-	t.Run("updates task successfully", func(t *testing.T) {
+		service := NewTodoService(logger, repo, WithValidationLimits(domain.ValidationLimits{
+			MaxTitleLength:       3,
+			MaxDescriptionLength: 3,
+		}))
+
+		err := service.CreateTask(domain.NewTask(1, "Long title", "Description"))
+
+		var validationErr *validator.Validator
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected validator error, got %v", err)
+		}
+		if _, exists := validationErr.Errors["title"]; !exists {
+			t.Error("Expected 'title' error to exist")
+		}
+		if _, exists := validationErr.Errors["description"]; !exists {
+			t.Error("Expected 'description' error to exist")
+		}
+	})
+
+	t.Run("sets timestamps on create", func(t *testing.T) {
 		repo, cleanup := setupTestEnvironment(t)
 		defer cleanup()
 
 		service := NewTodoService(logger, repo)
 
-		task := domain.NewTask(1, "Original", "Original Description")
-		err := repo.Insert(task)
+		before := time.Now()
+		task := domain.NewTask(1, "Task", "Description")
+		err := service.CreateTask(task)
 		if err != nil {
-			t.Fatalf("Failed to insert task: %v", err)
+			t.Fatalf("Expected no error, got %v", err)
 		}
 
-		input := dto.UpdateTaskInput{
+		if task.CreatedAt.Before(before) || !task.UpdatedAt.Equal(task.CreatedAt) {
+			t.Errorf("Unexpected timestamps: created %s, updated %s", task.CreatedAt, task.UpdatedAt)
+		}
+	})
+
+	t.Run("rejects inverted created range", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		now := time.Now()
+		_, err := service.SearchTasks(domain.TaskFilter{CreatedFrom: now, CreatedTo: now.Add(-time.Hour)})
+		if !errors.Is(err, ErrInvalidTimeRange) {
+			t.Errorf("Expected ErrInvalidTimeRange, got %v", err)
+		}
+	})
+}
+
+func TestTodoServiceClock(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	service := NewTodoService(logger, repo, WithClock(clock))
+
+	task := domain.NewTask(1, "Task", "Description")
+	err := service.CreateTask(task)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !task.CreatedAt.Equal(clock.now) {
+		t.Errorf("Expected CreatedAt %v, got %v", clock.now, task.CreatedAt)
+	}
+	if !task.UpdatedAt.Equal(clock.now) {
+		t.Errorf("Expected UpdatedAt %v, got %v", clock.now, task.UpdatedAt)
+	}
+
+	clock.Advance(time.Hour)
+
+	updated, err := service.UpdateTask(1, dto.UpdateTaskInput{Title: "Updated", Description: "Description"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !updated.UpdatedAt.Equal(clock.now) {
+		t.Errorf("Expected UpdatedAt %v, got %v", clock.now, updated.UpdatedAt)
+	}
+	if !updated.CreatedAt.Equal(task.CreatedAt) {
+		t.Errorf("Expected CreatedAt to stay %v, got %v", task.CreatedAt, updated.CreatedAt)
+	}
+
+	// due_within is counted from the clock time, not the wall time
+	due := clock.now.Add(time.Hour)
+	updated, err = service.UpdateTask(1, dto.UpdateTaskInput{Title: "Updated", Description: "Description", DueDate: &due})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tasks, err := service.SearchTasks(domain.TaskFilter{DueWithin: 2 * time.Hour})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != updated.ID {
+		t.Errorf("Expected task %d due within 2h of the clock time, got %v", updated.ID, tasks)
+	}
+}
+
+func TestTodoServiceCreateTaskUniqueTitles(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	tests := []struct {
+		name        string
+		enabled     bool
+		doneFirst   bool
+		expectedErr error
+	}{
+		{name: "fails when enabled", enabled: true, expectedErr: ErrDuplicateTitle},
+		{name: "succeeds when disabled", enabled: false},
+		{name: "succeeds when the task with the title is done", enabled: true, doneFirst: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, cleanup := setupTestEnvironment(t)
+			defer cleanup()
+
+			service := NewTodoService(logger, repo, WithUniqueTitles(tt.enabled))
+
+			first := domain.NewTask(1, "Buy milk", "")
+			first.Done = tt.doneFirst
+			err := service.CreateTask(first)
+			if err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+
+			err = service.CreateTask(domain.NewTask(2, "  buy MILK ", ""))
+			if !errors.Is(err, tt.expectedErr) {
+				t.Errorf("Expected error %v, got %v", tt.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestTodoServiceCreateTaskIdempotent(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("idempotency record expires after TTL", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		clock := &fakeClock{now: time.Now()}
+		service := NewTodoService(logger, repo, WithClock(clock))
+
+		_, _, err := service.CreateTaskIdempotent("key", domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		clock.Advance(idempotencyTTL + time.Second)
+
+		_, replayed, err := service.CreateTaskIdempotent("key", domain.NewTask(2, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if replayed {
+			t.Error("Expected expired record not to be replayed")
+		}
+	})
+
+	t.Run("replays result for the same key", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		first, replayed, err := service.CreateTaskIdempotent("key", domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if replayed {
+			t.Error("Expected first create not to be replayed")
+		}
+
+		second, replayed, err := service.CreateTaskIdempotent("key", domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error on retry, got %v", err)
+		}
+		if !replayed {
+			t.Error("Expected retry to be replayed")
+		}
+		if second.ID != first.ID || second.Title != first.Title {
+			t.Errorf("Expected replayed task %v, got %v", first, second)
+		}
+
+		tasks, err := service.GetAllTasks()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(tasks) != 1 {
+			t.Errorf("Expected 1 task, got %d", len(tasks))
+		}
+	})
+
+	t.Run("different keys create different tasks", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		_, _, err := service.CreateTaskIdempotent("key1", domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		_, _, err = service.CreateTaskIdempotent("key2", domain.NewTask(1, "Task", "Description"))
+		if !errors.Is(err, ErrTaskExists) {
+			t.Errorf("Expected ErrTaskExists, got %v", err)
+		}
+
+		_, _, err = repo.GetIdempotencyRecord("key2")
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected no record for the failed create, got %v", err)
+		}
+	})
+
+	t.Run("expired record is deleted on lookup", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		clock := &fakeClock{now: time.Now()}
+		service := NewTodoService(logger, repo, WithClock(clock))
+
+		_, _, err := service.CreateTaskIdempotent("key", domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		clock.Advance(idempotencyTTL + time.Second)
+
+		_, _, err = service.CreateTaskIdempotent("key", domain.NewTask(2, "", "Description"))
+		if err == nil {
+			t.Fatal("Expected validation error")
+		}
+
+		_, _, err = repo.GetIdempotencyRecord("key")
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected expired record to be deleted, got %v", err)
+		}
+	})
+
+	t.Run("purges expired records", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		clock := &fakeClock{now: time.Now()}
+		service := NewTodoService(logger, repo, WithClock(clock))
+
+		_, _, err := service.CreateTaskIdempotent("old", domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		clock.Advance(idempotencyTTL + time.Second)
+
+		_, _, err = service.CreateTaskIdempotent("new", domain.NewTask(2, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		purged, err := service.PurgeIdempotencyRecords()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if purged != 1 {
+			t.Errorf("Expected 1 purged record, got %d", purged)
+		}
+
+		_, _, err = repo.GetIdempotencyRecord("old")
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected expired record to be purged, got %v", err)
+		}
+		_, _, err = repo.GetIdempotencyRecord("new")
+		if err != nil {
+			t.Errorf("Expected fresh record to be kept, got %v", err)
+		}
+	})
+
+	t.Run("does not remember failed creates", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		_, _, err := service.CreateTaskIdempotent("key", domain.NewTask(1, "", "Description"))
+		if err == nil {
+			t.Fatal("Expected validation error")
+		}
+
+		task, replayed, err := service.CreateTaskIdempotent("key", domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if replayed || task.Title != "Task" {
+			t.Errorf("Expected task to be created, got %v(replayed: %t)", task, replayed)
+		}
+	})
+}
+
+func TestTodoServiceUpdateTask(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("updates task successfully", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		task := domain.NewTask(1, "Original", "Original Description")
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+
+		input := dto.UpdateTaskInput{
 			Title:       "Updated",
 			Description: "Updated Description",
 			Done:        true,
@@ -325,61 +1151,1098 @@ func TestTodoServiceUpdateTask(t *testing.T) {
 			t.Errorf("Expected validator error, got %T", err)
 		}
 	})
+
+	t.Run("concurrent delete is not undone", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		// the update is paused while it sets UpdatedAt, the delete runs in between
+		clock := &pausingClock{entered: make(chan struct{}), release: make(chan struct{})}
+		service := NewTodoService(logger, repo, WithClock(clock), WithTrashTTL(time.Hour))
+
+		err := repo.Insert(domain.NewTask(1, "Original", ""))
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		wg.Go(func() {
+			_, err := service.UpdateTask(1, dto.UpdateTaskInput{Title: "Updated"})
+			if err != nil {
+				t.Errorf("Failed to update task: %v", err)
+			}
+		})
+
+		<-clock.entered
+		err = service.DeleteTask(1)
+		close(clock.release)
+		wg.Wait()
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+
+		_, err = service.GetTask(1)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected deleted task to stay deleted, got %v", err)
+		}
+
+		trash, err := service.GetTrash()
+		if err != nil {
+			t.Fatalf("Failed to get trash: %v", err)
+		}
+		if len(trash) != 1 || trash[0].Title != "Updated" {
+			t.Errorf("Expected the updated task in trash, got %v", trash)
+		}
+	})
 }
 
-func TestTodoServiceDeleteTask(t *testing.T) {
+func TestTodoServiceUpsertTask(t *testing.T) {
 	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
 
-	t.Run("deletes task successfully", func(t *testing.T) {
+	t.Run("creates missing task", func(t *testing.T) {
 		repo, cleanup := setupTestEnvironment(t)
 		defer cleanup()
 
 		service := NewTodoService(logger, repo)
 
-		task := domain.NewTask(1, "Task", "Description")
-		err := repo.Insert(task)
+		task, created, err := service.UpsertTask(5, dto.UpdateTaskInput{Title: "New", Done: true})
 		if err != nil {
-			t.Fatalf("Failed to insert task: %v", err)
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !created {
+			t.Error("Expected task to be created")
+		}
+		if task.ID != 5 || task.Title != "New" || !task.Done || task.Version != 1 {
+			t.Errorf("Unexpected task: %+v", task)
 		}
 
-		err = service.DeleteTask(1)
+		stored, err := repo.Get(5)
 		if err != nil {
-			t.Errorf("Expected no error, got %v", err)
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if stored.Title != "New" {
+			t.Errorf("Expected stored title 'New', got '%s'", stored.Title)
 		}
+	})
 
-		_, err = repo.Get(task.ID)
-		if err == nil {
-			t.Error("Task was not deleted from repository")
+	t.Run("replaces existing task", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		err := repo.Insert(domain.NewTask(1, "Original", "Original Description"))
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+
+		task, created, err := service.UpsertTask(1, dto.UpdateTaskInput{Title: "Replaced"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if created {
+			t.Error("Expected task to be replaced, not created")
+		}
+		if task.Title != "Replaced" || task.Description != "" || task.Version != 2 {
+			t.Errorf("Unexpected task: %+v", task)
 		}
 	})
 
-	t.Run("fails to delete with invalid ID", func(t *testing.T) {
+	t.Run("validates created task", func(t *testing.T) {
 		repo, cleanup := setupTestEnvironment(t)
 		defer cleanup()
 
 		service := NewTodoService(logger, repo)
 
-		err := service.DeleteTask(0)
-		if err == nil {
-			t.Error("Expected error for ID 0")
+		_, _, err := service.UpsertTask(1, dto.UpdateTaskInput{Title: ""})
+
+		var validationErr *validator.Validator
+		if !errors.As(err, &validationErr) {
+			t.Errorf("Expected validator error, got %v", err)
+		}
+		if _, err := repo.Get(1); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected invalid task not to be stored, got %v", err)
 		}
+	})
+
+	t.Run("rejects invalid id", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		_, _, err := service.UpsertTask(0, dto.UpdateTaskInput{Title: "Task"})
 		if !errors.Is(err, ErrInvalidID) {
 			t.Errorf("Expected ErrInvalidID, got %v", err)
 		}
 	})
+}
+
+func TestTodoServiceCompleteTasks(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	setup := func(t *testing.T) *TodoService {
+		t.Helper()
 
-	t.Run("fails to delete non-existent task", func(t *testing.T) {
 		repo, cleanup := setupTestEnvironment(t)
-		defer cleanup()
+		t.Cleanup(cleanup)
 
 		service := NewTodoService(logger, repo)
 
-		err := service.DeleteTask(999)
-		if err == nil {
-			t.Error("Expected error for non-existent task")
+		tags := map[int64][]string{
+			1: {"work"},
+			2: {"work", "urgent"},
+			3: {"home"},
+			4: {"work"},
 		}
-		if !errors.Is(err, repository.ErrNotFound) {
-			t.Errorf("Expected ErrNotFound, got %v", err)
+		for id, taskTags := range tags {
+			task := domain.NewTask(id, "Task", "Description")
+			task.Tags = taskTags
+
+			err := service.CreateTask(task)
+			if err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+		}
+
+		// already done task
+		_, err := service.UpdateTask(4, dto.UpdateTaskInput{Title: "Task", Description: "Description", Done: true, Tags: []string{"work"}})
+		if err != nil {
+			t.Fatalf("Failed to complete task: %v", err)
+		}
+
+		return service
+	}
+
+	t.Run("completes only matching open tasks", func(t *testing.T) {
+		service := setup(t)
+
+		completed, err := service.CompleteTasks("work")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if completed != 2 {
+			t.Errorf("Expected 2 completed tasks, got %d", completed)
+		}
+
+		expected := map[int64]bool{1: true, 2: true, 3: false, 4: true}
+		for id, done := range expected {
+			task, err := service.GetTask(id)
+			if err != nil {
+				t.Fatalf("Failed to get task: %v", err)
+			}
+
+			if task.Done != done {
+				t.Errorf("Expected task %d done=%t, got %t", id, done, task.Done)
+			}
+		}
+
+		// already done task is not modified
+		task, err := service.GetTask(4)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if task.Version != 2 {
+			t.Errorf("Expected done task version to stay 2, got %d", task.Version)
+		}
+	})
+
+	t.Run("empty tag completes all open tasks", func(t *testing.T) {
+		service := setup(t)
+
+		completed, err := service.CompleteTasks("")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if completed != 3 {
+			t.Errorf("Expected 3 completed tasks, got %d", completed)
+		}
+	})
+}
+
+func TestTodoServiceUpdateTasksStatus(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	setup := func(t *testing.T) *TodoService {
+		t.Helper()
+
+		repo, cleanup := setupTestEnvironment(t)
+		t.Cleanup(cleanup)
+
+		service := NewTodoService(logger, repo)
+
+		for id := int64(1); id <= 3; id++ {
+			err := service.CreateTask(domain.NewTask(id, "Task", "Description"))
+			if err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+		}
+
+		// already done task
+		_, err := service.UpdateTask(3, dto.UpdateTaskInput{Title: "Task", Description: "Description", Done: true})
+		if err != nil {
+			t.Fatalf("Failed to complete task: %v", err)
+		}
+
+		return service
+	}
+
+	done := true
+	open := false
+
+	t.Run("reports existing, missing and completed tasks", func(t *testing.T) {
+		service := setup(t)
+
+		results, err := service.UpdateTasksStatus(dto.BulkStatusInput{IDs: []domain.JSONID{2, 10, 3, 1}, Done: &done})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := []string{domain.BulkUpdated, domain.BulkNotFound, domain.BulkConflict, domain.BulkUpdated}
+		if len(results) != len(expected) {
+			t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+		}
+		for i, result := range results {
+			if result.Status != expected[i] {
+				t.Errorf("Expected task %d status %s, got %s", result.ID, expected[i], result.Status)
+			}
+		}
+		if results[2].Errors["done"] == "" {
+			t.Errorf("Expected conflict to explain the done error, got %v", results[2].Errors)
+		}
+
+		for id, version := range map[int64]int{1: 2, 2: 2, 3: 2} {
+			task, err := service.GetTask(id)
+			if err != nil {
+				t.Fatalf("Failed to get task: %v", err)
+			}
+			if !task.Done || task.Version != version {
+				t.Errorf("Expected task %d done with version %d, got done=%t version %d", id, version, task.Done, task.Version)
+			}
+		}
+	})
+
+	t.Run("completed task can't be reopened", func(t *testing.T) {
+		service := setup(t)
+
+		results, err := service.UpdateTasksStatus(dto.BulkStatusInput{IDs: []domain.JSONID{1, 3}, Done: &open})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if results[0].Status != domain.BulkUpdated || results[1].Status != domain.BulkConflict {
+			t.Errorf("Expected updated and conflict, got %+v", results)
+		}
+
+		task, err := service.GetTask(3)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if !task.Done {
+			t.Error("Expected completed task to stay done")
+		}
+	})
+
+	t.Run("validates input", func(t *testing.T) {
+		service := setup(t)
+
+		tests := []struct {
+			name  string
+			input dto.BulkStatusInput
+			key   string
+		}{
+			{"no ids", dto.BulkStatusInput{Done: &done}, "ids"},
+			{"non-positive id", dto.BulkStatusInput{IDs: []domain.JSONID{1, 0}, Done: &done}, "ids"},
+			{"duplicate ids", dto.BulkStatusInput{IDs: []domain.JSONID{1, 1}, Done: &done}, "ids"},
+			{"missing done", dto.BulkStatusInput{IDs: []domain.JSONID{1}}, "done"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := service.UpdateTasksStatus(tt.input)
+
+				var validationErr *validator.Validator
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("Expected validation error, got %v", err)
+				}
+				if _, ok := validationErr.Errors[tt.key]; !ok {
+					t.Errorf("Expected error for %s, got %v", tt.key, validationErr.Errors)
+				}
+			})
+		}
+	})
+}
+
+func TestTodoServiceDeleteCompletedTasks(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := NewTodoService(logger, repo)
+
+	for id := int64(1); id <= 4; id++ {
+		task := domain.NewTask(id, "Task", "Description")
+		task.Done = id%2 == 0
+
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+	}
+
+	deleted, err := service.DeleteCompletedTasks()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Expected 2 deleted tasks, got %d", deleted)
+	}
+
+	tasks, err := service.GetAllTasks()
+	if err != nil {
+		t.Fatalf("Failed to get tasks: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != 1 || tasks[1].ID != 3 {
+		t.Errorf("Expected open tasks 1 and 3 to remain, got %v", tasks)
+	}
+
+	deleted, err = service.DeleteCompletedTasks()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if deleted != 0 {
+		t.Errorf("Expected 0 deleted tasks on second call, got %d", deleted)
+	}
+}
+
+func TestTodoServiceRestoreTasks(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := NewTodoService(logger, repo)
+
+	existing := domain.NewTask(1, "Old title", "")
+	err := repo.Insert(existing)
+	if err != nil {
+		t.Fatalf("Failed to insert task: %v", err)
+	}
+
+	created := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	restored := domain.NewTask(1, "Restored title", "")
+	restored.CreatedAt = created
+	restored.UpdatedAt = created
+	restored.Version = 5
+	restored.Position = 9
+	added := domain.NewTask(2, "Task 2", "")
+
+	t.Run("invalid task stores nothing", func(t *testing.T) {
+		_, err := service.RestoreTasks([]*domain.Task{added, domain.NewTask(3, "", "")})
+
+		var validationErr *validator.Validator
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected validation error, got %v", err)
+		}
+		if _, ok := validationErr.Errors["tasks[1].title"]; !ok {
+			t.Errorf("Expected error for tasks[1].title, got %v", validationErr.Errors)
+		}
+
+		_, err = service.GetTask(2)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected task 2 not to be stored, got %v", err)
+		}
+	})
+
+	t.Run("replaces and adds tasks", func(t *testing.T) {
+		n, err := service.RestoreTasks([]*domain.Task{restored, added})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if n != 2 {
+			t.Errorf("Expected 2 restored tasks, got %d", n)
+		}
+
+		got, err := service.GetTask(1)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+		if got.Title != restored.Title || got.Version != 5 || got.Position != 9 || !got.CreatedAt.Equal(created) {
+			t.Errorf("Expected task to be restored as is, got %+v", got)
+		}
+
+		_, err = service.GetTask(2)
+		if err != nil {
+			t.Errorf("Expected task 2 to be added, got %v", err)
+		}
+	})
+}
+
+func TestTodoServiceVersionLifecycle(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := NewTodoService(logger, repo)
+
+	version := func(t *testing.T, id int64) int {
+		t.Helper()
+
+		task, err := service.GetTask(id)
+		if err != nil {
+			t.Fatalf("Failed to get task: %v", err)
+		}
+
+		return task.Version
+	}
+
+	t.Run("created task has version 1", func(t *testing.T) {
+		task := domain.NewTask(1, "Task", "")
+		task.Version = 7
+
+		err := service.CreateTask(task)
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+
+		if v := version(t, 1); v != 1 {
+			t.Errorf("Expected version 1, got %d", v)
+		}
+	})
+
+	t.Run("update increments version", func(t *testing.T) {
+		_, err := service.UpdateTask(1, dto.UpdateTaskInput{Title: "Updated"})
+		if err != nil {
+			t.Fatalf("Failed to update task: %v", err)
+		}
+
+		if v := version(t, 1); v != 2 {
+			t.Errorf("Expected version 2, got %d", v)
+		}
+	})
+
+	t.Run("move increments version", func(t *testing.T) {
+		err := service.CreateTask(domain.NewTask(2, "Task 2", ""))
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+
+		_, err = service.MoveTask(2, 1)
+		if err != nil {
+			t.Fatalf("Failed to move task: %v", err)
+		}
+
+		if v := version(t, 2); v != 2 {
+			t.Errorf("Expected moved task version 2, got %d", v)
+		}
+		if v := version(t, 1); v != 3 {
+			t.Errorf("Expected renumbered task version 3, got %d", v)
+		}
+	})
+
+	t.Run("imported task keeps its version", func(t *testing.T) {
+		imported := domain.NewTask(3, "Imported", "")
+		imported.Version = 7
+
+		_, err := service.RestoreTasks([]*domain.Task{imported})
+		if err != nil {
+			t.Fatalf("Failed to restore task: %v", err)
+		}
+
+		if v := version(t, 3); v != 7 {
+			t.Errorf("Expected version 7, got %d", v)
+		}
+
+		_, err = service.UpdateTask(3, dto.UpdateTaskInput{Title: "Updated"})
+		if err != nil {
+			t.Fatalf("Failed to update task: %v", err)
+		}
+
+		if v := version(t, 3); v != 8 {
+			t.Errorf("Expected version 8, got %d", v)
+		}
+	})
+}
+
+func TestTodoServiceDuplicateTask(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	repo, cleanup := setupTestEnvironment(t)
+	defer cleanup()
+
+	service := NewTodoService(logger, repo)
+
+	dueDate := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	original := domain.NewTask(5, "Task", "Description")
+	original.Subtasks = []domain.Subtask{{Title: "Subtask", Done: true}}
+	original.Tags = []string{"work"}
+	original.DueDate = &dueDate
+
+	err := service.CreateTask(original)
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	_, err = service.UpdateTask(5, dto.UpdateTaskInput{
+		Title:       original.Title,
+		Description: original.Description,
+		Done:        true,
+		Subtasks:    original.Subtasks,
+		Tags:        original.Tags,
+		DueDate:     original.DueDate,
+	})
+	if err != nil {
+		t.Fatalf("Failed to complete task: %v", err)
+	}
+
+	t.Run("creates copy with new id", func(t *testing.T) {
+		task, err := service.DuplicateTask(5)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if task.ID != 6 {
+			t.Errorf("Expected id 6, got %d", task.ID)
+		}
+		if task.Title != "Task (copy)" || task.Description != "Description" || task.Done {
+			t.Errorf("Unexpected copy: %+v", task)
+		}
+		if len(task.Subtasks) != 1 || !slices.Equal(task.Tags, []string{"work"}) || task.DueDate == nil || !task.DueDate.Equal(dueDate) {
+			t.Errorf("Expected fields to be copied, got %+v", task)
+		}
+
+		stored, err := service.GetTask(6)
+		if err != nil || stored.Title != "Task (copy)" {
+			t.Errorf("Expected copy to be stored, got %v(%v)", stored, err)
+		}
+	})
+
+	t.Run("doesn't reuse ids of deleted tasks", func(t *testing.T) {
+		err := service.DeleteTask(6)
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+
+		task, err := service.DuplicateTask(5)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if task.ID != 7 {
+			t.Errorf("Expected id 7, got %d", task.ID)
+		}
+	})
+
+	t.Run("returns not found for missing task", func(t *testing.T) {
+		_, err := service.DuplicateTask(42)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestTodoServicePatchTask(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	setup := func(t *testing.T) (*TodoService, func()) {
+		t.Helper()
+
+		repo, cleanup := setupTestEnvironment(t)
+
+		task := domain.NewTask(1, "Original", "Original Description")
+		task.Tags = []string{"work"}
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+
+		return NewTodoService(logger, repo), cleanup
+	}
+
+	t.Run("sets done and keeps absent fields", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		task, err := service.PatchTask(1, []byte(`{"done":true}`), 0)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if !task.Done {
+			t.Error("Expected task to be done")
+		}
+		if task.Title != "Original" || task.Description != "Original Description" || !slices.Equal(task.Tags, []string{"work"}) {
+			t.Errorf("Expected other fields to be untouched, got %+v", task)
+		}
+		if task.Version != 2 {
+			t.Errorf("Expected version 2, got %d", task.Version)
+		}
+	})
+
+	t.Run("null resets field to zero value", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		task, err := service.PatchTask(1, []byte(`{"done":null,"description":null,"tags":null}`), 0)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if task.Done || task.Description != "" || len(task.Tags) != 0 {
+			t.Errorf("Expected fields to be reset, got %+v", task)
+		}
+	})
+
+	t.Run("null title fails validation", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		_, err := service.PatchTask(1, []byte(`{"title":null}`), 0)
+
+		var validationErr *validator.Validator
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected validation error, got %v", err)
+		}
+		if _, ok := validationErr.Errors["title"]; !ok {
+			t.Errorf("Expected title error, got %v", validationErr.Errors)
+		}
+	})
+
+	t.Run("rejects read-only and unknown fields", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		for _, patch := range []string{`{"id":2}`, `{"unknown":1}`, `{"done":"yes"}`, `"title"`} {
+			_, err := service.PatchTask(1, []byte(patch), 0)
+			if !errors.Is(err, ErrInvalidPatch) {
+				t.Errorf("Expected ErrInvalidPatch for %s, got %v", patch, err)
+			}
+		}
+	})
+
+	t.Run("returns not found for missing task", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		_, err := service.PatchTask(42, []byte(`{"done":true}`), 0)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("checks expected version", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		_, err := service.PatchTask(1, []byte(`{"done":true}`), 2)
+		if !errors.Is(err, ErrVersionMismatch) {
+			t.Errorf("Expected ErrVersionMismatch, got %v", err)
+		}
+
+		task, err := service.PatchTask(1, []byte(`{"done":true}`), 1)
+		if err != nil {
+			t.Fatalf("Expected no error for matching version, got %v", err)
+		}
+		if !task.Done || task.Version != 2 {
+			t.Errorf("Expected patched task with version 2, got %+v", task)
+		}
+	})
+}
+
+func TestTodoServiceMoveTask(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	setup := func(t *testing.T) (*TodoService, func()) {
+		t.Helper()
+
+		repo, cleanup := setupTestEnvironment(t)
+		service := NewTodoService(logger, repo)
+
+		for i := range 3 {
+			err := service.CreateTask(domain.NewTask(int64(i+1), "Task", "Description"))
+			if err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+		}
+
+		return service, cleanup
+	}
+
+	positions := func(t *testing.T, service *TodoService) map[int64]int {
+		t.Helper()
+
+		tasks, err := service.GetAllTasks()
+		if err != nil {
+			t.Fatalf("Failed to get tasks: %v", err)
+		}
+
+		result := make(map[int64]int, len(tasks))
+		for _, task := range tasks {
+			result[task.ID] = task.Position
+		}
+
+		return result
+	}
+
+	t.Run("assigns positions on create", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		got := positions(t, service)
+		if got[1] != 1 || got[2] != 2 || got[3] != 3 {
+			t.Errorf("Expected positions 1, 2, 3, got %v", got)
+		}
+	})
+
+	t.Run("inserts between two tasks and renumbers", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		task, err := service.MoveTask(3, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if task.Position != 2 {
+			t.Errorf("Expected position 2, got %d", task.Position)
+		}
+
+		got := positions(t, service)
+		if got[1] != 1 || got[3] != 2 || got[2] != 3 {
+			t.Errorf("Expected positions 1:1, 3:2, 2:3, got %v", got)
+		}
+	})
+
+	t.Run("moves to the end for too large position", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		_, err := service.MoveTask(1, 100)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		got := positions(t, service)
+		if got[2] != 1 || got[3] != 2 || got[1] != 3 {
+			t.Errorf("Expected positions 2:1, 3:2, 1:3, got %v", got)
+		}
+	})
+
+	t.Run("renumbered tasks get new versions and events", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		var events []TaskEvent
+		service := NewTodoService(logger, repo, WithObservers(ObserverFunc(func(event TaskEvent) error {
+			events = append(events, event)
+			return nil
+		})))
+
+		for i := range 3 {
+			err := service.CreateTask(domain.NewTask(int64(i+1), "Task", "Description"))
+			if err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+		}
+		events = nil
+
+		_, err := service.MoveTask(3, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for id := int64(1); id <= 3; id++ {
+			task, err := service.GetTask(id)
+			if err != nil {
+				t.Fatalf("Failed to get task: %v", err)
+			}
+			if task.Version != 2 {
+				t.Errorf("Expected task %d version 2, got %d", id, task.Version)
+			}
+		}
+		if len(events) != 3 {
+			t.Errorf("Expected 3 update events, got %d", len(events))
+		}
+	})
+
+	t.Run("moving a done task is not a completion", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		clock := &fakeClock{now: time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)}
+		service := NewTodoService(logger, repo, WithClock(clock))
+
+		for i := range 2 {
+			err := service.CreateTask(domain.NewTask(int64(i+1), "Task", "Description"))
+			if err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+		}
+
+		_, err := service.CompleteTasks("")
+		if err != nil {
+			t.Fatalf("Failed to complete tasks: %v", err)
+		}
+
+		clock.Advance(24 * time.Hour)
+
+		_, err = service.MoveTask(2, 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		stats, err := service.GetTaskStats()
+		if err != nil {
+			t.Fatalf("Failed to get stats: %v", err)
+		}
+		if stats.CompletedToday != 0 {
+			t.Errorf("Expected no tasks completed today, got %d", stats.CompletedToday)
+		}
+	})
+
+	t.Run("returns errors for invalid input", func(t *testing.T) {
+		service, cleanup := setup(t)
+		defer cleanup()
+
+		_, err := service.MoveTask(1, 0)
+		var v *validator.Validator
+		if !errors.As(err, &v) {
+			t.Errorf("Expected validation error, got %v", err)
+		}
+
+		_, err = service.MoveTask(42, 1)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+
+		got := positions(t, service)
+		if got[1] != 1 || got[2] != 2 || got[3] != 3 {
+			t.Errorf("Expected positions to stay unchanged, got %v", got)
+		}
+	})
+}
+
+func TestTodoServiceDeleteTask(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("deletes task successfully", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		task := domain.NewTask(1, "Task", "Description")
+		err := repo.Insert(task)
+		if err != nil {
+			t.Fatalf("Failed to insert task: %v", err)
+		}
+
+		err = service.DeleteTask(1)
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+
+		_, err = repo.Get(task.ID)
+		if err == nil {
+			t.Error("Task was not deleted from repository")
+		}
+	})
+
+	t.Run("fails to delete with invalid ID", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		err := service.DeleteTask(0)
+		if err == nil {
+			t.Error("Expected error for ID 0")
+		}
+		if !errors.Is(err, ErrInvalidID) {
+			t.Errorf("Expected ErrInvalidID, got %v", err)
+		}
+	})
+
+	t.Run("fails to delete non-existent task", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo)
+
+		err := service.DeleteTask(999)
+		if err == nil {
+			t.Error("Expected error for non-existent task")
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+}
+
+func TestTodoServiceTrash(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("deleted task is restorable", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+		service := NewTodoService(logger, repo, WithClock(clock), WithTrashTTL(time.Hour))
+
+		err := service.CreateTask(domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+
+		err = service.DeleteTask(1)
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+
+		_, err = service.GetTask(1)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for deleted task, got %v", err)
+		}
+
+		trash, err := service.GetTrash()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(trash) != 1 || trash[0].DeletedAt == nil || !trash[0].DeletedAt.Equal(clock.now) {
+			t.Fatalf("Expected task deleted at %v in trash, got %+v", clock.now, trash)
+		}
+
+		restored, err := service.RestoreTask(1)
+		if err != nil {
+			t.Fatalf("Failed to restore task: %v", err)
+		}
+		if restored.DeletedAt != nil {
+			t.Errorf("Expected deleted_at to be cleared, got %v", restored.DeletedAt)
+		}
+
+		task, err := service.GetTask(1)
+		if err != nil {
+			t.Fatalf("Expected restored task, got %v", err)
+		}
+		if task.Title != "Task" {
+			t.Errorf("Expected title 'Task', got '%s'", task.Title)
+		}
+
+		_, err = service.RestoreTask(1)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for task not in trash, got %v", err)
+		}
+	})
+
+	t.Run("corrupt task is deleted permanently", func(t *testing.T) {
+		db, err := inmemorydb.Open(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("Failed to open db: %v", err)
+		}
+		defer db.Close()
+
+		err = db.PutObject("1", []byte("corrupt"))
+		if err != nil {
+			t.Fatalf("Failed to store corrupt task: %v", err)
+		}
+
+		service := NewTodoService(logger, repository.NewTaskRepo(db), WithTrashTTL(time.Hour))
+
+		err = service.DeleteTask(1)
+		if err != nil {
+			t.Fatalf("Failed to delete corrupt task: %v", err)
+		}
+
+		if db.Has("1") {
+			t.Error("Expected corrupt task to be deleted")
+		}
+
+		trash, err := service.GetTrash()
+		if err != nil || len(trash) != 0 {
+			t.Errorf("Expected empty trash, got %+v(%v)", trash, err)
+		}
+	})
+
+	t.Run("restore fails if id is taken", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		service := NewTodoService(logger, repo, WithTrashTTL(time.Hour))
+
+		err := service.CreateTask(domain.NewTask(1, "Task", "Description"))
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+		err = service.DeleteTask(1)
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+		err = service.CreateTask(domain.NewTask(1, "New task", "Description"))
+		if err != nil {
+			t.Fatalf("Failed to create task: %v", err)
+		}
+
+		_, err = service.RestoreTask(1)
+		if !errors.Is(err, ErrTaskExists) {
+			t.Errorf("Expected ErrTaskExists, got %v", err)
+		}
+	})
+
+	t.Run("sweeper purges expired tasks", func(t *testing.T) {
+		repo, cleanup := setupTestEnvironment(t)
+		defer cleanup()
+
+		clock := &fakeClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+		service := NewTodoService(logger, repo, WithClock(clock), WithTrashTTL(time.Hour))
+
+		for _, id := range []int64{1, 2} {
+			err := service.CreateTask(domain.NewTask(id, "Task", "Description"))
+			if err != nil {
+				t.Fatalf("Failed to create task: %v", err)
+			}
+		}
+
+		err := service.DeleteTask(1)
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+		clock.Advance(2 * time.Hour)
+		err = service.DeleteTask(2)
+		if err != nil {
+			t.Fatalf("Failed to delete task: %v", err)
+		}
+
+		stop := service.StartTrashSweeper(time.Millisecond)
+
+		deadline := time.Now().Add(time.Second)
+		var trash []*domain.Task
+		for {
+			trash, err = service.GetTrash()
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if len(trash) < 2 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		stop()
+
+		if len(trash) != 1 || trash[0].ID != 2 {
+			t.Fatalf("Expected only task 2 in trash, got %+v", trash)
+		}
+
+		_, err = service.RestoreTask(1)
+		if !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Expected ErrNotFound for purged task, got %v", err)
+		}
+
+		_, err = service.RestoreTask(2)
+		if err != nil {
+			t.Errorf("Expected task within TTL to be restorable, got %v", err)
 		}
 	})
 }
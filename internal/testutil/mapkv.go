@@ -0,0 +1,96 @@
+// Package testutil holds helpers shared by tests of several packages.
+package testutil
+
+import (
+	"sync"
+
+	"github.com/vladgrskkh/todo/pkg/inmemorydb"
+)
+
+// MapKV is a repository.KV keeping values in a map, without persistence.
+type MapKV struct {
+	mutex sync.RWMutex
+	data  map[string][]byte
+}
+
+// NewMapKV returns an empty MapKV.
+func NewMapKV() *MapKV {
+	return &MapKV{
+		data: make(map[string][]byte),
+	}
+}
+
+func (m *MapKV) GetObject(key string) ([]byte, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	value, exists := m.data[key]
+	if !exists {
+		return nil, inmemorydb.ErrNotFound
+	}
+
+	return append([]byte(nil), value...), nil
+}
+
+func (m *MapKV) PutObject(key string, value []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.data[key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (m *MapKV) Update(key string, fn func(value []byte, exists bool) ([]byte, error)) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var valueCopy []byte
+	current, exists := m.data[key]
+	if exists {
+		valueCopy = append([]byte(nil), current...)
+	}
+
+	value, err := fn(valueCopy, exists)
+	if err != nil {
+		return err
+	}
+
+	m.data[key] = value
+	return nil
+}
+
+func (m *MapKV) DeleteObject(key string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.data[key]; !exists {
+		return inmemorydb.ErrNotFound
+	}
+
+	delete(m.data, key)
+	return nil
+}
+
+// ForEach calls fn for every key-value pair. The read lock is held during
+// iteration, so fn must not modify the store.
+func (m *MapKV) ForEach(fn func(key string, value []byte) error) error {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for key, value := range m.data {
+		err := fn(key, append([]byte(nil), value...))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *MapKV) Has(key string) bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	_, exists := m.data[key]
+	return exists
+}
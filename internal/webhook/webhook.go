@@ -0,0 +1,243 @@
+// Package webhook delivers task events to an HTTP endpoint. Sender is a service.Observer,
+// events are queued and POSTed in background, so requests don't wait for the endpoint.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/service"
+)
+
+const (
+	// SignatureHeader holds "sha256=" and the hex HMAC-SHA256 of the body keyed with
+	// the secret, so the receiver can check the event was sent by this server.
+	SignatureHeader = "X-Todo-Signature"
+	// EventHeader holds the event type(created, updated or deleted).
+	EventHeader = "X-Todo-Event"
+)
+
+// ErrQueueFull is returned by OnTaskEvent when the event is dropped because
+// the delivery queue is full(the endpoint is slow or down).
+var ErrQueueFull = errors.New("webhook queue is full, event dropped")
+
+// Event is the JSON body of a webhook request.
+type Event struct {
+	Type       service.EventType `json:"type"`
+	Task       *domain.Task      `json:"task"`
+	OccurredAt time.Time         `json:"occurred_at"`
+}
+
+// Sender POSTs task events to the configured URL. Failed deliveries(network errors
+// and non-2xx responses) are retried with exponential backoff, events still failing
+// after the last attempt are logged and dropped.
+type Sender struct {
+	logger *slog.Logger
+	url    string
+	secret []byte
+	client *http.Client
+
+	timeout     time.Duration
+	maxAttempts int
+	backoff     time.Duration
+	queueSize   int
+
+	queue     chan delivery
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// delivery is a queued event with its encoded body.
+type delivery struct {
+	eventType service.EventType
+	body      []byte
+}
+
+// Option configures a Sender created with New.
+type Option func(*Sender)
+
+// WithTimeout sets the timeout of a single delivery attempt. Default is 5s.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Sender) {
+		s.timeout = timeout
+	}
+}
+
+// WithMaxAttempts sets how many times an event is sent before it's dropped. Default is 5.
+func WithMaxAttempts(n int) Option {
+	return func(s *Sender) {
+		s.maxAttempts = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubled for every next one.
+// Default is 1s.
+func WithBackoff(backoff time.Duration) Option {
+	return func(s *Sender) {
+		s.backoff = backoff
+	}
+}
+
+// WithQueueSize sets how many events wait for delivery before new ones are dropped.
+// Default is 1000.
+func WithQueueSize(size int) Option {
+	return func(s *Sender) {
+		s.queueSize = size
+	}
+}
+
+// New starts a Sender delivering events to url, signed with secret(see SignatureHeader,
+// empty secret sends no signature). Close must be called to stop it.
+func New(logger *slog.Logger, url string, secret string, opts ...Option) *Sender {
+	s := &Sender{
+		logger:      logger,
+		url:         url,
+		secret:      []byte(secret),
+		client:      &http.Client{},
+		timeout:     5 * time.Second,
+		maxAttempts: 5,
+		backoff:     time.Second,
+		queueSize:   1000,
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.queue = make(chan delivery, s.queueSize)
+	go s.run()
+
+	return s
+}
+
+// OnTaskEvent queues the event for delivery. The event is encoded right away, as the
+// task may be changed after the call. Returns ErrQueueFull if the event is dropped.
+func (s *Sender) OnTaskEvent(event service.TaskEvent) error {
+	body, err := json.Marshal(Event{Type: event.Type, Task: event.Task, OccurredAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("error encoding webhook event: %w", err)
+	}
+
+	select {
+	case <-s.stop:
+		return errors.New("webhook sender is closed")
+	default:
+	}
+
+	select {
+	case s.queue <- delivery{eventType: event.Type, body: body}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Close stops accepting events and waits until the queued ones are sent. Retries of
+// events still queued are not waited for: each is sent once.
+func (s *Sender) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+func (s *Sender) run() {
+	defer close(s.done)
+
+	for {
+		select {
+		case d := <-s.queue:
+			s.deliver(d)
+		case <-s.stop:
+			for {
+				select {
+				case d := <-s.queue:
+					s.deliver(d)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends the event, retrying failed attempts until maxAttempts or Close.
+func (s *Sender) deliver(d delivery) {
+	backoff := s.backoff
+	for attempt := 1; ; attempt++ {
+		err := s.send(d)
+		if err == nil {
+			return
+		}
+
+		if attempt >= s.maxAttempts {
+			s.logger.Error("webhook delivery failed, event dropped",
+				slog.String("event", string(d.eventType)),
+				slog.Int("attempts", attempt),
+				slog.String("error", err.Error()))
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-s.stop:
+			s.logger.Error("webhook sender closed, event dropped",
+				slog.String("event", string(d.eventType)),
+				slog.Int("attempts", attempt),
+				slog.String("error", err.Error()))
+			return
+		}
+	}
+}
+
+// send makes a single delivery attempt. Only 2xx responses are successful.
+func (s *Sender) send(d delivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(d.body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(d.eventType))
+	if len(s.secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(s.secret, d.body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the SignatureHeader value of the body: "sha256=" and the hex
+// HMAC-SHA256 of the body keyed with secret.
+func Sign(secret []byte, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,116 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/vladgrskkh/todo/internal/domain"
+	"github.com/vladgrskkh/todo/internal/service"
+)
+
+func TestSender(t *testing.T) {
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+
+	t.Run("delivers signed event", func(t *testing.T) {
+		received := make(chan *http.Request, 1)
+		bodies := make(chan []byte, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			received <- r
+			bodies <- body
+		}))
+		defer server.Close()
+
+		sender := New(logger, server.URL, "secret")
+		defer sender.Close()
+
+		err := sender.OnTaskEvent(service.TaskEvent{Type: service.EventCreated, Task: domain.NewTask(1, "Task", "")})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		var r *http.Request
+		select {
+		case r = <-received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected webhook to be delivered")
+		}
+		body := <-bodies
+
+		if r.Header.Get(SignatureHeader) != Sign([]byte("secret"), body) {
+			t.Errorf("Expected valid signature, got '%s'", r.Header.Get(SignatureHeader))
+		}
+		if r.Header.Get(EventHeader) != "created" {
+			t.Errorf("Expected event header 'created', got '%s'", r.Header.Get(EventHeader))
+		}
+
+		var event Event
+		err = json.Unmarshal(body, &event)
+		if err != nil {
+			t.Fatalf("Failed to decode event: %v", err)
+		}
+		if event.Type != service.EventCreated || event.Task.ID != 1 || event.Task.Title != "Task" {
+			t.Errorf("Expected created event of task 1, got %+v", event)
+		}
+	})
+
+	t.Run("retries failed deliveries", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		sender := New(logger, server.URL, "", WithBackoff(time.Millisecond))
+
+		err := sender.OnTaskEvent(service.TaskEvent{Type: service.EventUpdated, Task: domain.NewTask(1, "Task", "")})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for attempts.Load() < 3 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		sender.Close()
+
+		if attempts.Load() != 3 {
+			t.Errorf("Expected 3 attempts, got %d", attempts.Load())
+		}
+	})
+
+	t.Run("drops events when the queue is full", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+
+		sender := New(logger, server.URL, "", WithQueueSize(1))
+		defer sender.Close()
+		defer close(release)
+
+		task := domain.NewTask(1, "Task", "")
+		var err error
+		// the first event may be picked up by the delivery goroutine, so up to three fit
+		for range 3 {
+			err = sender.OnTaskEvent(service.TaskEvent{Type: service.EventUpdated, Task: task})
+			if err != nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if err != ErrQueueFull {
+			t.Errorf("Expected ErrQueueFull, got %v", err)
+		}
+	})
+}
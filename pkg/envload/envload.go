@@ -13,12 +13,30 @@ package envload
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"regexp"
 	"strings"
 )
 
+// Option configures Load.
+type Option func(*loader)
+
+// loader holds the Load settings.
+type loader struct {
+	collectErrors bool
+}
+
+// CollectErrors makes Load keep setting the remaining variables when setting one fails
+// (e.g. a key invalid on the platform) instead of returning on the first error.
+// The errors of all failed keys are returned joined at the end.
+func CollectErrors() Option {
+	return func(l *loader) {
+		l.collectErrors = true
+	}
+}
+
 // varPattern matches ${VAR} style variable references used for
 // variable expansion inside .env values.
 var varPattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
@@ -31,6 +49,8 @@ var varPattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
 //
 // Lines beginning with '#' and empty lines are ignored.
 //
+// Load returns on the first variable that can't be set, unless CollectErrors is passed.
+//
 // Example .env file:
 //
 //	PORT=8080
@@ -39,11 +59,16 @@ var varPattern = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
 //
 // Example usage:
 //
-//	err := envload.Load(".env")
+//	err := envload.Load(".env", false)
 //	if err != nil {
 //	    slog.Error(err.Error())
 //	}
-func Load(filepath string, override bool) error {
+func Load(filepath string, override bool, opts ...Option) error {
+	var l loader
+	for _, opt := range opts {
+		opt(&l)
+	}
+
 	file, err := os.Open(filepath)
 	if err != nil {
 		return fmt.Errorf("envload: unable to open file %s: %w", filepath, err)
@@ -57,6 +82,8 @@ func Load(filepath string, override bool) error {
 		}
 	}()
 
+	var setErrs []error
+
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -88,15 +115,19 @@ func Load(filepath string, override bool) error {
 
 		err := os.Setenv(key, value)
 		if err != nil {
-			return fmt.Errorf("envload: error setting %s: %w", key, err)
+			err = fmt.Errorf("envload: error setting %q: %w", key, err)
+			if !l.collectErrors {
+				return err
+			}
+			setErrs = append(setErrs, err)
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("envload: error reading file: %w", err)
+		return errors.Join(append(setErrs, fmt.Errorf("envload: error reading file: %w", err))...)
 	}
 
-	return nil
+	return errors.Join(setErrs...)
 }
 
 // expandVars replaces ${VAR} references in a value with the corresponding
@@ -3,6 +3,7 @@ package envload
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -155,3 +156,39 @@ func TestLoad(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadSetenvErrors(t *testing.T) {
+	// NUL bytes are not allowed in environment variable keys
+	env := "PORT=8080\nBAD\x00KEY=1\nENV=development\n"
+
+	t.Run("fails fast by default", func(t *testing.T) {
+		clearEnv(t, "PORT", "ENV")
+
+		err := Load(writeTempEnv(t, env), true)
+		if err == nil {
+			t.Fatal("Expected error for invalid key")
+		}
+
+		if got := os.Getenv("PORT"); got != "8080" {
+			t.Errorf("PORT: expected '8080', got '%s'", got)
+		}
+		if got := os.Getenv("ENV"); got != "" {
+			t.Errorf("ENV: expected not to be loaded after the error, got '%s'", got)
+		}
+	})
+
+	t.Run("collects errors and loads the other keys", func(t *testing.T) {
+		clearEnv(t, "PORT", "ENV")
+
+		err := Load(writeTempEnv(t, env), true, CollectErrors())
+		if err == nil || !strings.Contains(err.Error(), "BAD") {
+			t.Fatalf("Expected error for invalid key, got %v", err)
+		}
+
+		for k, expected := range map[string]string{"PORT": "8080", "ENV": "development"} {
+			if got := os.Getenv(k); got != expected {
+				t.Errorf("%s: expected '%s', got '%s'", k, expected, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package inmemorydb
+
+import "fmt"
+
+// batchValue is a pending batch write. deleted marks a pending delete.
+type batchValue struct {
+	value   []byte
+	deleted bool
+}
+
+// Batch collects Put and Delete operations that are applied atomically by DB.Batch.
+// Reads made through the Batch see its own pending writes.
+type Batch struct {
+	db      *DB
+	entries []*entry
+	pending map[string]batchValue
+}
+
+// Batch calls fn with a new Batch and applies the collected operations atomically
+// if fn returns nil: the in-memory state is updated and the entries are appended to
+// the log like any other write(WithFlushInterval, WithBufferSize, WithFlushEvery and
+// WithWriteBehind apply), between Begin and Commit records, so Open discards a batch
+// whose Commit never reached the file. If fn returns an error, the operations are
+// discarded and the error is returned. Nothing is applied if any write exceeds the
+// size limits(ErrTooLarge).
+//
+// The write lock is held while fn runs, so fn must not call other DB methods.
+func (db *DB) Batch(fn func(b *Batch) error) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if db.closed {
+		return ErrClose
+	}
+
+	b := &Batch{
+		db:      db,
+		pending: make(map[string]batchValue),
+	}
+
+	err := fn(b)
+	if err != nil {
+		return err
+	}
+
+	// checked before writing, so a batch is never written in part
+	for _, e := range b.entries {
+		err = checkSize(e.key, e.value)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(b.entries) > 0 {
+		entries := make([]*entry, 0, len(b.entries)+2)
+		entries = append(entries, newEntry(Begin, "", nil))
+		entries = append(entries, b.entries...)
+		entries = append(entries, newEntry(Commit, "", nil))
+
+		for _, e := range entries {
+			err = db.appendEntry(e)
+			if err != nil {
+				return fmt.Errorf("inmemorydb: unable to append batch entry: %w", err)
+			}
+		}
+	}
+
+	for key, v := range b.pending {
+		if v.deleted {
+			delete(db.data, key)
+			continue
+		}
+
+		db.data[key] = v.value
+	}
+
+	return nil
+}
+
+// PutObject stores a value at the given key when the batch is applied.
+func (b *Batch) PutObject(key string, value []byte) {
+	valueCopy := make([]byte, len(value))
+	copy(valueCopy, value)
+
+	b.pending[key] = batchValue{value: valueCopy}
+	b.entries = append(b.entries, newEntry(Put, key, valueCopy))
+}
+
+// DeleteObject removes the given key when the batch is applied.
+// Returns ErrNotFound if the key does not exist.
+func (b *Batch) DeleteObject(key string) error {
+	if !b.Has(key) {
+		return ErrNotFound
+	}
+
+	b.pending[key] = batchValue{deleted: true}
+	b.entries = append(b.entries, newEntry(Del, key, nil))
+
+	return nil
+}
+
+// GetObject retrieves the value associated with the given key, including pending writes.
+// Returns ErrNotFound if the key does not exist.
+func (b *Batch) GetObject(key string) ([]byte, error) {
+	data, exists := b.get(key)
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	dataCopy := make([]byte, len(data))
+	copy(dataCopy, data)
+	return dataCopy, nil
+}
+
+// Has returns true if the given key exists, including pending writes.
+func (b *Batch) Has(key string) bool {
+	_, exists := b.get(key)
+	return exists
+}
+
+// ForEach calls fn for every key-value pair, including pending writes.
+// Order is not guaranteed. Iteration stops at the first error returned by fn.
+func (b *Batch) ForEach(fn func(key string, value []byte) error) error {
+	for k := range b.db.data {
+		if _, ok := b.pending[k]; ok {
+			continue
+		}
+
+		value, _ := b.GetObject(k)
+		err := fn(k, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	for k, v := range b.pending {
+		if v.deleted {
+			continue
+		}
+
+		value, _ := b.GetObject(k)
+		err := fn(k, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *Batch) get(key string) ([]byte, bool) {
+	if v, ok := b.pending[key]; ok {
+		if v.deleted {
+			return nil, false
+		}
+		return v.value, true
+	}
+
+	data, exists := b.db.data[key]
+	return data, exists
+}
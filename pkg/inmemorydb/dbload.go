@@ -2,20 +2,37 @@ package inmemorydb
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"slices"
+	"time"
 )
 
 // load reads the database file and reconstructs the in-memory state.
 func (db *DB) load() error {
+	if db.memory {
+		// writes are discarded, so the writer can be flushed as usual
+		db.writer = bufio.NewWriter(io.Discard)
+		return nil
+	}
+
 	// Check if file exists
 	if _, err := os.Stat(db.FilePath); os.IsNotExist(err) {
+		// the directory may not exist on the first run
+		err = os.MkdirAll(filepath.Dir(db.FilePath), 0o755)
+		if err != nil {
+			return fmt.Errorf("inmemorydb: unable to create database directory %q: %w", filepath.Dir(db.FilePath), err)
+		}
+
 		file, err := os.Create(db.FilePath)
 		if err != nil {
 			return fmt.Errorf("inmemorydb: failed file creation: %w", err)
 		}
 		db.file = file
-		db.writer = bufio.NewWriter(db.file)
+		db.writer = db.newWriter(db.file)
 		return nil
 	}
 
@@ -25,9 +42,25 @@ func (db *DB) load() error {
 	}
 
 	db.file = file
-	db.writer = bufio.NewWriter(file)
+	db.writer = db.newWriter(file)
 
-	scanner := bufio.NewScanner(file)
+	err = replayLog(file, db.data)
+	if err != nil {
+		return err
+	}
+
+	return db.Shrink()
+}
+
+// replayLog applies the log entries read from r to data. The entries of a batch are
+// applied when its Commit is read, a batch without Commit is discarded.
+func replayLog(r io.Reader, data map[string][]byte) error {
+	var (
+		batch   []*entry
+		inBatch bool
+	)
+
+	scanner := newLineScanner(r)
 	for scanner.Scan() {
 		entry, err := newEntryFromLine(scanner.Text())
 		if err != nil {
@@ -35,10 +68,23 @@ func (db *DB) load() error {
 		}
 
 		switch entry.action {
-		case Put:
-			db.data[string(entry.key)] = entry.value
-		case Del:
-			delete(db.data, string(entry.key))
+		case Begin:
+			// a previous batch without Commit is discarded
+			batch, inBatch = nil, true
+		case Commit:
+			if !inBatch {
+				return ErrBadFormat
+			}
+			for _, e := range batch {
+				applyEntry(data, e)
+			}
+			batch, inBatch = nil, false
+		case Put, Del:
+			if inBatch {
+				batch = append(batch, entry)
+				continue
+			}
+			applyEntry(data, entry)
 		default:
 			return ErrBadFormat
 		}
@@ -47,31 +93,104 @@ func (db *DB) load() error {
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("inmemorydb: failed to scan file: %w", err)
 	}
-	return db.Shrink()
+
+	return nil
+}
+
+// applyEntry applies the Put or Del entry to data.
+func applyEntry(data map[string][]byte, e *entry) {
+	if e.action == Del {
+		delete(data, e.key)
+		return
+	}
+
+	data[e.key] = e.value
+}
+
+// loadBackup replaces the database file that failed to load with loadErr by a copy
+// of the backup file and loads it. Returns loadErr if there is no backup file.
+func (db *DB) loadBackup(loadErr error) error {
+	if db.file != nil {
+		db.file.Close()
+		db.file = nil
+	}
+
+	backupPath := db.FilePath + ".bak"
+	backup, err := os.Open(backupPath)
+	if err != nil {
+		return loadErr
+	}
+	defer backup.Close()
+
+	err = os.Rename(db.FilePath, db.FilePath+".corrupt")
+	if err != nil {
+		return errors.Join(loadErr, fmt.Errorf("inmemorydb: unable to rename corrupt file: %w", err))
+	}
+
+	// copied, so the backup stays intact if it fails to load too
+	file, err := os.Create(db.FilePath)
+	if err != nil {
+		return errors.Join(loadErr, fmt.Errorf("inmemorydb: unable to create file from backup: %w", err))
+	}
+
+	_, err = io.Copy(file, backup)
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		return errors.Join(loadErr, fmt.Errorf("inmemorydb: unable to copy backup file: %w", err))
+	}
+
+	db.data = make(map[string][]byte)
+	err = db.load()
+	if err != nil {
+		return errors.Join(loadErr, fmt.Errorf("inmemorydb: unable to load backup file: %w", err))
+	}
+
+	db.onBackupFallback(loadErr)
+	return nil
 }
 
 // Close flushes pending writes to disk and closes the database file.
 // After Close is called, the database should not be used. The in-memory data is cleared.
 func (db *DB) Close() error {
+	// the flusher and the write-behind goroutine take the lock, so they are
+	// stopped before locking; the queue and the final buffer are flushed below
+	if db.flusher != nil {
+		db.flusher.Stop()
+	}
+	if db.writeBehind != nil {
+		db.writeBehind.Stop()
+	}
+
 	db.mutex.Lock()
+	defer db.mutex.Unlock()
 	if db.closed {
-		db.mutex.Unlock()
 		return ErrClose
 	}
-	errFlush := db.writer.Flush()
 
-	// want to close file even if flush fails
-	errClose := db.file.Close()
-	if errFlush != nil {
-		return fmt.Errorf("inmemorydb: unable to flush writer: %w", errFlush)
+	db.writeMutex.Lock()
+	errFlush := db.drainWriteQueue()
+	if errFlush == nil {
+		errFlush = db.writer.Flush()
+	}
+
+	// want to close file even if flush fails, the database is closed either way
+	var errClose error
+	if db.file != nil {
+		errClose = db.file.Close()
 	}
 
 	db.file = nil
 	db.writer = nil
+	db.writeMutex.Unlock()
+
 	db.closed = true
-	db.mutex.Unlock()
+	db.data = make(map[string][]byte)
 
-	db.Clear()
+	if errFlush != nil {
+		return fmt.Errorf("inmemorydb: unable to flush writer: %w", errFlush)
+	}
 	if errClose != nil {
 		return fmt.Errorf("inmemorydb: unable to close file: %w", errClose)
 	}
@@ -80,11 +199,67 @@ func (db *DB) Close() error {
 
 // Shrink compacts the database file by removing delete operations and rewriting only
 // the current state (Put operations). This is called automatically during Load().
+// The compacted state is flushed to disk before Shrink returns.
 func (db *DB) Shrink() error {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 
-	err := db.file.Close()
+	return db.rewrite()
+}
+
+// ReplaceAll atomically replaces all the data in the database with data, so readers
+// see either the old or the new data. Like Shrink, the database file is rewritten with
+// only the new data, and the previous file is kept at FilePath.bak. If the rewrite
+// fails, the in-memory data is restored and the previous data can be recovered from
+// the .bak file.
+func (db *DB) ReplaceAll(data map[string][]byte) error {
+	newData := make(map[string][]byte, len(data))
+	for key, value := range data {
+		valueCopy := make([]byte, len(value))
+		copy(valueCopy, value)
+		newData[key] = valueCopy
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if db.closed {
+		return ErrClose
+	}
+
+	oldData := db.data
+	db.data = newData
+
+	err := db.rewrite()
+	if err != nil {
+		db.data = oldData
+		return err
+	}
+
+	return nil
+}
+
+// rewrite replaces the database file with the current in-memory state,
+// keeping the previous file at FilePath.bak. The caller must hold the write lock.
+func (db *DB) rewrite() error {
+	if db.memory {
+		return nil
+	}
+
+	db.writeMutex.Lock()
+	defer db.writeMutex.Unlock()
+
+	// pending writes must be in the backup file too
+	err := db.drainWriteQueue()
+	if err != nil {
+		return fmt.Errorf("inmemorydb: unable to write queued entries while shrinking: %w", err)
+	}
+
+	err = db.writer.Flush()
+	if err != nil {
+		return fmt.Errorf("inmemorydb: unable to flush writer while shrinking: %w", err)
+	}
+
+	err = db.file.Close()
 	if err != nil {
 		return fmt.Errorf("inmemorydb: unable to close file while shrinking: %w", err)
 	}
@@ -99,20 +274,199 @@ func (db *DB) Shrink() error {
 		return fmt.Errorf("inmemorydb: unable to create file while shrinking: %w", err)
 	}
 
-	db.writer = bufio.NewWriter(db.file)
+	db.writer = db.newWriter(db.file)
+	db.logEntries = 0
 
 	for key, value := range db.data {
 		entry := newEntry(Put, key, value)
-		err := db.appendEntry(entry)
+		err := db.writeEntry(entry)
 		if err != nil {
 			return fmt.Errorf("inmemorydb: unable to append entry: %w", err)
 		}
 	}
 
+	err = db.writer.Flush()
+	if err != nil {
+		return fmt.Errorf("inmemorydb: unable to flush writer while shrinking: %w", err)
+	}
+
+	db.lastCompaction = time.Now()
 	return nil
 }
 
+// History scans the database file and returns the values stored at the given key,
+// oldest first. A delete clears the history, so only the values put after the last
+// delete are returned, up to the last MaxHistory of them. The file is compacted on
+// Open and Shrink, so the history only covers changes made since the last compaction.
+// Returns ErrNotFound if there are no values for the key.
+//
+// The file is scanned through its own handle without holding the lock, up to the
+// writes made before the call, so History doesn't block other operations.
+func (db *DB) History(key string) ([][]byte, error) {
+	file, size, err := db.openLog()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var values [][]byte
+
+	scanner := newLineScanner(io.LimitReader(file, size))
+	for scanner.Scan() {
+		entry, err := newEntryFromLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("inmemorydb: error reading entry at line: %w", err)
+		}
+
+		if entry.key != key || entry.action == Begin || entry.action == Commit {
+			continue
+		}
+
+		switch entry.action {
+		case Put:
+			if len(values) == MaxHistory {
+				values = slices.Delete(values, 0, 1)
+			}
+			values = append(values, entry.value)
+		case Del:
+			values = nil
+		default:
+			return nil, ErrBadFormat
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("inmemorydb: failed to scan file: %w", err)
+	}
+
+	if len(values) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return values, nil
+}
+
+// openLog flushes the pending writes and opens the database file for reading,
+// returning its size. The returned file stays readable after Shrink replaces the
+// database file, and writes made after openLog are beyond the returned size.
+func (db *DB) openLog() (*os.File, int64, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	if db.closed {
+		return nil, 0, ErrClose
+	}
+
+	// no log is kept in memory mode
+	if db.memory {
+		return nil, 0, ErrNotFound
+	}
+
+	// pending writes must be in the file before scanning it
+	db.writeMutex.Lock()
+	defer db.writeMutex.Unlock()
+
+	err := db.drainWriteQueue()
+	if err == nil {
+		err = db.writer.Flush()
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("inmemorydb: unable to flush writer: %w", err)
+	}
+
+	info, err := db.file.Stat()
+	if err != nil {
+		return nil, 0, fmt.Errorf("inmemorydb: unable to stat file: %w", err)
+	}
+
+	file, err := os.Open(db.FilePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("inmemorydb: failed file opening: %w", err)
+	}
+
+	return file, info.Size(), nil
+}
+
+// appendEntry appends the entry to the log, through the write-behind queue if enabled.
+// The caller must hold the write lock.
 func (db *DB) appendEntry(entry *entry) error {
-	_, err := db.writer.Write(entry.toBytes())
-	return err
+	if db.memory {
+		return nil
+	}
+
+	if db.writeBehind != nil {
+		if db.writeBehind.enqueue(entry.toBytes()) {
+			return nil
+		}
+
+		// queue is full, writing synchronously after the queued entries
+		db.writeBehind.blocked++
+	}
+
+	db.writeMutex.Lock()
+	defer db.writeMutex.Unlock()
+
+	return db.writeEntry(entry)
+}
+
+// writeEntry writes the entry to the writer, after the queued entries.
+// The caller must hold the writer lock.
+func (db *DB) writeEntry(entry *entry) error {
+	if db.memory {
+		return nil
+	}
+
+	err := db.drainWriteQueue()
+	if err != nil {
+		return err
+	}
+
+	return db.writeLine(entry.toBytes())
+}
+
+// writeLine writes the encoded entry to the writer, flushing it every flushEvery
+// entries. The caller must hold the writer lock.
+func (db *DB) writeLine(line []byte) error {
+	_, err := db.writer.Write(line)
+	if err != nil {
+		return err
+	}
+	db.logEntries++
+
+	if db.flushEvery == 0 {
+		return nil
+	}
+
+	db.unflushed++
+	if db.unflushed < db.flushEvery {
+		return nil
+	}
+
+	db.unflushed = 0
+	return db.writer.Flush()
+}
+
+// checkSize returns ErrTooLarge if the key or the value exceeds the size limits.
+func checkSize(key string, value []byte) error {
+	if len(key) > MaxKeySize || len(value) > MaxValueSize {
+		return ErrTooLarge
+	}
+
+	return nil
+}
+
+// newLineScanner returns a scanner of the log lines in r, reading lines up to maxLineSize.
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	return scanner
+}
+
+// newWriter returns the buffered writer to the database file, sized by WithBufferSize.
+func (db *DB) newWriter(file *os.File) *bufio.Writer {
+	if db.bufferSize > 0 {
+		return bufio.NewWriterSize(file, db.bufferSize)
+	}
+
+	return bufio.NewWriter(file)
 }
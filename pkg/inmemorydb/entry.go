@@ -13,6 +13,11 @@ type action string
 const (
 	Put action = "put"
 	Del action = "del"
+
+	// Begin and Commit enclose the entries written by Batch, so a batch cut
+	// short(e.g. by a crash) is discarded on load.
+	Begin  action = "begin"
+	Commit action = "commit"
 )
 
 var (
@@ -0,0 +1,68 @@
+package inmemorydb
+
+import "time"
+
+// flusher periodically flushes the DB writer in background, coalescing
+// writes made between ticks into a single flush.
+type flusher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func startFlusher(db *DB, interval time.Duration) *flusher {
+	f := &flusher{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(f.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-f.stop:
+				return
+			case <-ticker.C:
+				db.flushBuffered()
+			}
+		}
+	}()
+
+	return f
+}
+
+// Stop stops the flusher and waits for the goroutine to exit.
+// Calling Stop more than once is a no-op.
+func (f *flusher) Stop() {
+	select {
+	case <-f.stop:
+	default:
+		close(f.stop)
+	}
+
+	<-f.done
+}
+
+// flushBuffered flushes the writer if it has buffered data. The error is not
+// returned: bufio.Writer keeps it, so it's reported by the next write or Close.
+func (db *DB) flushBuffered() {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	if db.closed {
+		return
+	}
+
+	db.writeMutex.Lock()
+	defer db.writeMutex.Unlock()
+
+	_ = db.drainWriteQueue()
+	if db.writer.Buffered() == 0 {
+		return
+	}
+
+	_ = db.writer.Flush()
+}
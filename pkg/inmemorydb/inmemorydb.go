@@ -10,12 +10,32 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"time"
 )
 
+// MemoryPath opens the database in memory-only mode: nothing is read from or
+// written to disk, so the data is lost on Close.
+const MemoryPath = ":memory:"
+
+// Size limits of a record. Writes exceeding them return ErrTooLarge, so every
+// written entry fits into a log line Open can read back(maxLineSize).
+const (
+	MaxKeySize   = 1 << 10
+	MaxValueSize = 4 << 20
+)
+
+// MaxHistory is the largest number of values History returns for a key.
+const MaxHistory = 100
+
+// maxLineSize is the longest log line: the action, the base64 key and value,
+// two commas and the newline.
+const maxLineSize = len(Put) + 2 + (MaxKeySize+2)/3*4 + (MaxValueSize+2)/3*4 + 1
+
 var (
 	ErrNotFound    = errors.New("key not found")
 	ErrInvalidType = errors.New("invalid type")
 	ErrClose       = errors.New("database is closed")
+	ErrTooLarge    = errors.New("key or value is too large")
 )
 
 // DB represents an in-memory key-value database with persistent storage.
@@ -23,39 +43,183 @@ var (
 type DB struct {
 	FilePath string
 	closed   bool
+	memory   bool
 	data     map[string][]byte
 	mutex    sync.RWMutex
 	file     *os.File
 	writer   *bufio.Writer
+
+	// writeMutex guards writes to the file writer(and logEntries, unflushed). It's
+	// taken after mutex, or alone by the write-behind goroutine.
+	writeMutex sync.Mutex
+
+	flushInterval time.Duration
+	flusher       *flusher
+
+	writeQueueSize int
+	writeBehind    *writeBehind
+
+	// bufferSize is the size of the file writer buffer, set by WithBufferSize.
+	bufferSize int
+	// flushEvery is set by WithFlushEvery, unflushed counts the entries
+	// written since the last flush it made.
+	flushEvery int
+	unflushed  int
+
+	// logEntries is the number of entries written to the file since the last
+	// compaction(at lastCompaction), including the compacted state.
+	logEntries     int
+	lastCompaction time.Time
+
+	// onBackupFallback is set by WithBackupFallback
+	onBackupFallback func(err error)
+}
+
+// Option configures a DB opened with Open.
+type Option func(*DB)
+
+// WithFlushInterval starts a background goroutine that flushes buffered writes
+// to disk every interval, so writes become durable without waiting for Close.
+// 0 disables background flushing(writes are flushed on Close, Shrink and History).
+func WithFlushInterval(interval time.Duration) Option {
+	return func(db *DB) {
+		db.flushInterval = interval
+	}
+}
+
+// WithWriteBehind makes writes return after updating memory, appending the log entry
+// to the file writer in background. Up to queueSize entries are queued, when the queue
+// is full the write is made synchronously(counted in Stats.BlockedWrites). Queued
+// entries are written before Shrink, History and Close, so the log order is kept. 0 disables write-behind. Ignored in memory mode.
+//
+// Like buffered writes, queued writes are lost if the process crashes.
+func WithWriteBehind(queueSize int) Option {
+	return func(db *DB) {
+		db.writeQueueSize = queueSize
+	}
+}
+
+// WithBufferSize sets the size in bytes of the buffer writes are collected in before
+// being written to the file. A larger buffer means fewer syscalls for write-heavy
+// workloads and more writes lost if the process crashes. 0 uses the bufio default(4KB).
+func WithBufferSize(size int) Option {
+	return func(db *DB) {
+		db.bufferSize = size
+	}
+}
+
+// WithFlushEvery makes every n-th log entry flush the buffer to the file, so at most
+// n-1 entries are buffered(1 flushes every write, trading throughput for durability).
+// The buffer is still flushed when full, by WithFlushInterval and on Close.
+// 0 disables flushing by the number of writes.
+func WithFlushEvery(n int) Option {
+	return func(db *DB) {
+		db.flushEvery = n
+	}
+}
+
+// WithBackupFallback makes Open fall back to the backup file(FilePath.bak, left by
+// Shrink) when the database file fails to load. The failed file is kept at
+// FilePath.corrupt and onFallback is called with the load error(e.g. to log it).
+func WithBackupFallback(onFallback func(err error)) Option {
+	return func(db *DB) {
+		db.onBackupFallback = onFallback
+	}
 }
 
 // Open creates and returns a new database instance. It loads existing data from the file
-// at filePath, creating the file if it doesn't exist.
+// at filePath, creating the file(and its directory) if it doesn't exist.
 // The returned DB should be closed with Close() when no longer needed.
 //
 // Dont open the same file twice. Opening the same file simoltaneously twice will result in ub.
-func Open(filePath string) (*DB, error) {
+//
+// MemoryPath opens the database without a file(see OpenMemory).
+func Open(filePath string, opts ...Option) (*DB, error) {
 	db := &DB{
 		data:     make(map[string][]byte),
 		FilePath: filePath,
+		memory:   filePath == MemoryPath,
+	}
+
+	for _, opt := range opts {
+		opt(db)
 	}
+
 	err := db.load()
+	if err != nil && db.onBackupFallback != nil {
+		err = db.loadBackup(err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("inmemorydb: failed to load database: %w", err)
 	}
 
+	if db.flushInterval > 0 {
+		db.flusher = startFlusher(db, db.flushInterval)
+	}
+
+	if db.writeQueueSize > 0 && !db.memory {
+		db.writeBehind = startWriteBehind(db, db.writeQueueSize)
+	}
+
 	return db, nil
 }
 
+// OpenMemory opens a database that keeps data only in memory, without a file
+// (same as Open(MemoryPath)). Shrink is a no-op, History returns ErrNotFound and
+// the data is lost on Close. Useful for tests and stateless runs.
+func OpenMemory(opts ...Option) (*DB, error) {
+	return Open(MemoryPath, opts...)
+}
+
 // PutObject stores a value in the database at the given key. Overrides existing key value.
-// The operation is persisted to disk.
+// The operation is persisted to disk. Returns ErrTooLarge if the key is longer than
+// MaxKeySize or the value is longer than MaxValueSize.
 func (db *DB) PutObject(key string, value []byte) error {
+	err := checkSize(key, value)
+	if err != nil {
+		return err
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if db.closed {
+		return ErrClose
+	}
+
+	db.data[key] = value
+	return db.appendEntry(newEntry(Put, key, value))
+}
+
+// Update atomically replaces the value at the given key with the value returned by fn.
+// fn receives a copy of the current value and whether the key exists(nil and false
+// for a missing key). If fn returns an error, nothing is written and the error is
+// returned. The operation is persisted to disk. Returns ErrTooLarge like PutObject.
+//
+// The write lock is held while fn runs, so fn must not call other DB methods.
+func (db *DB) Update(key string, fn func(value []byte, exists bool) ([]byte, error)) error {
 	db.mutex.Lock()
 	defer db.mutex.Unlock()
 	if db.closed {
 		return ErrClose
 	}
 
+	var valueCopy []byte
+	current, exists := db.data[key]
+	if exists {
+		valueCopy = make([]byte, len(current))
+		copy(valueCopy, current)
+	}
+
+	value, err := fn(valueCopy, exists)
+	if err != nil {
+		return err
+	}
+
+	err = checkSize(key, value)
+	if err != nil {
+		return err
+	}
+
 	db.data[key] = value
 	return db.appendEntry(newEntry(Put, key, value))
 }
@@ -100,6 +264,32 @@ func (db *DB) GetAllObjects() [][]byte {
 	return dataCopy
 }
 
+// ForEach calls fn for every key-value pair stored in the database.
+// Order is not guaranteed. Iteration stops at the first error returned by fn
+// and the error is returned to the caller. fn receives a copy of the value.
+//
+// The read lock is held during iteration, so fn must not modify the database.
+func (db *DB) ForEach(fn func(key string, value []byte) error) error {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	if db.closed {
+		return ErrClose
+	}
+
+	for k, v := range db.data {
+		valueCopy := make([]byte, len(v))
+		copy(valueCopy, v)
+
+		err := fn(k, valueCopy)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // DeleteObject removes the value associated with the given key from the database.
 // Returns ErrNotFound if the key does not exist. The operation is persisted to disk.
 func (db *DB) DeleteObject(key string) error {
@@ -145,3 +335,62 @@ func (db *DB) Size() int {
 
 	return len(db.data)
 }
+
+// Stats describes the current state of the database.
+type Stats struct {
+	// Keys is the number of keys currently stored.
+	Keys int `json:"keys"`
+	// FileSize is the size of the database file in bytes, including writes
+	// buffered but not yet flushed to disk.
+	FileSize int64 `json:"file_size"`
+	// WriteQueueDepth is the number of writes queued by write-behind mode and
+	// BlockedWrites is the number of writes made synchronously because the queue was full.
+	WriteQueueDepth int   `json:"write_queue_depth"`
+	BlockedWrites   int64 `json:"blocked_writes"`
+	// LogEntries is the number of entries in the database file(including buffered and
+	// queued ones), StaleRatio is the share of them overwritten or deleted since,
+	// which Shrink reclaims.
+	LogEntries int     `json:"log_entries"`
+	StaleRatio float64 `json:"stale_ratio"`
+	// LastCompaction is when the file was last compacted by Shrink(Open compacts it too).
+	LastCompaction time.Time `json:"last_compaction,omitzero"`
+}
+
+// Stats returns the current database statistics.
+func (db *DB) Stats() (Stats, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	if db.closed {
+		return Stats{}, ErrClose
+	}
+
+	if db.memory {
+		return Stats{Keys: len(db.data)}, nil
+	}
+
+	db.writeMutex.Lock()
+	defer db.writeMutex.Unlock()
+
+	info, err := db.file.Stat()
+	if err != nil {
+		return Stats{}, fmt.Errorf("inmemorydb: unable to stat file: %w", err)
+	}
+
+	stats := Stats{
+		Keys:           len(db.data),
+		FileSize:       info.Size() + int64(db.writer.Buffered()),
+		LogEntries:     db.logEntries,
+		LastCompaction: db.lastCompaction,
+	}
+	if db.writeBehind != nil {
+		stats.WriteQueueDepth = len(db.writeBehind.queue)
+		stats.BlockedWrites = db.writeBehind.blocked
+		stats.LogEntries += stats.WriteQueueDepth
+	}
+	if stats.LogEntries > 0 {
+		stats.StaleRatio = float64(stats.LogEntries-stats.Keys) / float64(stats.LogEntries)
+	}
+
+	return stats, nil
+}
@@ -3,10 +3,13 @@ package inmemorydb
 import (
 	"bytes"
 	"encoding/gob"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"testing"
+	"time"
 )
 
 type Task struct {
@@ -471,6 +474,69 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestLargeValues(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test_db.dat")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	// longer than the default bufio.Scanner token once base64-encoded
+	large := bytes.Repeat([]byte("a"), 60*1024)
+	err = db.PutObject("large", large)
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	largest := bytes.Repeat([]byte("b"), MaxValueSize)
+	err = db.PutObject(string(bytes.Repeat([]byte("k"), MaxKeySize)), largest)
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	err = db.PutObject("too large", make([]byte, MaxValueSize+1))
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge for a large value, got %v", err)
+	}
+
+	err = db.PutObject(string(make([]byte, MaxKeySize+1)), nil)
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge for a large key, got %v", err)
+	}
+
+	err = db.Batch(func(b *Batch) error {
+		b.PutObject("small", []byte("value"))
+		b.PutObject("too large", make([]byte, MaxValueSize+1))
+		return nil
+	})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Errorf("Expected ErrTooLarge for a batch, got %v", err)
+	}
+	if db.Has("small") {
+		t.Error("Expected failed batch not to be applied")
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Expected large values to be read back, got %v", err)
+	}
+	defer db.Close()
+
+	value, err := db.GetObject("large")
+	if err != nil || !bytes.Equal(value, large) {
+		t.Errorf("Expected large value to be loaded, got %d bytes and %v", len(value), err)
+	}
+	if db.Size() != 2 {
+		t.Errorf("Expected 2 keys, got %d", db.Size())
+	}
+}
+
 func TestLoadNonExistentFile(t *testing.T) {
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "nonexistent_db.dat")
@@ -496,6 +562,40 @@ func TestLoadNonExistentFile(t *testing.T) {
 	}
 }
 
+func TestLoadNonExistentDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "data", "nested", "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	defer func() {
+		e := db.Close()
+		if e != nil {
+			t.Errorf("Close failed: %v", e)
+		}
+	}()
+
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Errorf("Open should have created the file and its directory: %v", err)
+	}
+
+	t.Run("directory path is a file", func(t *testing.T) {
+		filePath := filepath.Join(tmpDir, "file")
+		err := os.WriteFile(filePath, nil, 0o644)
+		if err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		_, err = Open(filepath.Join(filePath, "test.db"))
+		if err == nil {
+			t.Error("Expected error when the directory path is a file")
+		}
+	})
+}
+
 func TestClose(t *testing.T) {
 	tmpDir := t.TempDir()
 	dbPath := filepath.Join(tmpDir, "test_db.dat")
@@ -537,3 +637,1275 @@ func TestClose(t *testing.T) {
 		t.Error("Database should be closed after Close()")
 	}
 }
+
+func TestForEach(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() {
+		e := db.Close()
+		if e != nil {
+			t.Errorf("Close failed: %v", e)
+		}
+	}()
+
+	err = db.PutObject("key1", []byte("value1"))
+	if err != nil {
+		t.Errorf("PutObject failed: %v", err)
+	}
+	err = db.PutObject("key2", []byte("value2"))
+	if err != nil {
+		t.Errorf("PutObject failed: %v", err)
+	}
+
+	visited := make(map[string]string)
+	err = db.ForEach(func(key string, value []byte) error {
+		visited[key] = string(value)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("ForEach failed: %v", err)
+	}
+
+	if len(visited) != 2 || visited["key1"] != "value1" || visited["key2"] != "value2" {
+		t.Errorf("Unexpected visited entries: %v", visited)
+	}
+
+	errStop := errors.New("stop")
+	calls := 0
+	err = db.ForEach(func(key string, value []byte) error {
+		calls++
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("Expected errStop, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected iteration to stop after first error, got %d calls", calls)
+	}
+}
+
+func TestStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Keys != 0 || stats.FileSize != 0 {
+		t.Errorf("Expected empty stats, got %+v", stats)
+	}
+
+	err = db.PutObject("key", []byte("value"))
+	if err != nil {
+		t.Errorf("PutObject failed: %v", err)
+	}
+
+	stats, err = db.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	entrySize := int64(len(newEntry(Put, "key", []byte("value")).toBytes()))
+	if stats.Keys != 1 || stats.FileSize != entrySize {
+		t.Errorf("Expected 1 key and %d bytes, got %+v", entrySize, stats)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Errorf("Close failed: %v", err)
+	}
+
+	_, err = db.Stats()
+	if err != ErrClose {
+		t.Errorf("Expected ErrClose, got: %v", err)
+	}
+}
+
+func TestStatsStaleRatio(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	// 4 puts of the same key and a put and delete of another: 6 entries, 1 key
+	for i := range 4 {
+		err = db.PutObject("key", []byte{byte(i)})
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+	}
+	err = db.PutObject("other", []byte("value"))
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+	err = db.DeleteObject("other")
+	if err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.LogEntries != 6 || stats.StaleRatio != 5.0/6 {
+		t.Errorf("Expected 6 entries with stale ratio %f, got %+v", 5.0/6, stats)
+	}
+	if !stats.LastCompaction.IsZero() {
+		t.Errorf("Expected no compaction of a new file, got %s", stats.LastCompaction)
+	}
+
+	before := time.Now()
+	err = db.Shrink()
+	if err != nil {
+		t.Fatalf("Shrink failed: %v", err)
+	}
+
+	stats, err = db.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.LogEntries != 1 || stats.StaleRatio != 0 {
+		t.Errorf("Expected 1 entry without stale ones after Shrink, got %+v", stats)
+	}
+	if stats.LastCompaction.Before(before) {
+		t.Errorf("Expected compaction time after %s, got %s", before, stats.LastCompaction)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	err = db.PutObject("a", []byte("1"))
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	t.Run("discards writes on error", func(t *testing.T) {
+		batchErr := errors.New("batch error")
+
+		err := db.Batch(func(b *Batch) error {
+			b.PutObject("b", []byte("2"))
+
+			err := b.DeleteObject("a")
+			if err != nil {
+				return err
+			}
+
+			return batchErr
+		})
+		if !errors.Is(err, batchErr) {
+			t.Errorf("Expected batch error, got %v", err)
+		}
+
+		if db.Has("b") {
+			t.Error("Expected key 'b' not to be stored")
+		}
+		if !db.Has("a") {
+			t.Error("Expected key 'a' not to be deleted")
+		}
+	})
+
+	t.Run("applies all writes on success", func(t *testing.T) {
+		err := db.Batch(func(b *Batch) error {
+			b.PutObject("b", []byte("2"))
+			b.PutObject("c", []byte("3"))
+
+			// batch reads see pending writes
+			value, err := b.GetObject("b")
+			if err != nil || string(value) != "2" {
+				t.Errorf("Expected pending value '2', got '%s'(err: %v)", value, err)
+			}
+
+			return b.DeleteObject("a")
+		})
+		if err != nil {
+			t.Fatalf("Batch failed: %v", err)
+		}
+
+		if db.Has("a") || !db.Has("b") || !db.Has("c") {
+			t.Errorf("Unexpected keys after batch: a=%t b=%t c=%t", db.Has("a"), db.Has("b"), db.Has("c"))
+		}
+	})
+
+	t.Run("delete of missing key fails", func(t *testing.T) {
+		err := db.Batch(func(b *Batch) error {
+			return b.DeleteObject("missing")
+		})
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("for each sees pending writes", func(t *testing.T) {
+		err := db.Batch(func(b *Batch) error {
+			b.PutObject("d", []byte("4"))
+			err := b.DeleteObject("b")
+			if err != nil {
+				return err
+			}
+
+			keys := make(map[string]string)
+			err = b.ForEach(func(key string, value []byte) error {
+				keys[key] = string(value)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(keys) != 2 || keys["c"] != "3" || keys["d"] != "4" {
+				t.Errorf("Unexpected keys in batch: %v", keys)
+			}
+
+			return errors.New("rollback")
+		})
+		if err == nil {
+			t.Error("Expected rollback error")
+		}
+	})
+
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	t.Run("batch is persisted", func(t *testing.T) {
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer func() {
+			e := db.Close()
+			if e != nil {
+				t.Errorf("Close failed: %v", e)
+			}
+		}()
+
+		if db.Has("a") || !db.Has("b") || !db.Has("c") || db.Has("d") {
+			t.Errorf("Unexpected keys after reopen: a=%t b=%t c=%t d=%t", db.Has("a"), db.Has("b"), db.Has("c"), db.Has("d"))
+		}
+	})
+}
+
+func TestBatchLog(t *testing.T) {
+	t.Run("batch is buffered like other writes", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+
+		err = db.Batch(func(b *Batch) error {
+			b.PutObject("key", []byte("value"))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Batch failed: %v", err)
+		}
+
+		content, err := os.ReadFile(dbPath)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		if len(content) != 0 {
+			t.Errorf("Expected batch to stay buffered, file has %q", content)
+		}
+
+		err = db.Close()
+		if err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		content, err = os.ReadFile(dbPath)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+
+		want := string(newEntry(Begin, "", nil).toBytes()) +
+			string(newEntry(Put, "key", []byte("value")).toBytes()) +
+			string(newEntry(Commit, "", nil).toBytes())
+		if string(content) != want {
+			t.Errorf("Expected log %q, got %q", want, content)
+		}
+	})
+
+	t.Run("incomplete batch is discarded on load", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		var log []byte
+		for _, e := range []*entry{
+			newEntry(Put, "a", []byte("1")),
+			newEntry(Begin, "", nil),
+			newEntry(Put, "b", []byte("2")),
+			newEntry(Commit, "", nil),
+			// cut short before its commit
+			newEntry(Begin, "", nil),
+			newEntry(Put, "c", []byte("3")),
+			newEntry(Del, "a", nil),
+		} {
+			log = append(log, e.toBytes()...)
+		}
+
+		err := os.WriteFile(dbPath, log, 0o644)
+		if err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer db.Close()
+
+		if !db.Has("a") || !db.Has("b") || db.Has("c") {
+			t.Errorf("Unexpected keys after load: a=%t b=%t c=%t", db.Has("a"), db.Has("b"), db.Has("c"))
+		}
+	})
+
+	t.Run("commit without begin is a bad format", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		err := os.WriteFile(dbPath, newEntry(Commit, "", nil).toBytes(), 0o644)
+		if err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+
+		_, err = Open(dbPath)
+		if !errors.Is(err, ErrBadFormat) {
+			t.Errorf("Expected ErrBadFormat, got %v", err)
+		}
+	})
+}
+
+func TestHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer func() {
+		e := db.Close()
+		if e != nil {
+			t.Errorf("Close failed: %v", e)
+		}
+	}()
+
+	for _, v := range []string{"v1", "v2"} {
+		err = db.PutObject("key", []byte(v))
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+	}
+
+	err = db.PutObject("other", []byte("other"))
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	history, err := db.History("key")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 2 || string(history[0]) != "v1" || string(history[1]) != "v2" {
+		t.Errorf("Expected history [v1 v2], got %q", history)
+	}
+
+	err = db.DeleteObject("key")
+	if err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	_, err = db.History("key")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+
+	err = db.PutObject("key", []byte("v3"))
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	history, err = db.History("key")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != 1 || string(history[0]) != "v3" {
+		t.Errorf("Expected history [v3], got %q", history)
+	}
+
+	for i := range MaxHistory + 5 {
+		err = db.PutObject("many", []byte(strconv.Itoa(i)))
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+	}
+
+	history, err = db.History("many")
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	if len(history) != MaxHistory || string(history[0]) != "5" || string(history[MaxHistory-1]) != strconv.Itoa(MaxHistory+4) {
+		t.Errorf("Expected the last %d values, got %d from %q", MaxHistory, len(history), history[0])
+	}
+}
+
+func TestFlushInterval(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	db, err := Open(dbPath, WithFlushInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	t.Run("flushes writes in background", func(t *testing.T) {
+		err := db.PutObject("flushed", []byte("value"))
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			content, err := os.ReadFile(dbPath)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+
+			if bytes.Contains(content, newEntry(Put, "flushed", []byte("value")).toBytes()) {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				t.Fatal("Expected entry to be flushed to disk without explicit Flush")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	})
+
+	t.Run("close stops flusher and flushes final buffer", func(t *testing.T) {
+		err := db.PutObject("last", []byte("value"))
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+
+		err = db.Close()
+		if err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		select {
+		case <-db.flusher.done:
+		default:
+			t.Error("Expected flusher goroutine to be stopped after Close")
+		}
+
+		reopened, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer reopened.Close()
+
+		if !reopened.Has("flushed") || !reopened.Has("last") {
+			t.Error("Expected both entries to be persisted")
+		}
+
+		if !errors.Is(db.Close(), ErrClose) {
+			t.Error("Expected ErrClose on second Close")
+		}
+	})
+}
+
+func TestFlushEvery(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := Open(dbPath, WithFlushEvery(3))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	fileContains := func(key string) bool {
+		t.Helper()
+
+		content, err := os.ReadFile(dbPath)
+		if err != nil {
+			t.Fatalf("ReadFile failed: %v", err)
+		}
+		return bytes.Contains(content, newEntry(Put, key, []byte("value")).toBytes())
+	}
+
+	for i, key := range []string{"key1", "key2", "key3"} {
+		err := db.PutObject(key, []byte("value"))
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+
+		if i < 2 && fileContains(key) {
+			t.Errorf("Expected %s to stay buffered before 3 writes", key)
+		}
+	}
+
+	for _, key := range []string{"key1", "key2", "key3"} {
+		if !fileContains(key) {
+			t.Errorf("Expected %s to be durable after 3 writes", key)
+		}
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := Open(dbPath, WithBufferSize(1<<20))
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	// more than the default 4KB buffer
+	value := bytes.Repeat([]byte("v"), 100)
+	for i := range 100 {
+		err := db.PutObject("key"+strconv.Itoa(i), value)
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+	}
+
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatalf("Stat failed: %v", err)
+	}
+	if info.Size() != 0 {
+		t.Errorf("Expected writes to stay in the 1MB buffer, file size is %d", info.Size())
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if db.Size() != 100 {
+		t.Fatalf("Expected 100 keys after reopening, got %d", db.Size())
+	}
+	for i := range 100 {
+		got, err := db.GetObject("key" + strconv.Itoa(i))
+		if err != nil {
+			t.Fatalf("GetObject failed: %v", err)
+		}
+		if !bytes.Equal(got, value) {
+			t.Errorf("Expected value of key%d to persist, got %q", i, got)
+		}
+	}
+}
+
+func TestWriteBehind(t *testing.T) {
+	t.Run("writes become durable in background", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		db, err := Open(dbPath, WithWriteBehind(100), WithFlushInterval(10*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer db.Close()
+
+		err = db.PutObject("queued", []byte("value"))
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+
+		deadline := time.Now().Add(time.Second)
+		for {
+			content, err := os.ReadFile(dbPath)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+
+			if bytes.Contains(content, newEntry(Put, "queued", []byte("value")).toBytes()) {
+				break
+			}
+
+			if time.Now().After(deadline) {
+				t.Fatal("Expected queued entry to be written to disk")
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		stats, err := db.Stats()
+		if err != nil {
+			t.Fatalf("Stats failed: %v", err)
+		}
+		if stats.WriteQueueDepth != 0 {
+			t.Errorf("Expected empty queue, got depth %d", stats.WriteQueueDepth)
+		}
+	})
+
+	t.Run("full queue falls back to synchronous write in order", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		db, err := Open(dbPath, WithWriteBehind(1))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+
+		// without the background goroutine the queue is only drained by writes
+		db.writeBehind.Stop()
+
+		for _, value := range []string{"first", "second", "third"} {
+			err := db.PutObject("key", []byte(value))
+			if err != nil {
+				t.Fatalf("PutObject failed: %v", err)
+			}
+		}
+		err = db.DeleteObject("key")
+		if err != nil {
+			t.Fatalf("DeleteObject failed: %v", err)
+		}
+		err = db.PutObject("key", []byte("last"))
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+
+		stats, err := db.Stats()
+		if err != nil {
+			t.Fatalf("Stats failed: %v", err)
+		}
+		if stats.WriteQueueDepth != 1 {
+			t.Errorf("Expected queue depth 1, got %d", stats.WriteQueueDepth)
+		}
+		if stats.BlockedWrites != 2 {
+			t.Errorf("Expected 2 blocked writes, got %d", stats.BlockedWrites)
+		}
+
+		history, err := db.History("key")
+		if err != nil {
+			t.Fatalf("History failed: %v", err)
+		}
+		if len(history) != 1 || string(history[0]) != "last" {
+			t.Errorf("Expected history [last] after delete, got %q", history)
+		}
+
+		err = db.Close()
+		if err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	})
+
+	t.Run("close drains the queue", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		db, err := Open(dbPath, WithWriteBehind(1000))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+
+		for i := range 500 {
+			err := db.PutObject("key"+strconv.Itoa(i), []byte("value"))
+			if err != nil {
+				t.Fatalf("PutObject failed: %v", err)
+			}
+		}
+
+		err = db.Close()
+		if err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+
+		select {
+		case <-db.writeBehind.done:
+		default:
+			t.Error("Expected write-behind goroutine to be stopped after Close")
+		}
+
+		reopened, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer reopened.Close()
+
+		if reopened.Size() != 500 {
+			t.Errorf("Expected 500 persisted keys, got %d", reopened.Size())
+		}
+	})
+
+	t.Run("draining does not block the database", func(t *testing.T) {
+		db, err := Open(filepath.Join(t.TempDir(), "test.db"), WithWriteBehind(10))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer db.Close()
+
+		// the drain is stuck in a slow write to the file
+		db.writeMutex.Lock()
+
+		done := make(chan error)
+		go func() {
+			err := db.PutObject("key", []byte("value"))
+			if err == nil {
+				_, err = db.GetObject("key")
+			}
+			done <- err
+		}()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Error("Expected writes and reads not to wait for the drain")
+		}
+
+		db.writeMutex.Unlock()
+	})
+
+	t.Run("failed close releases the database", func(t *testing.T) {
+		db, err := Open(filepath.Join(t.TempDir(), "test.db"), WithWriteBehind(10))
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+
+		// the queued entry can't be written to the closed file
+		db.writeMutex.Lock()
+		err = db.PutObject("key", []byte("value"))
+		if err != nil {
+			t.Fatalf("PutObject failed: %v", err)
+		}
+		db.file.Close()
+		db.writeMutex.Unlock()
+
+		err = db.Close()
+		if err == nil {
+			t.Error("Expected Close to fail")
+		}
+
+		if _, err := db.GetObject("key"); !errors.Is(err, ErrClose) {
+			t.Errorf("Expected ErrClose after failed Close, got %v", err)
+		}
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	tmpDir := t.TempDir()
+	dbPath := filepath.Join(tmpDir, "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	defer func() {
+		e := db.Close()
+		if e != nil {
+			t.Errorf("Close failed: %v", e)
+		}
+	}()
+
+	t.Run("creates missing key", func(t *testing.T) {
+		err := db.Update("key", func(value []byte, exists bool) ([]byte, error) {
+			if exists || value != nil {
+				t.Errorf("Expected missing key, got %q", value)
+			}
+			return []byte("a"), nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+
+		value, err := db.GetObject("key")
+		if err != nil || string(value) != "a" {
+			t.Errorf("Expected 'a', got %q(%v)", value, err)
+		}
+	})
+
+	t.Run("replaces existing value", func(t *testing.T) {
+		err := db.Update("key", func(value []byte, exists bool) ([]byte, error) {
+			return append(value, 'b'), nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+
+		value, err := db.GetObject("key")
+		if err != nil || string(value) != "ab" {
+			t.Errorf("Expected 'ab', got %q(%v)", value, err)
+		}
+	})
+
+	t.Run("error leaves value unchanged", func(t *testing.T) {
+		errFn := errors.New("fn error")
+		err := db.Update("key", func(value []byte, exists bool) ([]byte, error) {
+			return nil, errFn
+		})
+		if !errors.Is(err, errFn) {
+			t.Errorf("Expected fn error, got %v", err)
+		}
+
+		value, err := db.GetObject("key")
+		if err != nil || string(value) != "ab" {
+			t.Errorf("Expected 'ab', got %q(%v)", value, err)
+		}
+	})
+}
+
+func TestReplaceAll(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+
+	for _, key := range []string{"old1", "old2", "shared"} {
+		if err := db.PutObject(key, []byte("old")); err != nil {
+			t.Fatalf("Failed to put object: %v", err)
+		}
+	}
+
+	data := map[string][]byte{
+		"new1":   []byte("new1"),
+		"shared": []byte("new"),
+	}
+
+	err = db.ReplaceAll(data)
+	if err != nil {
+		t.Fatalf("Failed to replace data: %v", err)
+	}
+
+	// the database keeps its own copy
+	data["new1"][0] = 'X'
+
+	check := func(t *testing.T, db *DB) {
+		t.Helper()
+
+		if db.Size() != 2 {
+			t.Errorf("Expected 2 keys, got %d", db.Size())
+		}
+		for _, key := range []string{"old1", "old2"} {
+			if db.Has(key) {
+				t.Errorf("Expected key %q to be removed", key)
+			}
+		}
+		for key, expected := range map[string]string{"new1": "new1", "shared": "new"} {
+			value, err := db.GetObject(key)
+			if err != nil {
+				t.Fatalf("Failed to get %q: %v", key, err)
+			}
+			if string(value) != expected {
+				t.Errorf("Expected %q at %q, got %q", expected, key, value)
+			}
+		}
+	}
+
+	check(t, db)
+
+	if _, err := os.Stat(dbPath + ".bak"); err != nil {
+		t.Errorf("Expected backup file to exist, got %v", err)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Failed to close database: %v", err)
+	}
+
+	db, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to reopen database: %v", err)
+	}
+	defer db.Close()
+
+	check(t, db)
+
+	t.Run("closed database", func(t *testing.T) {
+		db, err := Open(filepath.Join(t.TempDir(), "closed.db"))
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		db.Close()
+
+		if err := db.ReplaceAll(data); !errors.Is(err, ErrClose) {
+			t.Errorf("Expected ErrClose, got %v", err)
+		}
+	})
+}
+
+func TestMerge(t *testing.T) {
+	create := func(t *testing.T, path string, data map[string]string) {
+		t.Helper()
+
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		for key, value := range data {
+			if err := db.PutObject(key, []byte(value)); err != nil {
+				t.Fatalf("PutObject failed: %v", err)
+			}
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		overwrite bool
+		expected  map[string]string
+	}{
+		{
+			name:     "keeps dst values on conflict",
+			expected: map[string]string{"dst": "dst", "shared": "from dst", "src": "src"},
+		},
+		{
+			name:      "overwrites dst values on conflict",
+			overwrite: true,
+			expected:  map[string]string{"dst": "dst", "shared": "from src", "src": "src"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			dstPath := filepath.Join(tmpDir, "dst.db")
+			srcPath := filepath.Join(tmpDir, "src.db")
+
+			create(t, dstPath, map[string]string{"dst": "dst", "shared": "from dst"})
+			create(t, srcPath, map[string]string{"src": "src", "shared": "from src"})
+
+			srcBefore, err := os.ReadFile(srcPath)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+
+			err = Merge(dstPath, srcPath, tt.overwrite)
+			if err != nil {
+				t.Fatalf("Merge failed: %v", err)
+			}
+
+			db, err := Open(dstPath)
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer db.Close()
+
+			if db.Size() != len(tt.expected) {
+				t.Errorf("Expected %d keys, got %d", len(tt.expected), db.Size())
+			}
+			for key, expected := range tt.expected {
+				value, err := db.GetObject(key)
+				if err != nil {
+					t.Fatalf("GetObject(%q) failed: %v", key, err)
+				}
+				if string(value) != expected {
+					t.Errorf("Expected %q at %q, got %q", expected, key, value)
+				}
+			}
+
+			srcAfter, err := os.ReadFile(srcPath)
+			if err != nil {
+				t.Fatalf("ReadFile failed: %v", err)
+			}
+			if !bytes.Equal(srcBefore, srcAfter) {
+				t.Error("Expected src file to be left unchanged")
+			}
+		})
+	}
+
+	t.Run("creates missing dst", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dstPath := filepath.Join(tmpDir, "dst.db")
+		srcPath := filepath.Join(tmpDir, "src.db")
+
+		create(t, srcPath, map[string]string{"src": "src"})
+
+		err := Merge(dstPath, srcPath, false)
+		if err != nil {
+			t.Fatalf("Merge failed: %v", err)
+		}
+
+		db, err := Open(dstPath)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		defer db.Close()
+
+		if !db.Has("src") {
+			t.Error("Expected src key to be merged into new dst")
+		}
+	})
+
+	t.Run("fails for missing src and same file", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		dstPath := filepath.Join(tmpDir, "dst.db")
+
+		if err := Merge(dstPath, filepath.Join(tmpDir, "missing.db"), false); err == nil {
+			t.Error("Expected error for missing src")
+		}
+		if err := Merge(dstPath, dstPath, false); err == nil {
+			t.Error("Expected error for merging database into itself")
+		}
+	})
+}
+
+func TestBackupFallback(t *testing.T) {
+	setup := func(t *testing.T) string {
+		t.Helper()
+
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		if err := db.PutObject("key", []byte("value")); err != nil {
+			t.Fatalf("Failed to put object: %v", err)
+		}
+
+		// Shrink moves the current file to .bak
+		if err := db.Shrink(); err != nil {
+			t.Fatalf("Failed to shrink: %v", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf("Failed to close database: %v", err)
+		}
+
+		err = os.WriteFile(dbPath, []byte("corrupt line\n"), 0o644)
+		if err != nil {
+			t.Fatalf("Failed to corrupt database file: %v", err)
+		}
+
+		return dbPath
+	}
+
+	t.Run("restores from backup", func(t *testing.T) {
+		dbPath := setup(t)
+
+		var fallbackErr error
+		db, err := Open(dbPath, WithBackupFallback(func(err error) {
+			fallbackErr = err
+		}))
+		if err != nil {
+			t.Fatalf("Expected successful open, got %v", err)
+		}
+		defer db.Close()
+
+		if !errors.Is(fallbackErr, ErrBadFormat) {
+			t.Errorf("Expected fallback with ErrBadFormat, got %v", fallbackErr)
+		}
+
+		value, err := db.GetObject("key")
+		if err != nil || string(value) != "value" {
+			t.Errorf("Expected backup data, got %q, %v", value, err)
+		}
+
+		corrupt, err := os.ReadFile(dbPath + ".corrupt")
+		if err != nil || string(corrupt) != "corrupt line\n" {
+			t.Errorf("Expected corrupt file to be kept, got %q, %v", corrupt, err)
+		}
+	})
+
+	t.Run("fails without the option", func(t *testing.T) {
+		dbPath := setup(t)
+
+		_, err := Open(dbPath)
+		if !errors.Is(err, ErrBadFormat) {
+			t.Errorf("Expected ErrBadFormat, got %v", err)
+		}
+	})
+
+	t.Run("fails without backup file", func(t *testing.T) {
+		dbPath := setup(t)
+		if err := os.Remove(dbPath + ".bak"); err != nil {
+			t.Fatalf("Failed to remove backup: %v", err)
+		}
+
+		_, err := Open(dbPath, WithBackupFallback(func(err error) {
+			t.Error("Expected no fallback")
+		}))
+		if !errors.Is(err, ErrBadFormat) {
+			t.Errorf("Expected ErrBadFormat, got %v", err)
+		}
+	})
+}
+
+func TestOpenMemory(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+
+	db, err := OpenMemory(WithFlushInterval(time.Millisecond))
+	if err != nil {
+		t.Fatalf("OpenMemory failed: %v", err)
+	}
+
+	err = db.PutObject("a", []byte("1"))
+	if err != nil {
+		t.Fatalf("PutObject failed: %v", err)
+	}
+
+	err = db.Update("a", func(value []byte, exists bool) ([]byte, error) {
+		return append(value, '2'), nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	err = db.Batch(func(b *Batch) error {
+		b.PutObject("b", []byte("3"))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Batch failed: %v", err)
+	}
+
+	value, err := db.GetObject("a")
+	if err != nil {
+		t.Fatalf("GetObject failed: %v", err)
+	}
+	if string(value) != "12" {
+		t.Errorf("Expected value '12', got %q", value)
+	}
+
+	err = db.DeleteObject("b")
+	if err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+
+	err = db.Shrink()
+	if err != nil {
+		t.Fatalf("Shrink failed: %v", err)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Keys != 1 || stats.FileSize != 0 {
+		t.Errorf("Expected 1 key and no file, got %+v", stats)
+	}
+
+	_, err = db.History("a")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound from History, got %v", err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("ReadDir failed: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no files to be created, got %d", len(entries))
+	}
+}
+
+func TestVerify(t *testing.T) {
+	setup := func(t *testing.T) string {
+		t.Helper()
+
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Failed to open database: %v", err)
+		}
+		for _, key := range []string{"a", "b", "c"} {
+			if err := db.PutObject(key, []byte("value")); err != nil {
+				t.Fatalf("Failed to put object: %v", err)
+			}
+		}
+		if err := db.DeleteObject("c"); err != nil {
+			t.Fatalf("Failed to delete object: %v", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatalf("Failed to close database: %v", err)
+		}
+
+		return dbPath
+	}
+
+	t.Run("clean log", func(t *testing.T) {
+		dbPath := setup(t)
+
+		report, err := Verify(dbPath)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if report.Entries != 4 || report.BadEntries != 0 || report.Keys != 2 {
+			t.Errorf("Expected 4 entries, 0 bad and 2 keys, got %+v", report)
+		}
+	})
+
+	t.Run("corrupt log", func(t *testing.T) {
+		dbPath := setup(t)
+
+		file, err := os.OpenFile(dbPath, os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatalf("Failed to open file: %v", err)
+		}
+		_, err = file.WriteString("corrupt line\nput,!!!,dmFsdWU=\nmov,ZA==,dmFsdWU=\nput,ZA==,dmFsdWU=\n")
+		if errClose := file.Close(); err == nil {
+			err = errClose
+		}
+		if err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+
+		report, err := Verify(dbPath)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+
+		if report.Entries != 8 || report.BadEntries != 3 || report.Keys != 3 {
+			t.Errorf("Expected 8 entries, 3 bad and 3 keys, got %+v", report)
+		}
+		if !slices.Equal(report.BadLines, []int{5, 6, 7}) {
+			t.Errorf("Expected bad lines [5 6 7], got %v", report.BadLines)
+		}
+
+		// the corruption Verify reports makes Open fail
+		_, err = Open(dbPath)
+		if err == nil {
+			t.Error("Expected Open to fail on corrupt log")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		report, err := Verify(filepath.Join(t.TempDir(), "missing.db"))
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if report.Entries != 0 {
+			t.Errorf("Expected empty report, got %+v", report)
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package inmemorydb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Merge copies the keys of the database file at srcPath into the database at dstPath
+// and compacts it(see ReplaceAll: the previous dst file is kept at dstPath.bak).
+// Keys present in both databases get the src value if overwrite is true and keep the
+// dst value otherwise. The src file is only read, dst is created if it doesn't exist.
+//
+// Neither database may be open while merging.
+func Merge(dstPath, srcPath string, overwrite bool) error {
+	if dstPath == MemoryPath || srcPath == MemoryPath {
+		return errors.New("inmemorydb: unable to merge memory-only databases")
+	}
+
+	dstAbs, err := filepath.Abs(dstPath)
+	if err != nil {
+		return fmt.Errorf("inmemorydb: unable to resolve %q: %w", dstPath, err)
+	}
+	srcAbs, err := filepath.Abs(srcPath)
+	if err != nil {
+		return fmt.Errorf("inmemorydb: unable to resolve %q: %w", srcPath, err)
+	}
+	if dstAbs == srcAbs {
+		return errors.New("inmemorydb: unable to merge database into itself")
+	}
+
+	// src is read without Open, so it's not compacted
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return fmt.Errorf("inmemorydb: failed file opening: %w", err)
+	}
+	defer srcFile.Close()
+
+	src := make(map[string][]byte)
+	err = replayLog(srcFile, src)
+	if err != nil {
+		return err
+	}
+
+	dst, err := Open(dstPath)
+	if err != nil {
+		return err
+	}
+
+	merged := make(map[string][]byte, dst.Size()+len(src))
+	err = dst.ForEach(func(key string, value []byte) error {
+		merged[key] = value
+		return nil
+	})
+	if err != nil {
+		return errors.Join(err, dst.Close())
+	}
+
+	for key, value := range src {
+		if _, exists := merged[key]; exists && !overwrite {
+			continue
+		}
+		merged[key] = value
+	}
+
+	err = dst.ReplaceAll(merged)
+	if err != nil {
+		return errors.Join(err, dst.Close())
+	}
+
+	return dst.Close()
+}
@@ -0,0 +1,75 @@
+package inmemorydb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// maxReportedBadLines limits the number of bad line numbers kept in VerifyReport.
+const maxReportedBadLines = 100
+
+// VerifyReport is the result of Verify.
+type VerifyReport struct {
+	// Entries is the number of log lines read, BadEntries the number of them that can't be parsed.
+	Entries    int `json:"entries"`
+	BadEntries int `json:"bad_entries"`
+	// BadLines holds the line numbers(starting from 1) of the first bad entries.
+	BadLines []int `json:"bad_lines,omitempty"`
+	// Keys is the number of keys the parsed entries replay to.
+	Keys int `json:"keys"`
+}
+
+// Verify reads the whole database file at filePath and checks that every log entry
+// parses, without stopping at the first bad one like Open does. A missing file is
+// reported as empty. Errors are returned only if the file can't be read.
+//
+// The database may be open while verifying, but buffered writes are not seen.
+func Verify(filePath string) (VerifyReport, error) {
+	var report VerifyReport
+	if filePath == MemoryPath {
+		return report, nil
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return report, nil
+		}
+		return report, fmt.Errorf("inmemorydb: failed file opening: %w", err)
+	}
+	defer file.Close()
+
+	data := make(map[string][]byte)
+
+	scanner := newLineScanner(file)
+	for scanner.Scan() {
+		report.Entries++
+
+		entry, err := newEntryFromLine(scanner.Text())
+		if err == nil && entry.action != Put && entry.action != Del && entry.action != Begin && entry.action != Commit {
+			err = ErrBadFormat
+		}
+		if err != nil {
+			report.BadEntries++
+			if len(report.BadLines) < maxReportedBadLines {
+				report.BadLines = append(report.BadLines, report.Entries)
+			}
+			continue
+		}
+
+		switch entry.action {
+		case Put:
+			data[entry.key] = nil
+		case Del:
+			delete(data, entry.key)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return report, fmt.Errorf("inmemorydb: failed to scan file: %w", err)
+	}
+
+	report.Keys = len(data)
+	return report, nil
+}
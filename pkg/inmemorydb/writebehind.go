@@ -0,0 +1,98 @@
+package inmemorydb
+
+// writeBehind appends queued log entries to the DB writer in background, so writes
+// return after updating memory instead of waiting for the append.
+//
+// Entries are queued under the DB write lock and taken from the queue only under the
+// writer lock(by the background goroutine or by drainWriteQueue), so they are appended
+// in the order they were made. The background goroutine takes only the writer lock,
+// so draining doesn't block reads and writes to memory.
+type writeBehind struct {
+	queue  chan []byte
+	notify chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+
+	// blocked counts writes made synchronously because the queue was full,
+	// guarded by the DB lock
+	blocked int64
+}
+
+func startWriteBehind(db *DB, queueSize int) *writeBehind {
+	wb := &writeBehind{
+		queue:  make(chan []byte, queueSize),
+		notify: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(wb.done)
+
+		for {
+			select {
+			case <-wb.stop:
+				return
+			case <-wb.notify:
+				// Close stops the goroutine before closing the writer
+				db.writeMutex.Lock()
+				// the error is kept by bufio.Writer and reported by the next write or Close
+				_ = db.drainWriteQueue()
+				db.writeMutex.Unlock()
+			}
+		}
+	}()
+
+	return wb
+}
+
+// Stop stops the background goroutine and waits for it to exit. The queue
+// is not drained. Calling Stop more than once is a no-op.
+func (wb *writeBehind) Stop() {
+	select {
+	case <-wb.stop:
+	default:
+		close(wb.stop)
+	}
+
+	<-wb.done
+}
+
+// enqueue queues the entry and wakes the background goroutine. Returns false
+// if the queue is full.
+func (wb *writeBehind) enqueue(e []byte) bool {
+	select {
+	case wb.queue <- e:
+	default:
+		return false
+	}
+
+	select {
+	case wb.notify <- struct{}{}:
+	default:
+		// already notified, the pending drain takes this entry too
+	}
+
+	return true
+}
+
+// drainWriteQueue appends all queued entries to the writer. Anything that writes
+// to the writer directly calls it first, so the queued entries go before.
+// The caller must hold the writer lock.
+func (db *DB) drainWriteQueue() error {
+	if db.writeBehind == nil {
+		return nil
+	}
+
+	for {
+		select {
+		case e := <-db.writeBehind.queue:
+			err := db.writeLine(e)
+			if err != nil {
+				return err
+			}
+		default:
+			return nil
+		}
+	}
+}
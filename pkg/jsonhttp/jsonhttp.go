@@ -10,18 +10,61 @@ import (
 	"strings"
 )
 
+// Errors returned by ReadJSON. Callers can check them with errors.Is,
+// while the returned error message stays human-readable.
+var (
+	ErrEmptyBody      = errors.New("body must not be empty")
+	ErrBodyTooLarge   = errors.New("body is too large")
+	ErrUnknownField   = errors.New("body contains unknown key")
+	ErrMalformedJSON  = errors.New("body contains badly-formated JSON")
+	ErrInvalidType    = errors.New("body contains incorrect JSON type")
+	ErrMultipleValues = errors.New("body must only contain a single JSON value")
+	ErrNotArray       = errors.New("body must be a JSON array")
+)
+
+// readError is an error with a detailed message that matches one of the
+// ReadJSON sentinel errors.
+type readError struct {
+	kind    error
+	message string
+}
+
+func (e *readError) Error() string {
+	return e.message
+}
+
+func (e *readError) Unwrap() error {
+	return e.kind
+}
+
+func newReadError(kind error, format string, args ...any) error {
+	return &readError{kind: kind, message: fmt.Sprintf(format, args...)}
+}
+
 // Envelope is a alias for a map[string]any
 type Envelope map[string]any
 
 // writeJSON is a helper method for writing JSON responses
 func WriteJSON(w http.ResponseWriter, status int, data Envelope, headers http.Header) error {
+	return writeJSON(w, status, data, headers, true)
+}
+
+// WriteJSONNoNewline writes a JSON response like WriteJSON, but without the
+// trailing '\n', so the body is exactly the encoded JSON(e.g. for response hashing).
+func WriteJSONNoNewline(w http.ResponseWriter, status int, data Envelope, headers http.Header) error {
+	return writeJSON(w, status, data, headers, false)
+}
+
+func writeJSON(w http.ResponseWriter, status int, data Envelope, headers http.Header, trailingNewline bool) error {
 	// Convert the data to JSON
 	js, err := json.MarshalIndent(data, "", "\t")
 	if err != nil {
 		return err
 	}
 
-	js = append(js, '\n')
+	if trailingNewline {
+		js = append(js, '\n')
+	}
 
 	// Add provided headers
 	maps.Copy(w.Header(), headers)
@@ -33,56 +76,159 @@ func WriteJSON(w http.ResponseWriter, status int, data Envelope, headers http.He
 	return err
 }
 
+// WriteError writes the standard error response: {"error": message, "code": code}.
+// code is a machine-readable error code and is omitted when empty. message is
+// usually a string, but can be any JSON value(e.g. validation errors map).
+func WriteError(w http.ResponseWriter, status int, code string, message any, headers http.Header) error {
+	data := Envelope{
+		"error": message,
+	}
+
+	if code != "" {
+		data["code"] = code
+	}
+
+	return WriteJSON(w, status, data, headers)
+}
+
+// maxBodyBytes limits the size of the request body read by ReadJSON and ReadJSONArray.
+const maxBodyBytes = 1_048_576 // 1 MB
+
 // readJSON is a helper method for reading JSON requests
 func ReadJSON(w http.ResponseWriter, r *http.Request, dst any) error {
-	maxBytes := 1_048_576 // 1 MB
+	return readJSON(w, r, dst, true)
+}
 
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+// ReadJSONLenient reads a JSON request like ReadJSON, but ignores unknown fields
+// instead of returning ErrUnknownField. It lets clients send extra(e.g. client-only)
+// fields, at the cost of silently ignoring misspelled ones, so strict ReadJSON
+// should be preferred.
+func ReadJSONLenient(w http.ResponseWriter, r *http.Request, dst any) error {
+	return readJSON(w, r, dst, false)
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, dst any, disallowUnknownFields bool) error {
+	err := checkContentLength(r)
+	if err != nil {
+		return err
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBodyBytes))
 
 	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
+	if disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
 
-	err := dec.Decode(dst)
+	err = dec.Decode(dst)
 	if err != nil {
-		var syntaxError *json.SyntaxError
-		var unmarshalTypeError *json.UnmarshalTypeError
-		var invalidUnmarshalError *json.InvalidUnmarshalError
+		return decodeError(err)
+	}
 
-		switch {
-		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly-formated JSON(at character %d)", syntaxError.Offset)
+	err = dec.Decode(&struct{}{})
+	if err != io.EOF {
+		return ErrMultipleValues
+	}
 
-		case errors.Is(err, io.ErrUnexpectedEOF):
-			return errors.New("body contains badly-formated JSON")
+	return nil
+}
 
-		case errors.As(err, &unmarshalTypeError):
-			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
-			}
-			return fmt.Errorf("body contains incorrect JSON type(at character %d)", unmarshalTypeError.Offset)
+// ReadJSONArray reads a request body that must be a top-level JSON array
+// into dst. Elements are decoded one by one with unknown fields disallowed,
+// the same size limit as in ReadJSON applies.
+func ReadJSONArray[T any](w http.ResponseWriter, r *http.Request, dst *[]T) error {
+	err := checkContentLength(r)
+	if err != nil {
+		return err
+	}
 
-		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBodyBytes))
 
-		case strings.HasPrefix(err.Error(), "json: unknown field "):
-			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
 
-		case err.Error() == "http: request body too large":
-			return fmt.Errorf("body must not be larger than %d bytes", maxBytes)
+	tok, err := dec.Token()
+	if err != nil {
+		return decodeError(err)
+	}
 
-		case errors.As(err, &invalidUnmarshalError):
-			panic(err)
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return ErrNotArray
+	}
 
-		default:
-			return err
+	items := []T{}
+	for dec.More() {
+		var item T
+		err = dec.Decode(&item)
+		if err != nil {
+			return decodeError(err)
 		}
+
+		items = append(items, item)
+	}
+
+	// closing bracket
+	_, err = dec.Token()
+	if err != nil {
+		return decodeError(err)
 	}
 
 	err = dec.Decode(&struct{}{})
 	if err != io.EOF {
-		return errors.New("body must only contain a single JSON value")
+		return ErrMultipleValues
 	}
 
+	*dst = items
+	return nil
+}
+
+// checkContentLength returns ErrBodyTooLarge if the request Content-Length
+// exceeds maxBodyBytes, so an oversized body is rejected before reading it.
+// Bodies of unknown length(-1) are limited by MaxBytesReader while reading.
+func checkContentLength(r *http.Request) error {
+	if r.ContentLength > maxBodyBytes {
+		return bodyTooLargeError()
+	}
 	return nil
 }
+
+func bodyTooLargeError() error {
+	return newReadError(ErrBodyTooLarge, "body must not be larger than %d bytes", maxBodyBytes)
+}
+
+// decodeError converts json decoding error into one of the ReadJSON errors.
+func decodeError(err error) error {
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	var invalidUnmarshalError *json.InvalidUnmarshalError
+
+	switch {
+	case errors.As(err, &syntaxError):
+		return newReadError(ErrMalformedJSON, "body contains badly-formated JSON(at character %d)", syntaxError.Offset)
+
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrMalformedJSON
+
+	case errors.As(err, &unmarshalTypeError):
+		if unmarshalTypeError.Field != "" {
+			return newReadError(ErrInvalidType, "body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+		}
+		return newReadError(ErrInvalidType, "body contains incorrect JSON type(at character %d)", unmarshalTypeError.Offset)
+
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		return newReadError(ErrUnknownField, "body contains unknown key %s", fieldName)
+
+	case err.Error() == "http: request body too large":
+		return bodyTooLargeError()
+
+	case errors.As(err, &invalidUnmarshalError):
+		panic(err)
+
+	default:
+		return err
+	}
+}
@@ -141,6 +141,38 @@ func TestWriteJSON(t *testing.T) {
 			t.Errorf("Expected 2 users, got %d", len(users))
 		}
 	})
+
+	t.Run("trailing newline", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			write    func(http.ResponseWriter, int, Envelope, http.Header) error
+			lastByte byte
+		}{
+			{name: "WriteJSON", write: WriteJSON, lastByte: '\n'},
+			{name: "WriteJSONNoNewline", write: WriteJSONNoNewline, lastByte: '}'},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w := httptest.NewRecorder()
+
+				err := tt.write(w, http.StatusOK, Envelope{"test": "data"}, nil)
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+
+				body := w.Body.Bytes()
+				if got := body[len(body)-1]; got != tt.lastByte {
+					t.Errorf("Expected last byte %q, got %q", tt.lastByte, got)
+				}
+
+				var response map[string]string
+				if err := json.Unmarshal(body, &response); err != nil {
+					t.Fatalf("Failed to unmarshal: %v", err)
+				}
+			})
+		}
+	})
 }
 
 func TestReadJSON(t *testing.T) {
@@ -184,6 +216,38 @@ func TestReadJSON(t *testing.T) {
 		}
 	})
 
+	t.Run("lenient variant ignores unknown fields", func(t *testing.T) {
+		var input struct {
+			Title string `json:"title"`
+		}
+
+		jsonData := `{"title":"Test","_dirty":true}`
+		req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(jsonData)))
+		w := httptest.NewRecorder()
+
+		err := ReadJSONLenient(w, req, &input)
+
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if input.Title != "Test" {
+			t.Errorf("Expected title 'Test', got '%s'", input.Title)
+		}
+	})
+
+	t.Run("lenient variant still rejects invalid JSON", func(t *testing.T) {
+		var input map[string]string
+
+		req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(`{"title":"Test"}{}`)))
+		w := httptest.NewRecorder()
+
+		err := ReadJSONLenient(w, req, &input)
+
+		if !errors.Is(err, ErrMultipleValues) {
+			t.Errorf("Expected ErrMultipleValues, got %v", err)
+		}
+	})
+
 	t.Run("rejects invalid JSON", func(t *testing.T) {
 		jsonData := `{"title":"Test",invalid}`
 		req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte(jsonData)))
@@ -211,6 +275,22 @@ func TestReadJSON(t *testing.T) {
 		}
 	})
 
+	t.Run("rejects oversized Content-Length before reading", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(`{"data":"a"}`))
+		req.ContentLength = 1_048_577
+		w := httptest.NewRecorder()
+
+		var input map[string]string
+		err := ReadJSON(w, req, &input)
+
+		if !errors.Is(err, ErrBodyTooLarge) {
+			t.Errorf("Expected ErrBodyTooLarge, got %v", err)
+		}
+		if input != nil {
+			t.Errorf("Expected body not to be decoded, got %v", input)
+		}
+	})
+
 	t.Run("handles empty body", func(t *testing.T) {
 		req := httptest.NewRequest("POST", "/test", bytes.NewReader([]byte("")))
 		w := httptest.NewRecorder()
@@ -320,6 +400,199 @@ func TestReadJSON(t *testing.T) {
 	})
 }
 
+func TestReadJSONErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected error
+		message  string
+	}{
+		{
+			name:     "empty body",
+			body:     "",
+			expected: ErrEmptyBody,
+			message:  "body must not be empty",
+		},
+		{
+			name:     "oversized body",
+			body:     `{"title":"` + strings.Repeat("a", 1_048_577) + `"}`,
+			expected: ErrBodyTooLarge,
+			message:  "body must not be larger than 1048576 bytes",
+		},
+		{
+			name:     "unknown field",
+			body:     `{"unknown":"field"}`,
+			expected: ErrUnknownField,
+			message:  `body contains unknown key "unknown"`,
+		},
+		{
+			name:     "syntax error",
+			body:     `{"title":"Test",invalid}`,
+			expected: ErrMalformedJSON,
+			message:  "body contains badly-formated JSON(at character 17)",
+		},
+		{
+			name:     "unexpected end of body",
+			body:     `{"title":"Test"`,
+			expected: ErrMalformedJSON,
+			message:  "body contains badly-formated JSON",
+		},
+		{
+			name:     "incorrect type",
+			body:     `{"title":1}`,
+			expected: ErrInvalidType,
+			message:  `body contains incorrect JSON type for field "title"`,
+		},
+		{
+			name:     "multiple values",
+			body:     `{"title":"a"}{"title":"b"}`,
+			expected: ErrMultipleValues,
+			message:  "body must only contain a single JSON value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var input struct {
+				Title string `json:"title"`
+			}
+
+			req := httptest.NewRequest("POST", "/test", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			err := ReadJSON(w, req, &input)
+
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected error %v, got %v", tt.expected, err)
+			}
+			if err != nil && err.Error() != tt.message {
+				t.Errorf("Expected message '%s', got '%s'", tt.message, err.Error())
+			}
+		})
+	}
+}
+
+func TestReadJSONArray(t *testing.T) {
+	type item struct {
+		Title string `json:"title"`
+	}
+
+	t.Run("reads valid array", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(`[{"title":"a"},{"title":"b"}]`))
+		w := httptest.NewRecorder()
+
+		var items []item
+		err := ReadJSONArray(w, req, &items)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(items) != 2 || items[0].Title != "a" || items[1].Title != "b" {
+			t.Errorf("Unexpected items: %+v", items)
+		}
+	})
+
+	t.Run("reads empty array", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/test", strings.NewReader(`[]`))
+		w := httptest.NewRecorder()
+
+		var items []item
+		err := ReadJSONArray(w, req, &items)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if items == nil || len(items) != 0 {
+			t.Errorf("Expected empty non-nil slice, got %v", items)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		body     string
+		expected error
+	}{
+		{"object", `{"title":"a"}`, ErrNotArray},
+		{"array with trailing object", `[{"title":"a"}]{"title":"b"}`, ErrMultipleValues},
+		{"empty body", ``, ErrEmptyBody},
+		{"unknown field in element", `[{"title":"a","unknown":1}]`, ErrUnknownField},
+		{"incorrect element type", `[{"title":1}]`, ErrInvalidType},
+		{"unclosed array", `[{"title":"a"}`, ErrMalformedJSON},
+		{"oversized body", `[{"title":"` + strings.Repeat("a", 1_048_577) + `"}]`, ErrBodyTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/test", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			var items []item
+			err := ReadJSONArray(w, req, &items)
+
+			if !errors.Is(err, tt.expected) {
+				t.Errorf("Expected error %v, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	t.Run("writes error shape with status and headers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		headers := http.Header{"Retry-After": []string{"1"}}
+
+		err := WriteError(w, http.StatusTooManyRequests, "rate_limited", "rate limit exceeded", headers)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected status %d, got %d", http.StatusTooManyRequests, w.Code)
+		}
+		if w.Header().Get("Retry-After") != "1" {
+			t.Errorf("Expected Retry-After '1', got '%s'", w.Header().Get("Retry-After"))
+		}
+
+		var response map[string]any
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+
+		if len(response) != 2 {
+			t.Errorf("Expected 2 fields, got %v", response)
+		}
+		if response["error"] != "rate limit exceeded" {
+			t.Errorf("Expected error 'rate limit exceeded', got %v", response["error"])
+		}
+		if response["code"] != "rate_limited" {
+			t.Errorf("Expected code 'rate_limited', got %v", response["code"])
+		}
+	})
+
+	t.Run("omits empty code", func(t *testing.T) {
+		w := httptest.NewRecorder()
+
+		err := WriteError(w, http.StatusBadRequest, "", map[string]string{"title": "must be provided"}, nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		var response map[string]any
+		err = json.Unmarshal(w.Body.Bytes(), &response)
+		if err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+
+		if _, exists := response["code"]; exists {
+			t.Error("Expected code to be omitted")
+		}
+		if _, ok := response["error"].(map[string]any); !ok {
+			t.Errorf("Expected error to be an object, got %v", response["error"])
+		}
+	})
+}
+
 func TestWriteJSONWithFail(t *testing.T) {
 	t.Run("handles write errors", func(t *testing.T) {
 		w := &failingWriter{}
@@ -0,0 +1,62 @@
+// Package mergepatch implements JSON Merge Patch(RFC 7386).
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Apply applies the merge patch to the target JSON document and returns the result.
+// Object members of the patch are merged recursively: null removes the member from
+// the target and absent members are left untouched. A patch that is not an object
+// replaces the target entirely. Empty target is treated as null.
+func Apply(target, patch []byte) ([]byte, error) {
+	var t any
+	if len(bytes.TrimSpace(target)) > 0 {
+		err := unmarshal(target, &t)
+		if err != nil {
+			return nil, fmt.Errorf("mergepatch: invalid target: %w", err)
+		}
+	}
+
+	var p any
+	err := unmarshal(patch, &p)
+	if err != nil {
+		return nil, fmt.Errorf("mergepatch: invalid patch: %w", err)
+	}
+
+	return json.Marshal(merge(t, p))
+}
+
+// merge is the MergePatch function from RFC 7386.
+func merge(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+
+	for key, value := range p {
+		if value == nil {
+			delete(t, key)
+			continue
+		}
+
+		t[key] = merge(t[key], value)
+	}
+
+	return t
+}
+
+// unmarshal decodes data keeping numbers as json.Number, so large integers
+// are not rounded.
+func unmarshal(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
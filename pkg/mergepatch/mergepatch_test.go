@@ -0,0 +1,72 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	// test cases from RFC 7386 Appendix A
+	tests := []struct {
+		target   string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{``, `{"a":"b"}`, `{"a":"b"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" + "+tt.patch, func(t *testing.T) {
+			result, err := Apply([]byte(tt.target), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+
+			var got, expected any
+			if err := json.Unmarshal(result, &got); err != nil {
+				t.Fatalf("Failed to unmarshal result: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("Failed to unmarshal expected: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, result)
+			}
+		})
+	}
+
+	t.Run("keeps large integers", func(t *testing.T) {
+		result, err := Apply([]byte(`{"id":9007199254740993}`), []byte(`{"a":1}`))
+		if err != nil {
+			t.Fatalf("Apply failed: %v", err)
+		}
+
+		if string(result) != `{"a":1,"id":9007199254740993}` {
+			t.Errorf("Unexpected result: %s", result)
+		}
+	})
+
+	t.Run("returns error for invalid patch", func(t *testing.T) {
+		_, err := Apply([]byte(`{}`), []byte(`{`))
+		if err == nil {
+			t.Error("Expected error for invalid patch")
+		}
+	})
+}
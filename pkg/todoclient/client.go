@@ -0,0 +1,407 @@
+// Package todoclient provides a Go client for the todo API.
+package todoclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors matched by APIError with errors.Is, depending on the response status code.
+var (
+	ErrBadRequest = errors.New("bad request")
+	ErrNotFound   = errors.New("not found")
+	ErrConflict   = errors.New("conflict")
+	ErrServer     = errors.New("server error")
+)
+
+// APIError is returned for non-2xx responses.
+type APIError struct {
+	StatusCode int
+	// Code is the machine-readable error code(e.g. not_found, validation_failed).
+	Code string
+	// Message is the error message, empty for validation errors.
+	Message string
+	// RequestID references the server error in the server logs.
+	RequestID string
+	// Errors holds validation errors by field.
+	Errors map[string]string
+
+	// validation is returned by As for validation errors.
+	validation *ValidationError
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) > 0 {
+		return fmt.Sprintf("todoclient: %d %s: %v", e.StatusCode, e.Code, e.Errors)
+	}
+	return fmt.Sprintf("todoclient: %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// As makes errors.As find a *ValidationError in the error of a rejected request body.
+func (e *APIError) As(target any) bool {
+	v, ok := target.(**ValidationError)
+	if !ok || e.validation == nil {
+		return false
+	}
+
+	*v = e.validation
+	return true
+}
+
+// Unwrap returns the sentinel error for the status code, or nil for other codes.
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode >= 500:
+		return ErrServer
+	default:
+		return nil
+	}
+}
+
+// ValidationError describes the fields the server rejected(validation_failed responses).
+// It is found with errors.As in the errors returned by the client:
+//
+//	var validationErr *todoclient.ValidationError
+//	if errors.As(err, &validationErr) {
+//		fmt.Println(validationErr.Fields["title"])
+//	}
+type ValidationError struct {
+	// Fields maps the field(e.g. title, tasks[0].title) to its error messages.
+	Fields map[string][]string
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, messages := range e.Fields {
+		fields = append(fields, field+": "+strings.Join(messages, ", "))
+	}
+	slices.Sort(fields)
+
+	return "todoclient: validation failed: " + strings.Join(fields, "; ")
+}
+
+type Subtask struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+type Task struct {
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Done        bool       `json:"done"`
+	Subtasks    []Subtask  `json:"subtasks,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Version     int        `json:"version"`
+	Position    int        `json:"position"`
+	Progress    float64    `json:"progress"`
+}
+
+// UnmarshalJSON accepts the id both as a number and as a string, as the server
+// can be configured to send ids as strings(API_TODO_STRING_IDS).
+func (t *Task) UnmarshalJSON(data []byte) error {
+	type task Task
+
+	aux := struct {
+		*task
+		ID json.Number `json:"id"`
+	}{
+		task: (*task)(t),
+	}
+
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return err
+	}
+
+	if aux.ID == "" {
+		return nil
+	}
+
+	t.ID, err = aux.ID.Int64()
+	if err != nil {
+		return fmt.Errorf("todoclient: invalid task id %q: %w", aux.ID, err)
+	}
+
+	return nil
+}
+
+type CreateTaskInput struct {
+	ID          int64      `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Subtasks    []Subtask  `json:"subtasks,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+}
+
+type UpdateTaskInput struct {
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Done        bool       `json:"done"`
+	Subtasks    []Subtask  `json:"subtasks,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	DueDate     *time.Time `json:"due_date,omitempty"`
+	Priority    string     `json:"priority,omitempty"`
+}
+
+// ListOptions are the optional ListTasks parameters, zero values are not sent.
+type ListOptions struct {
+	Page     int
+	PageSize int
+	Sort     string
+
+	// Filters, combined with AND.
+	Done     *bool
+	Priority string
+	Tag      string
+	Query    string
+}
+
+// TaskList is a page of tasks with the total number of tasks.
+type TaskList struct {
+	Tasks []Task
+	Total int
+}
+
+// Client is the todo API client.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New returns a client for the API at baseURL(including the base path, e.g.
+// http://localhost:4000/v1). http.DefaultClient is used if httpClient is nil.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// CreateTask creates a task and returns it.
+func (c *Client) CreateTask(ctx context.Context, input CreateTaskInput) (*Task, error) {
+	var resp struct {
+		Task *Task `json:"task"`
+	}
+
+	err := c.do(ctx, http.MethodPost, "/todos", input, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Task, nil
+}
+
+// GetTask returns the task with the given id.
+func (c *Client) GetTask(ctx context.Context, id int64) (*Task, error) {
+	var resp struct {
+		Task *Task `json:"task"`
+	}
+
+	err := c.do(ctx, http.MethodGet, "/todos/"+strconv.FormatInt(id, 10), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Task, nil
+}
+
+// ListTasks returns a page of tasks. opts may be nil.
+func (c *Client) ListTasks(ctx context.Context, opts *ListOptions) (*TaskList, error) {
+	query := url.Values{}
+	if opts != nil {
+		if opts.Page > 0 {
+			query.Set("page", strconv.Itoa(opts.Page))
+		}
+		if opts.PageSize > 0 {
+			query.Set("page_size", strconv.Itoa(opts.PageSize))
+		}
+		if opts.Sort != "" {
+			query.Set("sort", opts.Sort)
+		}
+		if opts.Done != nil {
+			query.Set("done", strconv.FormatBool(*opts.Done))
+		}
+		if opts.Priority != "" {
+			query.Set("priority", opts.Priority)
+		}
+		if opts.Tag != "" {
+			query.Set("tag", opts.Tag)
+		}
+		if opts.Query != "" {
+			query.Set("q", opts.Query)
+		}
+	}
+
+	path := "/todos"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp struct {
+		Tasks []Task `json:"tasks"`
+	}
+
+	header, err := c.doWithHeader(ctx, http.MethodGet, path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &TaskList{Tasks: resp.Tasks, Total: len(resp.Tasks)}
+	if total, err := strconv.Atoi(header.Get("X-Total-Count")); err == nil {
+		list.Total = total
+	}
+
+	return list, nil
+}
+
+// UpdateTask replaces the task with the given id and returns the updated task.
+func (c *Client) UpdateTask(ctx context.Context, id int64, input UpdateTaskInput) (*Task, error) {
+	var resp struct {
+		Task *Task `json:"task"`
+	}
+
+	err := c.do(ctx, http.MethodPut, "/todos/"+strconv.FormatInt(id, 10), input, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Task, nil
+}
+
+// DeleteTask deletes the task with the given id.
+func (c *Client) DeleteTask(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, "/todos/"+strconv.FormatInt(id, 10), nil, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, dst any) error {
+	_, err := c.doWithHeader(ctx, method, path, body, dst)
+	return err
+}
+
+// doWithHeader sends the request with body encoded as JSON(if not nil), decodes
+// a 2xx response into dst(if not nil) and returns the response headers.
+// Non-2xx responses are returned as *APIError.
+func (c *Client) doWithHeader(ctx context.Context, method, path string, body, dst any) (http.Header, error) {
+	var reqBody io.Reader
+	if body != nil {
+		js, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("todoclient: unable to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(js)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("todoclient: unable to create request: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("todoclient: request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, decodeError(resp)
+	}
+
+	if dst != nil {
+		err = json.NewDecoder(resp.Body).Decode(dst)
+		if err != nil {
+			return nil, fmt.Errorf("todoclient: unable to decode response: %w", err)
+		}
+	}
+
+	return resp.Header, nil
+}
+
+// decodeError reads the {"error": ..., "code": ...} response body. The error is
+// a string, a map of validation errors(validation_failed code) or, for server
+// errors, the message with the request id.
+func decodeError(resp *http.Response) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+
+	var body struct {
+		Error json.RawMessage `json:"error"`
+		Code  string          `json:"code"`
+	}
+
+	err := json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+		return apiErr
+	}
+
+	apiErr.Code = body.Code
+	if json.Unmarshal(body.Error, &apiErr.Message) == nil {
+		return apiErr
+	}
+
+	var fields map[string]json.RawMessage
+	_ = json.Unmarshal(body.Error, &fields)
+	if body.Code != "validation_failed" {
+		_ = json.Unmarshal(fields["message"], &apiErr.Message)
+		_ = json.Unmarshal(fields["request_id"], &apiErr.RequestID)
+		return apiErr
+	}
+
+	if len(fields) == 0 {
+		return apiErr
+	}
+
+	apiErr.Errors = make(map[string]string, len(fields))
+	apiErr.validation = &ValidationError{Fields: make(map[string][]string, len(fields))}
+	for field, raw := range fields {
+		messages := fieldMessages(raw)
+		apiErr.Errors[field] = strings.Join(messages, ", ")
+		apiErr.validation.Fields[field] = messages
+	}
+
+	return apiErr
+}
+
+// fieldMessages decodes the error messages of a field, sent as a string
+// or as a list of strings.
+func fieldMessages(raw json.RawMessage) []string {
+	var message string
+	if json.Unmarshal(raw, &message) == nil {
+		return []string{message}
+	}
+
+	var messages []string
+	_ = json.Unmarshal(raw, &messages)
+	return messages
+}
@@ -0,0 +1,207 @@
+package todoclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/vladgrskkh/todo/config"
+	"github.com/vladgrskkh/todo/internal/handlers/routes"
+	"github.com/vladgrskkh/todo/internal/repository"
+	"github.com/vladgrskkh/todo/internal/service"
+	"github.com/vladgrskkh/todo/internal/testutil"
+)
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+
+	logger := slog.New(slog.NewJSONHandler(io.Discard, nil))
+	s := service.NewTodoService(logger, repository.NewTaskRepo(testutil.NewMapKV()))
+
+	srv := httptest.NewServer(routes.Routes(logger, s, &config.Config{Env: "test", BasePath: "/v1"}))
+	t.Cleanup(srv.Close)
+
+	return New(srv.URL+"/v1", srv.Client())
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	created, err := client.CreateTask(ctx, CreateTaskInput{ID: 1, Title: "Task", Tags: []string{"work"}})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+	if created.ID != 1 || created.Title != "Task" || created.Version != 1 {
+		t.Errorf("Unexpected created task: %+v", created)
+	}
+
+	_, err = client.CreateTask(ctx, CreateTaskInput{ID: 2, Title: "Second", Priority: "high"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	task, err := client.GetTask(ctx, 1)
+	if err != nil {
+		t.Fatalf("Failed to get task: %v", err)
+	}
+	if task.Title != "Task" || len(task.Tags) != 1 || task.Tags[0] != "work" {
+		t.Errorf("Unexpected task: %+v", task)
+	}
+
+	list, err := client.ListTasks(ctx, &ListOptions{PageSize: 1, Sort: "position"})
+	if err != nil {
+		t.Fatalf("Failed to list tasks: %v", err)
+	}
+	if list.Total != 2 || len(list.Tasks) != 1 || list.Tasks[0].ID != 1 {
+		t.Errorf("Unexpected task list: %+v", list)
+	}
+
+	notDone := false
+	list, err = client.ListTasks(ctx, &ListOptions{Done: &notDone, Priority: "high", Query: "sec"})
+	if err != nil {
+		t.Fatalf("Failed to list tasks: %v", err)
+	}
+	if list.Total != 1 || list.Tasks[0].ID != 2 || list.Tasks[0].Priority != "high" {
+		t.Errorf("Unexpected filtered task list: %+v", list)
+	}
+
+	updated, err := client.UpdateTask(ctx, 1, UpdateTaskInput{Title: "Updated", Done: true})
+	if err != nil {
+		t.Fatalf("Failed to update task: %v", err)
+	}
+	if updated.Title != "Updated" || !updated.Done || updated.Version != 2 {
+		t.Errorf("Unexpected updated task: %+v", updated)
+	}
+
+	err = client.DeleteTask(ctx, 1)
+	if err != nil {
+		t.Fatalf("Failed to delete task: %v", err)
+	}
+
+	_, err = client.GetTask(ctx, 1)
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	ctx := context.Background()
+	client := newTestClient(t)
+
+	_, err := client.CreateTask(ctx, CreateTaskInput{ID: 1, Title: "Task"})
+	if err != nil {
+		t.Fatalf("Failed to create task: %v", err)
+	}
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.GetTask(ctx, 100)
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Expected *APIError, got %v", err)
+		}
+		if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != "not_found" || apiErr.Message == "" {
+			t.Errorf("Unexpected error: %+v", apiErr)
+		}
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		_, err := client.CreateTask(ctx, CreateTaskInput{ID: 1, Title: "Duplicate"})
+		if !errors.Is(err, ErrConflict) {
+			t.Errorf("Expected ErrConflict, got %v", err)
+		}
+	})
+
+	t.Run("validation errors", func(t *testing.T) {
+		_, err := client.CreateTask(ctx, CreateTaskInput{ID: 2})
+
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("Expected *APIError, got %v", err)
+		}
+		if !errors.Is(err, ErrBadRequest) {
+			t.Errorf("Expected ErrBadRequest, got %v", err)
+		}
+		if apiErr.Code != "validation_failed" || apiErr.Errors["title"] == "" {
+			t.Errorf("Expected title validation error, got %+v", apiErr)
+		}
+
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("Expected *ValidationError, got %v", err)
+		}
+		if len(validationErr.Fields) != 1 || len(validationErr.Fields["title"]) != 1 || validationErr.Fields["title"][0] != apiErr.Errors["title"] {
+			t.Errorf("Expected title validation error, got %+v", validationErr.Fields)
+		}
+	})
+
+	t.Run("other errors are not validation errors", func(t *testing.T) {
+		_, err := client.GetTask(ctx, 100)
+
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			t.Errorf("Expected no *ValidationError, got %v", validationErr)
+		}
+	})
+}
+
+func TestDecodeValidationError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		io.WriteString(w, `{"error":{"title":"must be provided","tags":["too many tags","duplicate tag"]},"code":"validation_failed"}`)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, srv.Client()).GetTask(context.Background(), 1)
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("Expected *ValidationError, got %v", err)
+	}
+
+	expected := map[string][]string{
+		"title": {"must be provided"},
+		"tags":  {"too many tags", "duplicate tag"},
+	}
+	if !reflect.DeepEqual(validationErr.Fields, expected) {
+		t.Errorf("Expected fields %v, got %v", expected, validationErr.Fields)
+	}
+	if validationErr.Error() != "todoclient: validation failed: tags: too many tags, duplicate tag; title: must be provided" {
+		t.Errorf("Unexpected error message: %s", validationErr.Error())
+	}
+}
+
+func TestDecodeCorruptDataError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		io.WriteString(w, `{"error":{"message":"stored data is corrupt and could not be read","request_id":"abc"},"code":"data_corrupt"}`)
+	}))
+	defer srv.Close()
+
+	_, err := New(srv.URL, srv.Client()).GetTask(context.Background(), 1)
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		t.Fatalf("Expected no *ValidationError, got %v", validationErr)
+	}
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("Expected *APIError, got %v", err)
+	}
+	if apiErr.Code != "data_corrupt" || apiErr.RequestID != "abc" || apiErr.Errors != nil {
+		t.Errorf("Expected data_corrupt error with request id abc and no field errors, got %+v", apiErr)
+	}
+	if apiErr.Message != "stored data is corrupt and could not be read" {
+		t.Errorf("Unexpected message: %s", apiErr.Message)
+	}
+}
@@ -1,8 +1,25 @@
 package validator
 
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Code is a machine-readable error code with the arguments used to format the
+// error message, so the message can be translated.
+type Code struct {
+	Code string
+	Args []any
+}
+
 // Validator is a struct that holds a map of validation errors.
 type Validator struct {
 	Errors map[string]string
+
+	// Codes holds codes of the errors added with AddErrorCode or CheckCode,
+	// keyed like Errors.
+	Codes map[string]Code
 }
 
 // New returns a new Validator instance with an empty Errors map.
@@ -10,6 +27,7 @@ type Validator struct {
 func New() *Validator {
 	return &Validator{
 		Errors: make(map[string]string),
+		Codes:  make(map[string]Code),
 	}
 }
 
@@ -40,3 +58,62 @@ func (v *Validator) Check(ok bool, key, message string) {
 		v.AddError(key, message)
 	}
 }
+
+// AddErrorCode adds an error with the given code to the validator for the given key.
+// The message is format formatted with args. If the key already exists in the Errors
+// map, the error is not added.
+func (v *Validator) AddErrorCode(key, code, format string, args ...any) {
+	if _, exist := v.Errors[key]; exist {
+		return
+	}
+
+	if v.Codes == nil {
+		v.Codes = make(map[string]Code)
+	}
+
+	v.Errors[key] = fmt.Sprintf(format, args...)
+	v.Codes[key] = Code{Code: code, Args: args}
+}
+
+// CheckCode adds an error with the given code to the validator for the given key
+// if the condition ok is false(calls the AddErrorCode method).
+func (v *Validator) CheckCode(ok bool, key, code, format string, args ...any) {
+	if !ok {
+		v.AddErrorCode(key, code, format, args...)
+	}
+}
+
+// Merge copies errors of other into the validator under keys prefixed with
+// prefix and a dot(e.g. "subtasks[0]" and "title" give "subtasks[0].title"),
+// empty prefix keeps the keys as is. Codes are copied with their errors.
+// Like AddError, existing errors are not overwritten.
+func (v *Validator) Merge(prefix string, other *Validator) {
+	for otherKey, message := range other.Errors {
+		key := otherKey
+		if prefix != "" {
+			key = prefix + "." + otherKey
+		}
+
+		if _, exist := v.Errors[key]; exist {
+			continue
+		}
+		v.Errors[key] = message
+
+		code, ok := other.Codes[otherKey]
+		if !ok {
+			continue
+		}
+		if v.Codes == nil {
+			v.Codes = make(map[string]Code)
+		}
+		v.Codes[key] = code
+	}
+}
+
+// NoControlChars returns true if s contains no control characters(newlines, NUL, etc.),
+// tab is allowed.
+func NoControlChars(s string) bool {
+	return !strings.ContainsFunc(s, func(r rune) bool {
+		return r != '\t' && unicode.IsControl(r)
+	})
+}
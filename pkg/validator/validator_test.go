@@ -160,3 +160,123 @@ func TestValidatorIntegration(t *testing.T) {
 		}
 	})
 }
+
+func TestCheckCode(t *testing.T) {
+	t.Run("adds formatted message and code", func(t *testing.T) {
+		v := New()
+
+		v.CheckCode(false, "title", "too_long", "must not be more than %d symbols long", 100)
+
+		if v.Errors["title"] != "must not be more than 100 symbols long" {
+			t.Errorf("Unexpected message: '%s'", v.Errors["title"])
+		}
+		if code := v.Codes["title"]; code.Code != "too_long" || len(code.Args) != 1 || code.Args[0] != 100 {
+			t.Errorf("Unexpected code: %+v", code)
+		}
+	})
+
+	t.Run("does not add error when ok", func(t *testing.T) {
+		v := New()
+
+		v.CheckCode(true, "title", "required", "must be provided")
+
+		if !v.Valid() || len(v.Codes) != 0 {
+			t.Errorf("Expected no errors, got %v %v", v.Errors, v.Codes)
+		}
+	})
+
+	t.Run("keeps first error for the key", func(t *testing.T) {
+		v := New()
+
+		v.CheckCode(false, "title", "required", "must be provided")
+		v.CheckCode(false, "title", "too_long", "must not be more than %d symbols long", 100)
+
+		if v.Errors["title"] != "must be provided" || v.Codes["title"].Code != "required" {
+			t.Errorf("Expected first error to be kept, got '%s' %+v", v.Errors["title"], v.Codes["title"])
+		}
+	})
+}
+
+func TestMerge(t *testing.T) {
+	t.Run("copies errors under prefixed keys", func(t *testing.T) {
+		v := New()
+
+		first := New()
+		first.CheckCode(false, "title", "required", "must be provided")
+		second := New()
+		second.AddError("title", "must not be more than 100 symbols long")
+
+		v.Merge("subtasks[0]", first)
+		v.Merge("subtasks[1]", second)
+
+		if len(v.Errors) != 2 {
+			t.Fatalf("Expected 2 errors, got %d", len(v.Errors))
+		}
+		if msg := v.Errors["subtasks[0].title"]; msg != "must be provided" {
+			t.Errorf("Expected 'must be provided' for subtasks[0].title, got '%s'", msg)
+		}
+		if msg := v.Errors["subtasks[1].title"]; msg != "must not be more than 100 symbols long" {
+			t.Errorf("Expected length error for subtasks[1].title, got '%s'", msg)
+		}
+
+		if code := v.Codes["subtasks[0].title"]; code.Code != "required" {
+			t.Errorf("Expected code 'required' for subtasks[0].title, got '%s'", code.Code)
+		}
+		if _, exists := v.Codes["subtasks[1].title"]; exists {
+			t.Error("Expected no code for error added without code")
+		}
+	})
+
+	t.Run("keeps first error for same key", func(t *testing.T) {
+		v := New()
+		v.AddError("subtasks[0].title", "first error")
+
+		other := New()
+		other.AddError("title", "second error")
+
+		v.Merge("subtasks[0]", other)
+
+		if msg := v.Errors["subtasks[0].title"]; msg != "first error" {
+			t.Errorf("Expected original error message 'first error', got '%s'", msg)
+		}
+	})
+
+	t.Run("empty prefix keeps keys", func(t *testing.T) {
+		v := New()
+
+		other := New()
+		other.AddError("title", "error message")
+
+		v.Merge("", other)
+
+		if _, exists := v.Errors["title"]; !exists {
+			t.Error("Expected error for key 'title' to exist")
+		}
+	})
+}
+
+func TestNoControlChars(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "plain text", input: "Buy milk", expected: true},
+		{name: "unicode text", input: "Купить молоко 🥛", expected: true},
+		{name: "tab", input: "a\tb", expected: true},
+		{name: "empty", input: "", expected: true},
+		{name: "newline", input: "a\nb", expected: false},
+		{name: "carriage return", input: "a\rb", expected: false},
+		{name: "NUL", input: "a\x00b", expected: false},
+		{name: "DEL", input: "a\x7fb", expected: false},
+		{name: "C1 control", input: "a\u0085b", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NoControlChars(tt.input); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}